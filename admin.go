@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+)
+
+// defaultAdminAddr keeps the management endpoints off the network unless an operator opts in
+const defaultAdminAddr = "127.0.0.1:8001"
+
+// newAdminMux builds the management API served on the admin port:
+//
+//	/admin/pending, /admin/pending/apply   dry-run review (replayed against live)
+//	/admin/backup/verify                   restore drill of tasksFile's backup, written by storage
+//	/admin/check                           consistency check; POST also repairs
+//	/admin/ui/                             admin area pages, which hold no data of their own
+//	/admin/summary, /admin/backups         admin area data
+//	/admin/save, /admin/reload             save or reload the tasks now
+//	/admin/jobs, /admin/jobs/{name}/run    background job status and run-now
+//	/metrics                               Prometheus text-format gauges
+//	/debug/pprof/                          runtime profiles
+//
+// Every /admin/ endpoint but the pages needs the admin role when users are configured: being
+// reachable only from the local machine keeps out the network, not other local processes.
+func newAdminMux(live http.Handler, pendingFile, tasksFile, storage string) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/pending", LogRequestDuration(Authenticate(PendingChanges(live, pendingFile))))
+	mux.Handle("/admin/pending/apply", LogRequestDuration(Authenticate(PendingChanges(live, pendingFile))))
+	mux.Handle("/admin/backup/verify", LogRequestDuration(Authenticate(VerifyBackup(tasksFile+".bak", storage))))
+	mux.Handle("/admin/check", LogRequestDuration(Authenticate(http.HandlerFunc(ConsistencyCheck))))
+	mux.Handle("/admin/ui/", AdminUI())
+	mux.Handle("/admin/ui", http.RedirectHandler("/admin/ui/", http.StatusMovedPermanently))
+	mux.Handle("/admin/summary", Authenticate(http.HandlerFunc(Summary)))
+	mux.Handle("/admin/backups", Authenticate(Backups(tasksFile)))
+	mux.Handle("/admin/save", LogRequestDuration(Authenticate(http.HandlerFunc(SaveNow))))
+	mux.Handle("/admin/reload", LogRequestDuration(Authenticate(http.HandlerFunc(Reload))))
+	mux.Handle("/admin/loglevel", LogRequestDuration(Authenticate(http.HandlerFunc(LogLevel))))
+	mux.Handle("/admin/jobs", LogRequestDuration(Authenticate(http.HandlerFunc(ScheduledJobs))))
+	mux.Handle("/admin/jobs/", LogRequestDuration(Authenticate(http.HandlerFunc(ScheduledJobs))))
+	mux.HandleFunc("/metrics", Metrics)
+	mux.HandleFunc("/healthz", Health)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// Metrics serves GET /metrics in the Prometheus text exposition format
+func Metrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	tasks := countTasks(taskStore.List(), clock.Now())
+	events := taskEvents.stats()
+	counterMutex.Lock()
+	counterCount := len(counters)
+	counterMutex.Unlock()
+	storage := storageBreaker.stats()
+	degraded := 0
+	if storage.Degraded {
+		degraded = 1
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeGauge(w, "task_tracker_tasks", "Number of tasks", tasks.Total)
+	writeGauge(w, "task_tracker_tasks_completed", "Number of completed tasks", tasks.Completed)
+	writeGauge(w, "task_tracker_tasks_overdue", "Number of open tasks past their due date", tasks.Overdue)
+	writeCounter(w, "task_tracker_tasks_created_total", "Tasks created since startup", events.Created)
+	writeCounter(w, "task_tracker_tasks_completed_total", "Tasks marked completed since startup", events.Completed)
+	fmt.Fprintf(w, "# HELP task_tracker_task_completion_seconds Time from creation to completion of tasks completed since startup\n# TYPE task_tracker_task_completion_seconds summary\n")
+	fmt.Fprintf(w, "task_tracker_task_completion_seconds_sum %g\ntask_tracker_task_completion_seconds_count %d\n", events.CompletionSeconds, events.CompletedTimed)
+	writeGauge(w, "task_tracker_counters", "Number of counters", counterCount)
+	writeGauge(w, "task_tracker_event_streams", "Clients connected to GET /events", taskChanges.subscriberCount())
+	writeGauge(w, "task_tracker_storage_degraded", "1 while the storage circuit breaker is open", degraded)
+	writeGauge(w, "task_tracker_storage_consecutive_failures", "Consecutive failed storage operations", storage.ConsecutiveFailures)
+	writeCounter(w, "task_tracker_storage_retries_total", "Storage operations retried", storage.Retries)
+	limiter := concurrency.stats()
+	writeGauge(w, "task_tracker_requests_in_flight", "Requests being handled, when MAX_IN_FLIGHT is set", limiter.InFlight)
+	writeGauge(w, "task_tracker_requests_queued", "Requests waiting for a slot", limiter.Queued)
+	writeCounter(w, "task_tracker_requests_shed_total", "Requests rejected with 503 because the server was overloaded", limiter.Shed)
+	storageMetrics.writeMetrics(w)
+}
+
+func writeGauge(w http.ResponseWriter, name, help string, value int) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, value)
+}
+
+func writeCounter(w http.ResponseWriter, name, help string, value int) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", name, help, name, name, value)
+}
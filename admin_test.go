@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminMux(t *testing.T) {
+	useTasks(t, []Task{{ID: 1, Title: "Open"}, {ID: 2, Title: "Done", Completed: true}})
+	resetStorageBreaker(t)
+	mux := newAdminMux(http.NotFoundHandler(), filepath.Join(t.TempDir(), "pending.jsonl"), filepath.Join(t.TempDir(), "tasks.json"), "file")
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		contains     string
+	}{
+		{"Metrics", "GET", "/metrics", http.StatusOK, "task_tracker_tasks_completed 1\n"},
+		{"Metrics wrong method", "POST", "/metrics", http.StatusMethodNotAllowed, "Method Not Allowed"},
+		{"Pending changes", "GET", "/admin/pending", http.StatusOK, "[]"},
+		{"No backup yet", "GET", "/admin/backup/verify", http.StatusNotFound, "No backup found"},
+		{"Profile index", "GET", "/debug/pprof/", http.StatusOK, "goroutine"},
+		{"Task API not mounted", "GET", "/tasks", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("expected body to contain %q, got %q", tt.contains, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestTaskDomainMetrics(t *testing.T) {
+	fake := useFakeClock(t, testNow)
+	useTasks(t, []Task{{ID: 1, Title: "Overdue", DueDate: "2024-04-30T00:00:00Z"}, {ID: 2, Title: "Saved before timestamps"}})
+	original := taskEvents
+	taskEvents = &taskEventCounters{}
+	t.Cleanup(func() { taskEvents = original })
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title": "Quick win"}`))
+	Tasks(httptest.NewRecorder(), req)
+	fake.Advance(90 * time.Minute)
+	for i := 0; i < 2; i++ {
+		// Completing an already completed task isn't counted twice
+		req = httptest.NewRequest(http.MethodPut, "/tasks/3", strings.NewReader(`{"title": "Quick win", "completed": true}`))
+		Tasks(httptest.NewRecorder(), req)
+	}
+	// Tasks without created_at count as completed but stay out of the latency summary
+	req = httptest.NewRequest(http.MethodPut, "/tasks/2", strings.NewReader(`{"title": "Saved before timestamps", "completed": true}`))
+	Tasks(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	Metrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		"task_tracker_tasks_overdue 1\n",
+		"task_tracker_tasks_created_total 1\n",
+		"task_tracker_tasks_completed_total 2\n",
+		"task_tracker_task_completion_seconds_sum 5400\n",
+		"task_tracker_task_completion_seconds_count 1\n",
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"cmp"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+)
+
+// adminUIFiles is the admin area's static page, script, and stylesheet
+//
+//go:embed ui/admin
+var adminUIFiles embed.FS
+
+// AdminUI serves the admin area's assets under /admin/ui/. They hold no data, so they are public; the
+// page asks for an admin's API key when users are configured and sends it with every call.
+func AdminUI() http.Handler {
+	assets, err := fs.Sub(adminUIFiles, "ui/admin")
+	if err != nil {
+		panic(err)
+	}
+	site, err := newStaticSite(assets)
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/admin/ui/", site)
+}
+
+// AdminSummary is GET /admin/summary, the figures behind /metrics in one JSON document
+type AdminSummary struct {
+	Tasks             TaskCounts                         `json:"tasks"`
+	CreatedTotal      int                                `json:"created_total"`
+	CompletedTotal    int                                `json:"completed_total"`
+	Storage           StorageStats                       `json:"storage"`
+	Requests          RequestStats                       `json:"requests"`
+	StorageOperations map[string]StorageOperationSummary `json:"storage_operations"`
+}
+
+// TaskCounts counts tasks by state
+type TaskCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Overdue   int `json:"overdue"`
+}
+
+// RequestStats reports load shedding; all zero unless MAX_IN_FLIGHT is set
+type RequestStats struct {
+	InFlight int `json:"in_flight"`
+	Queued   int `json:"queued"`
+	Shed     int `json:"shed"`
+}
+
+// countTasks counts list by state as of now
+func countTasks(list []Task, now time.Time) TaskCounts {
+	counts := TaskCounts{Total: len(list)}
+	for _, t := range list {
+		if t.Completed {
+			counts.Completed++
+		}
+		if t.isOverdue(now) {
+			counts.Overdue++
+		}
+	}
+	return counts
+}
+
+// Summary serves GET /admin/summary
+func Summary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	events := taskEvents.stats()
+	limiter := concurrency.stats()
+	summary := AdminSummary{
+		Tasks:             countTasks(taskStore.List(), clock.Now()),
+		CreatedTotal:      events.Created,
+		CompletedTotal:    events.Completed,
+		Storage:           storageBreaker.stats(),
+		Requests:          RequestStats{InFlight: limiter.InFlight, Queued: limiter.Queued, Shed: limiter.Shed},
+		StorageOperations: storageMetrics.summary(),
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// BackupFile describes one backup of the tasks file
+type BackupFile struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// Backups serves GET /admin/backups, the tasks file's backups from newest to oldest
+func Backups(tasksFile string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+			writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+			return
+		}
+		backups := []BackupFile{}
+		for n := 0; ; n++ {
+			info, err := os.Stat(backupName(tasksFile, n))
+			if err != nil {
+				break
+			}
+			backups = append(backups, BackupFile{Name: info.Name(), Size: info.Size(), Modified: info.ModTime().UTC()})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(backups)
+	}
+}
+
+// SaveNow serves POST /admin/save, saving the tasks at once and compacting the journal
+func SaveNow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	flusher, ok := taskStore.(interface{ Flush() error })
+	if !ok {
+		writeJsonError(w, http.StatusNotImplemented, "The task store cannot be saved on demand")
+		return
+	}
+	if err := flusher.Flush(); err != nil {
+		logErrorContext(r.Context(), "Failed to save tasks on demand: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to save tasks")
+		return
+	}
+	logInfoContext(r.Context(), "Tasks saved on demand")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "saved_at": clock.Now()})
+}
+
+// Reload serves POST /admin/reload, replacing the in-memory tasks with the saved ones. It refuses with
+// 409 while changes are unsaved unless ?discard=true.
+func Reload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	params := newQueryParams(r.URL.Query())
+	discard := params.Bool("discard")
+	if err := params.Err(); err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	reloader, ok := taskStore.(interface {
+		Reload(discard bool) (int, error)
+	})
+	if !ok {
+		writeJsonError(w, http.StatusNotImplemented, "The task store cannot be reloaded")
+		return
+	}
+	count, err := reloader.Reload(discard != nil && *discard)
+	if errors.Is(err, ErrUnsavedChanges) {
+		logErrorContext(r.Context(), "Reload refused: %v", err)
+		writeJsonError(w, http.StatusConflict, "There are unsaved changes; save first or reload with ?discard=true")
+		return
+	}
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to reload tasks: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to reload tasks")
+		return
+	}
+	logInfoContext(r.Context(), "Reloaded %d tasks from storage", count)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "tasks": count})
+}
+
+// LogLevel serves /admin/loglevel: GET reports the least severe level logged, and PUT {"level": "debug"}
+// changes it until the next restart, for example to debug a problem without restarting the server
+func LogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		var body struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Level == "" {
+			logErrorContext(r.Context(), "Invalid log level request: %v", err)
+			writeJsonError(w, http.StatusBadRequest, `Request body must be {"level": "trace|debug|info|warn|error"}`)
+			return
+		}
+		level, err := parseLogLevel(body.Level)
+		if err != nil {
+			logErrorContext(r.Context(), err.Error())
+			writeJsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		previous := logVerbosity.Level()
+		logVerbosity.Set(level)
+		// Logged at warn so the change shows at any level but error
+		logWarnContext(r.Context(), "Log level changed from %s to %s by %s", levelName(previous), levelName(level), cmp.Or(userFor(r), clientIP(r)))
+	default:
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"level": levelName(logVerbosity.Level())})
+}
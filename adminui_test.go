@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixedBackend loads a fixed list and counts saves
+type fixedBackend struct {
+	countingBackend
+	list []Task
+}
+
+func (b *fixedBackend) Load() ([]Task, error) { return b.list, nil }
+
+func TestAdminUI(t *testing.T) {
+	useTasks(t, []Task{{ID: 1, Title: "Open"}, {ID: 2, Title: "Done", Completed: true}})
+	tasksFile := filepath.Join(t.TempDir(), "tasks.json")
+	os.WriteFile(backupName(tasksFile, 0), []byte("[]\n"), 0644)
+	os.WriteFile(backupName(tasksFile, 1), []byte("[{}]\n"), 0644)
+	mux := newAdminMux(http.NotFoundHandler(), filepath.Join(t.TempDir(), "pending.jsonl"), tasksFile, "file")
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		contains     string
+	}{
+		{"Page", "GET", "/admin/ui/", http.StatusOK, "<title>Task Tracker Admin</title>"},
+		{"Script", "GET", "/admin/ui/admin.js", http.StatusOK, `fetch(path`},
+		{"Without slash", "GET", "/admin/ui", http.StatusMovedPermanently, ""},
+		{"Summary", "GET", "/admin/summary", http.StatusOK, `"tasks":{"total":2,"completed":1,"overdue":0}`},
+		{"Backups", "GET", "/admin/backups", http.StatusOK, `[{"name":"tasks.json.bak","size":3,`},
+		{"Save wrong method", "GET", "/admin/save", http.StatusMethodNotAllowed, "Method Not Allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("expected body to contain %q, got %q", tt.contains, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAdminAreaRequiresAdmin(t *testing.T) {
+	useTasks(t, nil)
+	useUsers(t, "alice")
+	users = append(users, User{Name: "viewer", KeySHA256: hashAPIKey("viewer-key"), Role: roleReadOnly})
+	mux := newAdminMux(http.NotFoundHandler(), filepath.Join(t.TempDir(), "pending.jsonl"), filepath.Join(t.TempDir(), "tasks.json"), "file")
+
+	tests := []struct {
+		name         string
+		path         string
+		key          string
+		expectedCode int
+	}{
+		{"Page needs no key", "/admin/ui/", "", http.StatusOK},
+		{"No key", "/admin/summary", "", http.StatusUnauthorized},
+		{"Read-only user", "/admin/summary", "viewer-key", http.StatusForbidden},
+		{"Admin", "/admin/summary", "alice-key", http.StatusOK},
+		{"Read-only user can't list backups", "/admin/backups", "viewer-key", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedCode, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAdminEndpointsNeedKey(t *testing.T) {
+	useTasks(t, nil)
+	useUsers(t, "alice")
+	pendingFile := filepath.Join(t.TempDir(), "pending.jsonl")
+	os.WriteFile(pendingFile, []byte(`{"id":1,"method":"POST","path":"/tasks","body":{"title":"Staged"}}`+"\n"), 0644)
+	mux := newAdminMux(http.NotFoundHandler(), pendingFile, filepath.Join(t.TempDir(), "tasks.json"), "file")
+
+	endpoints := []struct{ method, path string }{
+		{"GET", "/admin/pending"},
+		{"DELETE", "/admin/pending"},
+		{"POST", "/admin/pending/apply"},
+		{"GET", "/admin/backup/verify"},
+		{"GET", "/admin/check"},
+		{"POST", "/admin/check"},
+		{"GET", "/admin/summary"},
+		{"GET", "/admin/backups"},
+		{"POST", "/admin/save"},
+		{"POST", "/admin/reload"},
+		{"GET", "/admin/loglevel"},
+		{"PUT", "/admin/loglevel"},
+		{"GET", "/admin/jobs"},
+		{"POST", "/admin/jobs/backup/run"},
+	}
+	for _, e := range endpoints {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(e.method, e.path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without a key got %d, want 401", e.method, e.path, rec.Code)
+		}
+	}
+	// Nothing was applied or discarded along the way
+	if data, _ := os.ReadFile(pendingFile); !strings.Contains(string(data), "Staged") {
+		t.Errorf("pending changes were touched: %q", data)
+	}
+}
+
+func TestAdminSaveAndReload(t *testing.T) {
+	backend := &fixedBackend{list: []Task{{ID: 1, Title: "Saved"}}}
+	store := newMemoryStore(backend.list)
+	store.backend = backend
+	original := taskStore
+	taskStore = store
+	t.Cleanup(func() { taskStore = original })
+
+	store.Create(Task{Title: "Unsaved"})
+	rec := httptest.NewRecorder()
+	Reload(rec, httptest.NewRequest("POST", "/admin/reload", nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("reload with unsaved changes = %d %s, want 409", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	SaveNow(rec, httptest.NewRequest("POST", "/admin/save", nil))
+	if rec.Code != http.StatusOK || backend.saves != 1 {
+		t.Fatalf("save = %d %s with %d saves", rec.Code, rec.Body, backend.saves)
+	}
+
+	// The backend still holds only the first task, as if the file had been edited by hand
+	rec = httptest.NewRecorder()
+	Reload(rec, httptest.NewRequest("POST", "/admin/reload", nil))
+	var body map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if rec.Code != http.StatusOK || body["tasks"] != 1.0 {
+		t.Fatalf("reload = %d %s", rec.Code, rec.Body)
+	}
+	if list := store.List(); len(list) != 1 || list[0].Title != "Saved" {
+		t.Errorf("tasks after reload = %+v", list)
+	}
+	if created, _ := store.Create(Task{Title: "Next"}); created.ID != 3 {
+		t.Errorf("ID after reload = %d, want 3 so ID 2 isn't reused", created.ID)
+	}
+
+	rec = httptest.NewRecorder()
+	Reload(rec, httptest.NewRequest("POST", "/admin/reload?discard=true", nil))
+	if rec.Code != http.StatusOK || len(store.List()) != 1 {
+		t.Errorf("reload discarding changes = %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// backupRetention is how many previous versions of a tasks file each save keeps: name.bak is the
+// newest, then name.bak.1, name.bak.2, and so on. 0 keeps none.
+var backupRetention = 1
+
+// writeFileAtomic replaces filename with what write produces. The data goes to a temporary file in the
+// same directory, is synced, and is renamed over filename, so a crash leaves the old version or the new
+// one, never a missing or half-written file.
+func writeFileAtomic(filename string, perm os.FileMode, write func(io.Writer) error) (err error) {
+	temp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(temp.Name())
+		}
+	}()
+
+	buffered := bufio.NewWriter(temp)
+	if err = write(buffered); err == nil {
+		err = buffered.Flush()
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(temp.Name(), perm); err != nil {
+		return err
+	}
+	if err = os.Rename(temp.Name(), filename); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(filename))
+	return nil
+}
+
+// syncDir flushes a directory so a rename in it survives a crash. Some platforms can't sync
+// directories; the rename is still atomic there, only possibly not yet durable.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// backupName returns the name of filename's nth most recent backup, counting from 0
+func backupName(filename string, n int) string {
+	if n == 0 {
+		return filename + ".bak"
+	}
+	return fmt.Sprintf("%s.bak.%d", filename, n)
+}
+
+// rotateBackups shifts filename's backups along one slot, dropping the oldest beyond keep, and
+// makes the current file the newest backup. The current file stays in place throughout.
+func rotateBackups(filename string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	for n := keep - 1; n > 0; n-- {
+		if err := os.Rename(backupName(filename, n-1), backupName(filename, n)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	newest := backupName(filename, 0)
+	if err := os.Remove(newest); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// A hard link costs nothing; copy on filesystems that don't support them
+	if err := os.Link(filename, newest); err == nil {
+		return nil
+	}
+	return copyFile(filename, newest)
+}
+
+// copyFile copies src to dst, creating or truncating dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(dst, info.Mode().Perm(), func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+}
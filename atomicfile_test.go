@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "tasks.json")
+	os.WriteFile(filename, []byte("old"), 0644)
+
+	// A failed write leaves the old file and no temporary files behind
+	err := writeFileAtomic(filename, 0644, func(w io.Writer) error {
+		w.Write([]byte("half"))
+		return errors.New("disk full")
+	})
+	if err == nil {
+		t.Fatal("expected the write error")
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "old" {
+		t.Errorf("after failed write got %q, want the old contents", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("got %d files, want only the tasks file", len(entries))
+	}
+
+	if err := writeFileAtomic(filename, 0600, func(w io.Writer) error {
+		_, err := w.Write([]byte("new"))
+		return err
+	}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	info, _ := os.Stat(filename)
+	if data, _ := os.ReadFile(filename); string(data) != "new" || info.Mode().Perm() != 0600 {
+		t.Errorf("got %q with mode %v, want \"new\" with 0600", data, info.Mode().Perm())
+	}
+}
+
+func TestBackupRetention(t *testing.T) {
+	original := backupRetention
+	backupRetention = 3
+	t.Cleanup(func() { backupRetention = original })
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	backend := fileBackend{filename: filename}
+	for i := 1; i <= 5; i++ {
+		if err := backend.Save([]Task{{ID: i, Title: "Save"}}); err != nil {
+			t.Fatalf("save %d: %v", i, err)
+		}
+	}
+
+	// The live file holds save 5, and the three backups saves 4, 3, and 2
+	want := map[string]int{filename: 5, filename + ".bak": 4, filename + ".bak.1": 3, filename + ".bak.2": 2}
+	for name, id := range want {
+		list, err := fileBackend{filename: name}.Load()
+		if err != nil || len(list) != 1 || list[0].ID != id {
+			t.Errorf("%s: got %+v (%v), want task %d", filepath.Base(name), list, err, id)
+		}
+	}
+	if _, err := os.Stat(filename + ".bak.3"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got a fourth backup, want at most 3")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "fmt"
+
+// autosave saves the store whenever afterChanges changes have piled up since the last save, so a burst
+// of edits is saved without waiting for the scheduled autosave job. It returns once stop is closed;
+// saving on shutdown is left to the caller.
+func (s *memoryStore) autosave(afterChanges int, stop <-chan struct{}) {
+	s.mu.Lock()
+	s.autosaveAfter = afterChanges
+	s.saveDue = make(chan struct{}, 1)
+	s.mu.Unlock()
+
+	for {
+		select {
+		case <-s.saveDue:
+		case <-stop:
+			return
+		}
+		if err := s.saveUnsaved(); err != nil {
+			logError("Autosave failed: %v", err)
+		}
+	}
+}
+
+// saveUnsaved saves the store if anything changed since the last save. It is what the autosave job runs.
+func (s *memoryStore) saveUnsaved() error {
+	s.mu.Lock()
+	unsaved := s.unsaved
+	s.mu.Unlock()
+	if unsaved == 0 {
+		return nil
+	}
+	if err := s.Flush(); err != nil {
+		return fmt.Errorf("%d changes unsaved: %w", unsaved, err)
+	}
+	logDebug("Autosaved %d changes", unsaved)
+	return nil
+}
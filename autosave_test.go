@@ -0,0 +1,110 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingBackend records how many times it was saved and what the last save held
+type countingBackend struct {
+	mu    sync.Mutex
+	saves int
+	last  []Task
+}
+
+func (b *countingBackend) Load() ([]Task, error) { return nil, nil }
+func (b *countingBackend) Close() error          { return nil }
+func (b *countingBackend) Save(list []Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.saves++
+	b.last = append([]Task(nil), list...)
+	return nil
+}
+
+// waitForSaves polls until the backend has been saved want times, failing after a second
+func (b *countingBackend) waitForSaves(t *testing.T, want int) []Task {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		b.mu.Lock()
+		saves, last := b.saves, b.last
+		b.mu.Unlock()
+		if saves >= want {
+			return last
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d saves, want %d", saves, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAutosaveAfterChanges(t *testing.T) {
+	backend := &countingBackend{}
+	store, _ := openTaskStore(backend, false)
+	stop := make(chan struct{})
+	defer close(stop)
+	go store.autosave(3, stop)
+	time.Sleep(10 * time.Millisecond) // let autosave install its trigger
+
+	for i := 0; i < 2; i++ {
+		store.Create(Task{Title: "Not yet"})
+	}
+	time.Sleep(20 * time.Millisecond)
+	backend.mu.Lock()
+	early := backend.saves
+	backend.mu.Unlock()
+	if early != 0 {
+		t.Fatalf("saved after 2 changes, want a save only after 3")
+	}
+	store.Create(Task{Title: "Now"})
+	if last := backend.waitForSaves(t, 1); len(last) != 3 {
+		t.Errorf("saved %d tasks, want 3", len(last))
+	}
+}
+
+func TestAutosaveJob(t *testing.T) {
+	backend := &countingBackend{}
+	store, _ := openTaskStore(backend, false)
+	jobs := &scheduler{}
+	jobs.Add("autosave", "@every 5ms", true, store.saveUnsaved)
+	stop := make(chan struct{})
+	defer jobs.Wait()
+	defer close(stop)
+	jobs.Start(stop)
+
+	store.Create(Task{Title: "Saved by the timer"})
+	backend.waitForSaves(t, 1)
+
+	// With nothing changed, runs don't save again
+	time.Sleep(30 * time.Millisecond)
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if backend.saves != 1 {
+		t.Errorf("got %d saves with no changes, want 1", backend.saves)
+	}
+}
+
+func TestAutosaveRetriesFailedWriteThrough(t *testing.T) {
+	resetStorageBreaker(t)
+	backend := &flakyBackend{failures: 1}
+	store, _ := openTaskStore(backend, true)
+
+	store.Create(Task{Title: "Not written through"})
+	if h := store.health(); h.unsaved != 1 || !h.dirtySince.Equal(store.Modified()) {
+		t.Fatalf("after a failed write-through got %d unsaved since %v, want 1 since the change", h.unsaved, h.dirtySince)
+	}
+	if err := store.saveUnsaved(); err != nil || backend.saves != 1 || store.health().unsaved != 0 {
+		t.Errorf("autosave = %v after %d saves leaving %d unsaved, want one save and none unsaved", err, backend.saves, store.health().unsaved)
+	}
+
+	// A successful write-through saves everything, earlier failures included
+	backend.failures = 1
+	store.Create(Task{Title: "Failed"})
+	store.Create(Task{Title: "Written through"})
+	if last := backend.last; len(last) != 3 || store.health().unsaved != 0 {
+		t.Errorf("saved %d tasks leaving %d unsaved, want 3 and none", len(last), store.health().unsaved)
+	}
+}
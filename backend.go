@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// taskBackend persists the task list behind a TaskStore. The in-memory list stays authoritative while the
+// server runs; a backend is read once at startup and written on shutdown, or after every change when it's cheap.
+type taskBackend interface {
+	Load() ([]Task, error)
+	Save(list []Task) error
+	Close() error
+}
+
+// fileBackend keeps tasks in a JSON file, atomically replaced on every save
+type fileBackend struct {
+	filename string
+}
+
+func (s fileBackend) Load() ([]Task, error) {
+	// Hold the advisory lock so a concurrent save from another process can't hand us a half-written file
+	unlock, err := acquireFileLock(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := os.Open(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stored []storedTask
+	if err := json.NewDecoder(file).Decode(&stored); err != nil {
+		return nil, decodeError{err}
+	}
+	logInfo("Tasks loaded successfully from %s", s.filename)
+	return displayOrder(stored), nil
+}
+
+func (s fileBackend) Save(list []Task) error {
+	// Keep other processes from reading or writing the file while it is being replaced
+	unlock, err := acquireFileLock(s.filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Keep the old tasks.json as a backup, then replace it atomically
+	if err := rotateBackups(s.filename, backupRetention); err != nil {
+		logError("Warning: Failed to back up %s: %v", s.filename, err)
+	}
+	data, err := marshalCanonical(canonicalTasks(list))
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(s.filename, 0644, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	logInfo("Tasks successfully saved to %s", s.filename)
+	return nil
+}
+
+func (s fileBackend) Close() error {
+	return nil
+}
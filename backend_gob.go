@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// gobFormatVersion is written ahead of the tasks so a future layout change can be detected on load
+const gobFormatVersion = 1
+
+// gobHeader precedes the task list in a gob file
+type gobHeader struct {
+	Version int
+	Count   int
+}
+
+// gobBackend keeps tasks in a compact encoding/gob file. It loads and saves large stores several times
+// faster than JSON; `task-tracker convert` turns it back into JSON for inspection or export.
+type gobBackend struct {
+	filename string
+}
+
+func (s gobBackend) Load() ([]Task, error) {
+	unlock, err := acquireFileLock(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	file, err := os.Open(s.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	loaded, err := decodeGob(bufio.NewReader(file))
+	if err != nil {
+		return nil, decodeError{err}
+	}
+	logInfo("Tasks loaded successfully from %s", s.filename)
+	return loaded, nil
+}
+
+// decodeGob reads a task list in the gob file format: a gobHeader, then the tasks
+func decodeGob(r io.Reader) ([]Task, error) {
+	decoder := gob.NewDecoder(r)
+	var header gobHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, fmt.Errorf("read gob header: %w", err)
+	}
+	if header.Version != gobFormatVersion {
+		return nil, fmt.Errorf("unsupported gob format version %d", header.Version)
+	}
+	loaded := make([]Task, 0, header.Count)
+	if err := decoder.Decode(&loaded); err != nil {
+		return nil, err
+	}
+	return loaded, nil
+}
+
+// encodeGob writes list in the gob file format
+func encodeGob(w io.Writer, list []Task) error {
+	encoder := gob.NewEncoder(w)
+	if err := encoder.Encode(gobHeader{Version: gobFormatVersion, Count: len(list)}); err != nil {
+		return err
+	}
+	return encoder.Encode(list)
+}
+
+func (s gobBackend) Save(list []Task) error {
+	unlock, err := acquireFileLock(s.filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := rotateBackups(s.filename, backupRetention); err != nil {
+		logError("Warning: Failed to back up %s: %v", s.filename, err)
+	}
+	err = writeFileAtomic(s.filename, 0644, func(w io.Writer) error {
+		return encodeGob(w, list)
+	})
+	if err != nil {
+		return err
+	}
+
+	logInfo("Tasks successfully saved to %s", s.filename)
+	return nil
+}
+
+func (s gobBackend) Close() error {
+	return nil
+}
+
+// backendForFile picks the file backend matching filename's extension: .gob for gob, anything else JSON
+func backendForFile(filename string) taskBackend {
+	if storageForFile(filename) == "gob" {
+		return gobBackend{filename: filename}
+	}
+	return fileBackend{filename: filename}
+}
+
+// storageForFile names the storage whose format filename's extension implies, "gob" or "file"
+func storageForFile(filename string) string {
+	if filepath.Ext(filename) == ".gob" {
+		return "gob"
+	}
+	return "file"
+}
+
+// runConvert implements `task-tracker convert <from> <to>`, copying tasks between JSON and gob files;
+// each file's format follows its extension
+func runConvert(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(stderr, "usage: task-tracker convert <from> <to>")
+		return exitUsage
+	}
+	from, to := fs.Arg(0), fs.Arg(1)
+	list, err := backendForFile(from).Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "convert: %v\n", err)
+		return exitProblems
+	}
+	if err := backendForFile(to).Save(list); err != nil {
+		fmt.Fprintf(stderr, "convert: %v\n", err)
+		return exitProblems
+	}
+	fmt.Fprintf(stdout, "Converted %d tasks from %s to %s\n", len(list), from, to)
+	return exitOK
+}
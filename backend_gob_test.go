@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGobStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.gob")
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want := []Task{
+		{ID: 1, Title: "One", Priority: "high", Tags: []string{"home"}, CreatedAt: &created, UpdatedAt: &created},
+		{ID: 2, Title: "Two", Completed: true, ParentID: 1, Checklist: []ChecklistItem{{ID: 1, Text: "Step", Done: true}}},
+	}
+	if err := (gobBackend{filename: path}).Save(want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := gobBackend{filename: path}.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+}
+
+func TestConvertCommand(t *testing.T) {
+	dir := t.TempDir()
+	jsonFile, gobFile, exported := filepath.Join(dir, "tasks.json"), filepath.Join(dir, "tasks.gob"), filepath.Join(dir, "export.json")
+	want := []Task{{ID: 1, Title: "One"}, {ID: 2, Title: "Two", DueDate: "2024-06-01T00:00:00Z"}}
+	if err := (fileBackend{filename: jsonFile}).Save(want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	for _, args := range [][]string{{"convert", jsonFile, gobFile}, {"convert", gobFile, exported}} {
+		var stdout, stderr bytes.Buffer
+		if code := runCommand(args, &stdout, &stderr); code != exitOK {
+			t.Fatalf("%v: exit %d, stderr %s", args, code, stderr.String())
+		}
+		if !strings.HasPrefix(stdout.String(), "Converted 2 tasks") {
+			t.Errorf("%v: got output %q", args, stdout.String())
+		}
+	}
+	got, err := fileBackend{filename: exported}.Load()
+	if err != nil {
+		t.Fatalf("load export: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exported %+v, want %+v", got, want)
+	}
+}
+
+// benchmarkTasks builds n tasks shaped like a busy store's
+func benchmarkTasks(n int) []Task {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	list := make([]Task, n)
+	for i := range list {
+		list[i] = Task{ID: i + 1, Title: fmt.Sprintf("Task number %d", i+1), Completed: i%3 == 0, Priority: "medium",
+			Tags: []string{"work", "q2"}, CreatedAt: &created, UpdatedAt: &created}
+	}
+	return list
+}
+
+// BenchmarkLoad compares startup load times per format. The 1M-task case is the one that motivated
+// the gob format; run it with `go test -run=^$ -bench=Load -benchtime=3x`.
+func BenchmarkLoad(b *testing.B) {
+	for _, n := range []int{10_000, 1_000_000} {
+		list := benchmarkTasks(n)
+		dir := b.TempDir()
+		backends := []struct {
+			name    string
+			backend taskBackend
+		}{
+			{"json", fileBackend{filename: filepath.Join(dir, "tasks.json")}},
+			{"gob", gobBackend{filename: filepath.Join(dir, "tasks.gob")}},
+		}
+		for _, bb := range backends {
+			if err := bb.backend.Save(list); err != nil {
+				b.Fatalf("save: %v", err)
+			}
+			b.Run(fmt.Sprintf("%s/%d", bb.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := bb.backend.Load(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
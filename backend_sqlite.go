@@ -0,0 +1,119 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema stores each task as a JSON document so new Task fields need no migration
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS tasks (
+	id       INTEGER PRIMARY KEY,
+	position INTEGER NOT NULL,
+	data     TEXT NOT NULL
+)`
+
+// sqliteRow is what a saved task looks like on disk, used to skip rows that haven't changed
+type sqliteRow struct {
+	position int
+	data     string
+}
+
+// sqliteBackend keeps tasks in a SQLite database. Saves only touch rows that changed since the
+// last save, so writing through on every mutation stays cheap for large task lists.
+type sqliteBackend struct {
+	path  string
+	db    *sql.DB
+	saved map[int]sqliteRow
+}
+
+// openSQLiteBackend opens or creates the database at path
+func openSQLiteBackend(path string) (*sqliteBackend, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer; a single connection avoids SQLITE_BUSY between our own goroutines
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{"PRAGMA journal_mode=WAL", "PRAGMA synchronous=NORMAL", sqliteSchema} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("initialize %s: %w", path, err)
+		}
+	}
+	return &sqliteBackend{path: path, db: db, saved: map[int]sqliteRow{}}, nil
+}
+
+func (s *sqliteBackend) Load() ([]Task, error) {
+	rows, err := s.db.Query("SELECT id, position, data FROM tasks ORDER BY position")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loaded := []Task{}
+	saved := map[int]sqliteRow{}
+	for rows.Next() {
+		var id int
+		var row sqliteRow
+		if err := rows.Scan(&id, &row.position, &row.data); err != nil {
+			return nil, err
+		}
+		var task Task
+		if err := json.Unmarshal([]byte(row.data), &task); err != nil {
+			return nil, decodeError{fmt.Errorf("task %d: %w", id, err)}
+		}
+		loaded = append(loaded, task)
+		saved[id] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	s.saved = saved
+	logInfo("Tasks loaded successfully from SQLite (%d tasks)", len(loaded))
+	return loaded, nil
+}
+
+func (s *sqliteBackend) Save(list []Task) error {
+	next := make(map[int]sqliteRow, len(list))
+	for i, t := range list {
+		data, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		next[t.ID] = sqliteRow{position: i, data: string(data)}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for id, row := range next {
+		if s.saved[id] == row {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO tasks (id, position, data) VALUES (?, ?, ?) ON CONFLICT(id) DO UPDATE SET position = excluded.position, data = excluded.data", id, row.position, row.data); err != nil {
+			return err
+		}
+	}
+	for id := range s.saved {
+		if _, ok := next[id]; !ok {
+			if _, err := tx.Exec("DELETE FROM tasks WHERE id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logDebug("Saved %d tasks to SQLite", len(next))
+	s.saved = next
+	return nil
+}
+
+func (s *sqliteBackend) Close() error {
+	return s.db.Close()
+}
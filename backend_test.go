@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSQLiteStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	store, err := openSQLiteBackend(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	saves := [][]Task{
+		{{ID: 1, Title: "One"}, {ID: 2, Title: "Two", Checklist: []ChecklistItem{{ID: 1, Text: "Step"}}}},
+		// Reorder, update, add, and delete in one save
+		{{ID: 3, Title: "Three"}, {ID: 1, Title: "One", Completed: true}},
+	}
+	for _, list := range saves {
+		if err := store.Save(list); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	store.Close()
+
+	reopened, err := openSQLiteBackend(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	got, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if want := saves[len(saves)-1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v, want %+v", got, want)
+	}
+}
+
+func TestSQLiteWriteThrough(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	backend, err := openSQLiteBackend(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	store, err := openTaskStore(backend, true)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	defer store.Close()
+	original := taskStore
+	taskStore = store
+	t.Cleanup(func() { taskStore = original })
+
+	rec := httptest.NewRecorder()
+	Tasks(rec, httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title": "Survives a crash"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+
+	// Read back through a second connection, as a restarted process would
+	other, err := openSQLiteBackend(path)
+	if err != nil {
+		t.Fatalf("open second connection: %v", err)
+	}
+	defer other.Close()
+	got, err := other.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(got) != 1 || got[0].Title != "Survives a crash" {
+		t.Errorf("got %+v, want the task created before the response", got)
+	}
+}
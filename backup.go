@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+)
+
+// BackupReport is the result of a restore drill: whether the backup restores cleanly and how it differs from the live store
+type BackupReport struct {
+	Backup         string              `json:"backup"`
+	Restorable     bool                `json:"restorable"`
+	BackupTasks    int                 `json:"backup_tasks"`
+	LiveTasks      int                 `json:"live_tasks"`
+	BackupChecksum string              `json:"backup_checksum,omitempty"`
+	LiveChecksum   string              `json:"live_checksum"`
+	InSync         bool                `json:"in_sync"`
+	Added          []int               `json:"added"`   // IDs in the live store but not the backup
+	Removed        []int               `json:"removed"` // IDs in the backup but not the live store
+	Changed        []int               `json:"changed"` // IDs whose contents differ
+	Problems       []ValidationProblem `json:"problems"`
+}
+
+// verifyBackup restores backup data, written by the named storage ("file" or "gob"), into a scratch
+// task list, checks it with the validate rules, confirms it survives a save/load round trip in that
+// format, and diffs it against live
+func verifyBackup(data []byte, storage string, live []Task) BackupReport {
+	report := BackupReport{LiveTasks: len(live), LiveChecksum: tasksChecksum(live), Added: []int{}, Removed: []int{}, Changed: []int{}}
+
+	if storage == "gob" {
+		// The validate rules read JSON, so a gob backup is checked as the JSON file it would convert to
+		list, err := decodeGob(bytes.NewReader(data))
+		if err == nil {
+			data, err = marshalCanonical(canonicalTasks(list))
+		}
+		if err != nil {
+			report.Problems = []ValidationProblem{{Index: -1, Message: "restore failed: " + err.Error()}}
+			return report
+		}
+	}
+	validation := validateTasksData(data, nil)
+	report.Problems = validation.Problems
+	report.BackupTasks = validation.TaskCount
+	if !validation.Valid {
+		return report
+	}
+
+	var restored []Task
+	if err := json.Unmarshal(data, &restored); err != nil {
+		report.Problems = append(report.Problems, ValidationProblem{Index: -1, Message: "restore failed: " + err.Error()})
+		return report
+	}
+	// A restore that loses data on the next save is no restore at all
+	reloaded, err := roundTrip(restored, storage)
+	if err != nil || tasksChecksum(reloaded) != tasksChecksum(restored) {
+		report.Problems = append(report.Problems, ValidationProblem{Index: -1, Message: "backup does not survive a save/load round trip"})
+		return report
+	}
+	report.Restorable = true
+	report.BackupChecksum = tasksChecksum(restored)
+
+	backupByID := make(map[int][]byte, len(restored))
+	for _, t := range restored {
+		backupByID[t.ID], _ = json.Marshal(t)
+	}
+	for _, t := range live {
+		encoded, _ := json.Marshal(t)
+		previous, ok := backupByID[t.ID]
+		switch {
+		case !ok:
+			report.Added = append(report.Added, t.ID)
+		case !bytes.Equal(previous, encoded):
+			report.Changed = append(report.Changed, t.ID)
+		}
+		delete(backupByID, t.ID)
+	}
+	for id := range backupByID {
+		report.Removed = append(report.Removed, id)
+	}
+	sort.Ints(report.Removed)
+	report.InSync = report.BackupChecksum == report.LiveChecksum
+	return report
+}
+
+// roundTrip saves list in the named storage's format and loads it back
+func roundTrip(list []Task, storage string) ([]Task, error) {
+	var reloaded []Task
+	if storage == "gob" {
+		var buf bytes.Buffer
+		if err := encodeGob(&buf, list); err != nil {
+			return nil, err
+		}
+		return decodeGob(&buf)
+	}
+	resaved, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(resaved, &reloaded)
+	return reloaded, err
+}
+
+// decodeTasksFile decodes the contents of a tasks file in the named storage's format
+func decodeTasksFile(data []byte, storage string) ([]Task, error) {
+	if storage == "gob" {
+		return decodeGob(bytes.NewReader(data))
+	}
+	var list []Task
+	err := json.Unmarshal(data, &list)
+	return list, err
+}
+
+// tasksChecksum hashes a task list independent of its order, so reordering alone doesn't count as drift
+func tasksChecksum(list []Task) string {
+	sorted := append([]Task(nil), list...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	data, _ := json.Marshal(sorted)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// runVerifyBackup implements `task-tracker verify-backup [--force] [--backup file] <tasks file>`,
+// printing a JSON report and exiting non-zero when the backup would not restore
+func runVerifyBackup(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("verify-backup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	force := fs.Bool("force", false, "read the files even if another process holds the lock")
+	backup := fs.String("backup", "", "backup to verify (default <tasks file>.bak)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: task-tracker verify-backup [--force] [--backup file] <tasks file>")
+		return exitUsage
+	}
+	filename := fs.Arg(0)
+	if *backup == "" {
+		*backup = filename + ".bak"
+	}
+
+	// The backup is written under the live file's lock, so one lock covers both
+	if !*force {
+		unlock, err := acquireFileLock(filename)
+		if err != nil {
+			fmt.Fprintf(stderr, "verify-backup: %v (use --force to read anyway)\n", err)
+			return exitUsage
+		}
+		defer unlock()
+	}
+
+	backupData, err := os.ReadFile(*backup)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify-backup: %v\n", err)
+		return exitUsage
+	}
+	liveData, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify-backup: %v\n", err)
+		return exitUsage
+	}
+	// Like convert, the format follows the tasks file's extension
+	storage := storageForFile(filename)
+	live, err := decodeTasksFile(liveData, storage)
+	if err != nil {
+		fmt.Fprintf(stderr, "verify-backup: live store %s is unreadable: %v\n", filename, err)
+		return exitUsage
+	}
+
+	report := verifyBackup(backupData, storage, live)
+	report.Backup = *backup
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if !report.Restorable {
+		return exitProblems
+	}
+	return exitOK
+}
+
+// VerifyBackup serves GET /admin/backup/verify, running the restore drill against the in-memory store.
+// storage is the configured backend, which wrote the backup.
+func VerifyBackup(backupFile, storage string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+			writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+			return
+		}
+		data, err := os.ReadFile(backupFile)
+		if os.IsNotExist(err) {
+			writeJsonError(w, http.StatusNotFound, "No backup found")
+			return
+		}
+		if err != nil {
+			logErrorContext(r.Context(), "Failed to read backup %s: %v", backupFile, err)
+			writeJsonError(w, http.StatusInternalServerError, "Failed to read backup")
+			return
+		}
+
+		report := verifyBackup(data, storage, taskStore.List())
+		report.Backup = backupFile
+		logInfoContext(r.Context(), "Backup drill for %s: restorable=%t in_sync=%t", backupFile, report.Restorable, report.InSync)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type verifyBackupTestCase struct {
+	name           string // Test case name
+	backup         string // Backup file contents
+	wantExit       int    // Expected exit code
+	wantRestorable bool   // Expected restorable verdict
+	wantInSync     bool   // Expected in-sync verdict
+	wantAdded      []int  // Expected IDs only in the live store
+	wantRemoved    []int  // Expected IDs only in the backup
+	wantChanged    []int  // Expected IDs that differ
+}
+
+// The live store in every case holds tasks 1 and 2, with task 2 completed
+var verifyBackupTests = []verifyBackupTestCase{
+	{
+		name:           "Identical In Different Order",
+		backup:         `[{"id":2,"title":"Two","completed":true},{"id":1,"title":"One"}]`,
+		wantExit:       exitOK,
+		wantRestorable: true,
+		wantInSync:     true,
+		wantAdded:      []int{},
+		wantRemoved:    []int{},
+		wantChanged:    []int{},
+	},
+	{
+		name:           "Drift",
+		backup:         `[{"id":2,"title":"Two"},{"id":3,"title":"Three"}]`,
+		wantExit:       exitOK,
+		wantRestorable: true,
+		wantAdded:      []int{1},
+		wantRemoved:    []int{3},
+		wantChanged:    []int{2},
+	},
+	{
+		name:        "Corrupt Backup",
+		backup:      `[{"id":1,"title":"One"`,
+		wantExit:    exitProblems,
+		wantAdded:   []int{},
+		wantRemoved: []int{},
+		wantChanged: []int{},
+	},
+}
+
+func TestVerifyBackupCommand(t *testing.T) {
+	dir := t.TempDir()
+	liveFile := filepath.Join(dir, "tasks.json")
+	if err := os.WriteFile(liveFile, []byte(`[{"id":1,"title":"One"},{"id":2,"title":"Two","completed":true}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range verifyBackupTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(liveFile+".bak", []byte(tt.backup), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			if got := runCommand([]string{"verify-backup", liveFile}, &stdout, &stderr); got != tt.wantExit {
+				t.Fatalf("got exit %d, want %d (stderr: %s)", got, tt.wantExit, stderr.String())
+			}
+			var report BackupReport
+			if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+				t.Fatalf("report is not JSON: %v", err)
+			}
+			if report.Restorable != tt.wantRestorable || report.InSync != tt.wantInSync {
+				t.Errorf("got restorable=%t in_sync=%t, want %t and %t", report.Restorable, report.InSync, tt.wantRestorable, tt.wantInSync)
+			}
+			if !reflect.DeepEqual(report.Added, tt.wantAdded) || !reflect.DeepEqual(report.Removed, tt.wantRemoved) || !reflect.DeepEqual(report.Changed, tt.wantChanged) {
+				t.Errorf("got added=%v removed=%v changed=%v, want %v %v %v", report.Added, report.Removed, report.Changed, tt.wantAdded, tt.wantRemoved, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestVerifyBackupMissingFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if got := runCommand([]string{"verify-backup", filepath.Join(t.TempDir(), "tasks.json")}, &stdout, &stderr); got != exitUsage {
+		t.Errorf("got exit %d, want %d", got, exitUsage)
+	}
+}
+
+func TestVerifyBackupGob(t *testing.T) {
+	tasks := []Task{{ID: 1, Title: "One"}, {ID: 2, Title: "Two", Completed: true}}
+	liveFile := filepath.Join(t.TempDir(), "tasks.gob")
+	var live bytes.Buffer
+	if err := encodeGob(&live, tasks); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(liveFile, live.Bytes(), 0644)
+
+	tests := []struct {
+		name           string
+		backup         []byte
+		wantExit       int
+		wantRestorable bool
+	}{
+		{"Restorable", live.Bytes(), exitOK, true},
+		{"Corrupt", live.Bytes()[:live.Len()/2], exitProblems, false},
+		{"JSON Where Gob Belongs", []byte(`[{"id":1,"title":"One"}]`), exitProblems, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(liveFile+".bak", tt.backup, 0644)
+			var stdout, stderr bytes.Buffer
+			if got := runCommand([]string{"verify-backup", liveFile}, &stdout, &stderr); got != tt.wantExit {
+				t.Fatalf("got exit %d, want %d (stderr: %s)", got, tt.wantExit, stderr.String())
+			}
+			var report BackupReport
+			if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+				t.Fatalf("report is not JSON: %v", err)
+			}
+			if report.Restorable != tt.wantRestorable || report.InSync != tt.wantRestorable {
+				t.Errorf("got restorable=%t in_sync=%t, want %t", report.Restorable, report.InSync, tt.wantRestorable)
+			}
+
+			// The admin endpoint reads the backup in the configured storage's format
+			useTasks(t, tasks)
+			rec := httptest.NewRecorder()
+			VerifyBackup(liveFile+".bak", "gob")(rec, httptest.NewRequest("GET", "/admin/backup/verify", nil))
+			var served BackupReport
+			json.Unmarshal(rec.Body.Bytes(), &served)
+			if served.Restorable != tt.wantRestorable {
+				t.Errorf("/admin/backup/verify got restorable=%t, want %t: %s", served.Restorable, tt.wantRestorable, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// boardColumns are the Kanban columns in display order. A task's column follows from its state:
+//
+//	upcoming     open, with a start_date still ahead
+//	todo         open and available, with no checklist progress
+//	in_progress  open, with some checklist items done
+//	done         completed
+var boardColumns = []string{"upcoming", "todo", "in_progress", "done"}
+
+// boardWIPLimits caps how many tasks a column should hold, by column name; loaded from BOARD_WIP_LIMITS
+var boardWIPLimits = map[string]int{}
+
+// Board is the response of GET /boards/{project}
+type Board struct {
+	Project  string        `json:"project"`
+	Total    int           `json:"total"`
+	Columns  []BoardColumn `json:"columns"`
+	Warnings []string      `json:"warnings"`
+}
+
+// BoardColumn is one column of a board, its tasks in list order
+type BoardColumn struct {
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+	WIPLimit  int    `json:"wip_limit,omitempty"`
+	OverLimit bool   `json:"over_limit"`
+	Tasks     []Task `json:"tasks"`
+}
+
+// boardColumn returns the column task belongs in
+func (t Task) boardColumn(now time.Time) string {
+	switch {
+	case t.Completed:
+		return "done"
+	case !t.isAvailable(now):
+		return "upcoming"
+	case t.ChecklistCompletion != nil && *t.ChecklistCompletion > 0:
+		return "in_progress"
+	}
+	return "todo"
+}
+
+// parseWIPLimits reads a JSON object of column names to positive limits, e.g. {"in_progress": 3}
+func parseWIPLimits(raw string) (map[string]int, error) {
+	limits := map[string]int{}
+	if raw == "" {
+		return limits, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &limits); err != nil {
+		return nil, err
+	}
+	for name, limit := range limits {
+		known := false
+		for _, column := range boardColumns {
+			known = known || column == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q, must be one of %s", name, strings.Join(boardColumns, ", "))
+		}
+		if limit <= 0 {
+			return nil, fmt.Errorf("limit for %q must be positive", name)
+		}
+	}
+	return limits, nil
+}
+
+// buildBoard groups the tasks tagged project into columns and flags columns over their WIP limit
+func buildBoard(project string, list []Task, limits map[string]int, now time.Time) Board {
+	board := Board{Project: project, Warnings: []string{}}
+	byColumn := make(map[string][]Task, len(boardColumns))
+	for _, t := range list {
+		if t.hasTag(project) {
+			column := t.boardColumn(now)
+			byColumn[column] = append(byColumn[column], t)
+			board.Total++
+		}
+	}
+	for _, name := range boardColumns {
+		column := BoardColumn{Name: name, Count: len(byColumn[name]), WIPLimit: limits[name], Tasks: byColumn[name]}
+		if column.Tasks == nil {
+			column.Tasks = []Task{}
+		}
+		if column.WIPLimit > 0 && column.Count > column.WIPLimit {
+			column.OverLimit = true
+			board.Warnings = append(board.Warnings, fmt.Sprintf("%s has %d tasks, over its WIP limit of %d", name, column.Count, column.WIPLimit))
+		}
+		board.Columns = append(board.Columns, column)
+	}
+	return board
+}
+
+// Boards serves GET /boards/{project}, a Kanban board of the tasks tagged with the project's name
+func Boards(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	parts := strings.Split(strings.Trim(path.Clean(r.URL.Path), "/"), "/")
+	if len(parts) != 2 || parts[0] != "boards" {
+		writeJsonError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method: %s", r.Method)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	name, err := url.PathUnescape(parts[1])
+	if err != nil {
+		writeJsonError(w, http.StatusBadRequest, "Invalid project name")
+		return
+	}
+	project, err := normalizeTags([]string{name})
+	if err != nil {
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	board := buildBoard(project[0], storeFor(r).List(), boardWIPLimits, clock.Now())
+	if board.Total == 0 {
+		logErrorContext(r.Context(), "No tasks tagged %q for board", project[0])
+		writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No project named %q", project[0]))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBoards(t *testing.T) {
+	useFakeClock(t, testNow)
+	half := 50
+	useTasks(t, []Task{
+		{ID: 1, Title: "Plan", Tags: []string{"launch"}, Completed: true},
+		{ID: 2, Title: "Build", Tags: []string{"launch"}, ChecklistCompletion: &half},
+		{ID: 3, Title: "Test", Tags: []string{"launch"}, ChecklistCompletion: &half},
+		{ID: 4, Title: "Announce", Tags: []string{"launch"}, StartDate: "2024-06-01"},
+		{ID: 5, Title: "Water plants", Tags: []string{"home"}},
+	})
+	original := boardWIPLimits
+	boardWIPLimits = map[string]int{"in_progress": 1}
+	t.Cleanup(func() { boardWIPLimits = original })
+
+	tests := []struct {
+		name       string
+		url        string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Board", "/boards/Launch", http.StatusOK, `{"project":"launch","total":4,"columns":[` +
+			`{"name":"upcoming","count":1,"over_limit":false,"tasks":[{"id":4,"title":"Announce","completed":false,"start_date":"2024-06-01","tags":["launch"]}]},` +
+			`{"name":"todo","count":0,"over_limit":false,"tasks":[]},` +
+			`{"name":"in_progress","count":2,"wip_limit":1,"over_limit":true,"tasks":[{"id":2,"title":"Build","completed":false,"checklist_completion":50,"tags":["launch"]},{"id":3,"title":"Test","completed":false,"checklist_completion":50,"tags":["launch"]}]},` +
+			`{"name":"done","count":1,"over_limit":false,"tasks":[{"id":1,"title":"Plan","completed":true,"tags":["launch"]}]}],` +
+			`"warnings":["in_progress has 2 tasks, over its WIP limit of 1"]}`},
+		{"Unknown Project", "/boards/garden", http.StatusNotFound, `{"error":"No project named \"garden\"","code":"not_found"}`},
+		{"No Project", "/boards/a/b", http.StatusNotFound, `{"error":"Not Found","code":"not_found"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Boards(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestParseWIPLimits(t *testing.T) {
+	if _, err := parseWIPLimits(`{"doing": 2}`); err == nil || !strings.Contains(err.Error(), `unknown column "doing"`) {
+		t.Errorf("got %v, want unknown column error", err)
+	}
+	if _, err := parseWIPLimits(`{"todo": 0}`); err == nil {
+		t.Error("expected a zero limit to be rejected")
+	}
+	if limits, err := parseWIPLimits(`{"in_progress": 3}`); err != nil || limits["in_progress"] != 3 {
+		t.Errorf("got %v, %v", limits, err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// snapshotHeader carries the list generation: GET /tasks sends it, and bulk deletes must echo it back
+const snapshotHeader = "X-Snapshot-Token"
+
+func formatSnapshot(generation uint64) string {
+	return strconv.FormatUint(generation, 10)
+}
+
+// DeleteTasks handles DELETE /tasks?<filters>, removing every task the GET /tasks filters select. The
+// request must carry the X-Snapshot-Token of the list the client looked at; if anything changed since,
+// nothing is deleted and it gets a 409, so tasks others created or edited meanwhile are never swept up.
+func DeleteTasks(w http.ResponseWriter, r *http.Request) {
+	store := storeFor(r)
+	query, err := parseTaskListQuery(r.URL.Query())
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !query.filters() {
+		logErrorContext(r.Context(), "Bulk delete without a filter")
+		writeJsonError(w, http.StatusBadRequest, "Bulk delete needs a filter, such as ?completed=true")
+		return
+	}
+	raw := r.Header.Get(snapshotHeader)
+	if raw == "" {
+		logErrorContext(r.Context(), "Bulk delete without a snapshot token")
+		writeJsonError(w, http.StatusPreconditionRequired, "Bulk delete requires the X-Snapshot-Token header from GET /tasks")
+		return
+	}
+	generation, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		writeJsonError(w, http.StatusBadRequest, "Invalid X-Snapshot-Token")
+		return
+	}
+
+	var deleted []Task
+	err = store.WithTx(func(tx TaskStore) error {
+		var err error
+		if deleted, err = tx.DeleteWhere(generation, query.matcher(clock.Now())); err != nil {
+			return err
+		}
+		removed := make(map[int]bool, len(deleted))
+		for _, t := range deleted {
+			removed[t.ID] = true
+		}
+		return detachSubtasks(tx, removed)
+	})
+	if errors.Is(err, ErrSnapshotChanged) {
+		logErrorContext(r.Context(), "Bulk delete rejected, snapshot %d is stale", generation)
+		w.Header().Set(snapshotHeader, formatSnapshot(store.Generation()))
+		writeJsonError(w, http.StatusConflict, fmt.Sprintf("Tasks changed since snapshot %d; reload them and retry", generation))
+		return
+	}
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to delete tasks: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to delete tasks")
+		return
+	}
+	ids := make([]int, len(deleted))
+	for i, t := range deleted {
+		ids[i] = t.ID
+	}
+	logInfoContext(r.Context(), "Bulk delete removed %d tasks", len(ids))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(snapshotHeader, formatSnapshot(store.Generation()))
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "deleted": ids})
+}
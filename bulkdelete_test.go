@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDeleteTasks(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Done", Completed: true},
+		{ID: 2, Title: "Open"},
+		{ID: 3, Title: "Done too", Completed: true},
+		{ID: 4, Title: "Child of done", ParentID: 3},
+	})
+	send := func(method, url, snapshot string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		if snapshot != "" {
+			req.Header.Set(snapshotHeader, snapshot)
+		}
+		rec := httptest.NewRecorder()
+		Tasks(rec, req)
+		return rec
+	}
+
+	snapshot := send("GET", "/tasks", "").Header().Get(snapshotHeader)
+	if snapshot == "" {
+		t.Fatal("GET /tasks sent no snapshot token")
+	}
+	tests := []struct {
+		name       string
+		url        string
+		snapshot   string
+		wantStatus int
+		wantBody   string
+	}{
+		{"No Filter", "/tasks", snapshot, http.StatusBadRequest, `{"error":"Bulk delete needs a filter, such as ?completed=true","code":"invalid_request"}`},
+		{"No Snapshot", "/tasks?completed=true", "", http.StatusPreconditionRequired, `{"error":"Bulk delete requires the X-Snapshot-Token header from GET /tasks","code":"precondition_required"}`},
+		{"Stale Snapshot", "/tasks?completed=true", "999", http.StatusConflict, `{"error":"Tasks changed since snapshot 999; reload them and retry","code":"conflict"}`},
+		{"Delete Completed", "/tasks?completed=true", snapshot, http.StatusOK, `{"deleted":[1,3],"status":"success"}`},
+		// The same token is stale once the list has changed
+		{"Replay", "/tasks?completed=false", snapshot, http.StatusConflict, `{"error":"Tasks changed since snapshot ` + snapshot + `; reload them and retry","code":"conflict"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := send("DELETE", tt.url, tt.snapshot)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+
+	want := `[{"id":2,"title":"Open","completed":false},{"id":4,"title":"Child of done","completed":false,"updated_at":"2024-05-01T12:00:00Z","version":1}]`
+	if got := strings.TrimSpace(send("GET", "/tasks", "").Body.String()); got != want {
+		t.Errorf("after bulk delete got %s, want %s", got, want)
+	}
+}
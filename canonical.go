@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// marshalCanonical encodes v the way every JSON file the server writes is encoded: two-space indentation,
+// a trailing newline, and <, >, and & left as they are. Keys follow struct declaration order and map
+// keys are sorted, so equal values always encode to equal bytes.
+func marshalCanonical(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// storedTask is a task as the tasks file keeps it. The file lists tasks by ID, so a change to one task
+// touches only its own lines, and Position records where the task sits in the display order.
+type storedTask struct {
+	Task
+	// Position is nil in files written before positions were recorded, which kept tasks in display order
+	Position *int `json:"position,omitempty"`
+}
+
+// canonicalTasks converts a list in display order to the file's ID order
+func canonicalTasks(list []Task) []storedTask {
+	stored := make([]storedTask, len(list))
+	for i, t := range list {
+		position := i
+		stored[i] = storedTask{Task: t, Position: &position}
+	}
+	sort.SliceStable(stored, func(i, j int) bool { return stored[i].ID < stored[j].ID })
+	return stored
+}
+
+// displayOrder converts tasks read from the file back to display order. Tasks without a position
+// follow those with one, keeping their order in the file.
+func displayOrder(stored []storedTask) []Task {
+	sort.SliceStable(stored, func(i, j int) bool { return storedPosition(stored[i]) < storedPosition(stored[j]) })
+	list := make([]Task, len(stored))
+	for i, t := range stored {
+		list[i] = t.Task
+	}
+	return list
+}
+
+func storedPosition(t storedTask) int {
+	if t.Position == nil {
+		return math.MaxInt
+	}
+	return *t.Position
+}
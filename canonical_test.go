@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTasksFileIsCanonical(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	backend := fileBackend{filename: filename}
+	// Display order differs from ID order, as after PUT /tasks/order
+	list := []Task{
+		{ID: 3, Title: "Third & last", Tags: []string{"b", "a"}},
+		{ID: 1, Title: "First"},
+		{ID: 2, Title: "Second", Completed: true},
+	}
+	if err := backend.Save(list); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	first, _ := os.ReadFile(filename)
+	want := `[
+  {
+    "id": 1,
+    "title": "First",
+    "completed": false,
+    "position": 1
+  },
+  {
+    "id": 2,
+    "title": "Second",
+    "completed": true,
+    "position": 2
+  },
+  {
+    "id": 3,
+    "title": "Third & last",
+    "completed": false,
+    "tags": [
+      "b",
+      "a"
+    ],
+    "position": 0
+  }
+]
+`
+	if string(first) != want {
+		t.Errorf("file =\n%s\nwant\n%s", first, want)
+	}
+
+	loaded, err := backend.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, list) {
+		t.Errorf("loaded %+v, want display order %+v", loaded, list)
+	}
+	if err := backend.Save(loaded); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+	if second, _ := os.ReadFile(filename); string(second) != string(first) {
+		t.Errorf("saving the same tasks again changed the file:\n%s", second)
+	}
+}
+
+func TestDisplayOrder(t *testing.T) {
+	at := func(n int) *int { return &n }
+	tests := []struct {
+		name   string
+		stored []storedTask
+		want   []int
+	}{
+		{
+			name:   "positions",
+			stored: []storedTask{{Task: Task{ID: 1}, Position: at(2)}, {Task: Task{ID: 2}, Position: at(0)}, {Task: Task{ID: 3}, Position: at(1)}},
+			want:   []int{2, 3, 1},
+		},
+		{
+			name:   "file written before positions keeps its order",
+			stored: []storedTask{{Task: Task{ID: 5}}, {Task: Task{ID: 2}}, {Task: Task{ID: 9}}},
+			want:   []int{5, 2, 9},
+		},
+		{
+			name:   "tasks without a position go last",
+			stored: []storedTask{{Task: Task{ID: 1}}, {Task: Task{ID: 2}, Position: at(0)}, {Task: Task{ID: 3}}},
+			want:   []int{2, 1, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, task := range displayOrder(tt.stored) {
+				got = append(got, task.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalCanonicalSortsMapKeys(t *testing.T) {
+	prefs := map[string]Preferences{
+		"zoe":   {Filters: map[string]string{"tag": "work", "completed": "false"}},
+		"alice": {Sort: "title"},
+	}
+	first, err := marshalCanonical(prefs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		again, _ := marshalCanonical(prefs)
+		if string(again) != string(first) {
+			t.Fatalf("encoding changed between runs:\n%s\n%s", first, again)
+		}
+	}
+	if alice, zoe := strings.Index(string(first), `"alice"`), strings.Index(string(first), `"zoe"`); alice > zoe {
+		t.Errorf("keys not sorted:\n%s", first)
+	}
+	if completed, tag := strings.Index(string(first), `"completed"`), strings.Index(string(first), `"tag"`); completed > tag {
+		t.Errorf("filter keys not sorted:\n%s", first)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// chaosConfig sets how often the chaos middleware misbehaves. Percentages apply independently to each
+// request; a request can be both delayed and then failed or dropped.
+type chaosConfig struct {
+	// Latency is added to LatencyPercent of requests
+	Latency        time.Duration `json:"-"`
+	LatencyPercent float64       `json:"latency_percent"`
+	// ErrorPercent of requests get a 500 without reaching the handler
+	ErrorPercent float64 `json:"error_percent"`
+	// DropPercent of requests have their connection closed without a response
+	DropPercent float64 `json:"drop_percent"`
+}
+
+// chaosRand returns a number in [0, 100); tests replace it to make chaos predictable
+var chaosRand = func() float64 { return rand.Float64() * 100 }
+
+// parseChaosConfig reads a JSON object such as {"latency": "500ms", "latency_percent": 10,
+// "error_percent": 5, "drop_percent": 1}
+func parseChaosConfig(raw string) (chaosConfig, error) {
+	var config struct {
+		chaosConfig
+		Latency string `json:"latency"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return chaosConfig{}, fmt.Errorf("must be a JSON object of latency, latency_percent, error_percent, and drop_percent: %w", err)
+	}
+	if config.Latency != "" {
+		latency, err := time.ParseDuration(config.Latency)
+		if err != nil || latency < 0 {
+			return chaosConfig{}, fmt.Errorf("invalid latency %q", config.Latency)
+		}
+		config.chaosConfig.Latency = latency
+	}
+	for name, percent := range map[string]float64{"latency_percent": config.LatencyPercent, "error_percent": config.ErrorPercent, "drop_percent": config.DropPercent} {
+		if percent < 0 || percent > 100 {
+			return chaosConfig{}, fmt.Errorf("%s must be between 0 and 100", name)
+		}
+	}
+	return config.chaosConfig, nil
+}
+
+// Chaos injects latency, 500s, and dropped connections into a share of requests so clients' retry
+// logic and alerting can be exercised against a real server. Health checks are left alone so a load
+// balancer doesn't take the instance out of rotation. Every injected fault is logged and marked with
+// an X-Chaos header where a response is sent.
+func Chaos(next http.Handler, config chaosConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tasks/health" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if config.Latency > 0 && chaosRand() < config.LatencyPercent {
+			logInfoContext(r.Context(), "Chaos: delaying %s %s by %v", r.Method, r.URL.Path, config.Latency)
+			w.Header().Add("X-Chaos", "latency")
+			select {
+			case <-time.After(config.Latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if chaosRand() < config.ErrorPercent {
+			logInfoContext(r.Context(), "Chaos: failing %s %s", r.Method, r.URL.Path)
+			w.Header().Add("X-Chaos", "error")
+			writeJsonError(w, http.StatusInternalServerError, "Injected failure")
+			return
+		}
+		if chaosRand() < config.DropPercent {
+			logInfoContext(r.Context(), "Chaos: dropping %s %s", r.Method, r.URL.Path)
+			// The server closes the connection without a response and without logging a stack trace
+			panic(http.ErrAbortHandler)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,7 @@
+//go:build !chaos
+
+package main
+
+// chaosBuild reports whether this binary may enable the chaos middleware. Release builds can't, so a
+// stray CHAOS variable can never degrade production; build with -tags chaos for testing.
+const chaosBuild = false
@@ -0,0 +1,6 @@
+//go:build chaos
+
+package main
+
+// chaosBuild reports whether this binary may enable the chaos middleware
+const chaosBuild = true
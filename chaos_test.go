@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseChaosConfig(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    chaosConfig
+		wantErr string
+	}{
+		{`{"latency": "250ms", "latency_percent": 10, "error_percent": 5, "drop_percent": 1}`, chaosConfig{Latency: 250 * time.Millisecond, LatencyPercent: 10, ErrorPercent: 5, DropPercent: 1}, ""},
+		{`{"error_percent": 100}`, chaosConfig{ErrorPercent: 100}, ""},
+		{`{"latency": "soon"}`, chaosConfig{}, `invalid latency "soon"`},
+		{`{"drop_percent": 150}`, chaosConfig{}, "drop_percent must be between 0 and 100"},
+		{`{"errors": 5}`, chaosConfig{}, "must be a JSON object"},
+	}
+	for _, tt := range tests {
+		got, err := parseChaosConfig(tt.raw)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: got error %v, want %q", tt.raw, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %+v, %v, want %+v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestChaos(t *testing.T) {
+	// Each request draws three numbers: latency, error, then drop
+	var draws []float64
+	original := chaosRand
+	chaosRand = func() float64 {
+		next := draws[0]
+		draws = draws[1:]
+		return next
+	}
+	t.Cleanup(func() { chaosRand = original })
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	handler := Chaos(ok, chaosConfig{Latency: time.Millisecond, LatencyPercent: 50, ErrorPercent: 50, DropPercent: 50})
+
+	tests := []struct {
+		name       string
+		path       string
+		draws      []float64
+		wantStatus int
+		wantChaos  string
+		wantPanic  bool
+	}{
+		{"Untouched", "/tasks", []float64{90, 90, 90}, http.StatusOK, "", false},
+		{"Delayed", "/tasks", []float64{10, 90, 90}, http.StatusOK, "latency", false},
+		{"Failed", "/tasks", []float64{90, 10}, http.StatusInternalServerError, "error", false},
+		{"Dropped", "/tasks", []float64{90, 90, 10}, 0, "", true},
+		{"Health Is Spared", "/tasks/health", nil, http.StatusOK, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draws = tt.draws
+			rec := httptest.NewRecorder()
+			defer func() {
+				if recovered := recover(); (recovered == http.ErrAbortHandler) != tt.wantPanic {
+					t.Errorf("got panic %v, want abort %t", recovered, tt.wantPanic)
+				}
+			}()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus || rec.Header().Get("X-Chaos") != tt.wantChaos {
+				t.Errorf("got status %d chaos %q, want %d %q", rec.Code, rec.Header().Get("X-Chaos"), tt.wantStatus, tt.wantChaos)
+			}
+		})
+	}
+}
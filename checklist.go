@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ChecklistItem is a lightweight to-do entry attached to a task
+type ChecklistItem struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// checklistRequest is the body accepted by checklist mutations
+type checklistRequest struct {
+	Text  *string `json:"text"`
+	Done  *bool   `json:"done"`
+	Order []int   `json:"order"`
+}
+
+// parseChecklistPath extracts the task ID and optional item ID (0 when absent) from a routed checklist request
+func parseChecklistPath(r *http.Request) (int, int, error) {
+	taskID, err := ParseTaskID(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	raw := r.PathValue("item")
+	if raw == "" {
+		return taskID, 0, nil
+	}
+	itemID, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, 0, fmt.Errorf("Invalid checklist item ID")
+	}
+	return taskID, itemID, nil
+}
+
+// Checklist handles listing, adding, toggling, removing, and reordering checklist items on a task.
+//
+//	GET    /tasks/{id}/checklist          list items
+//	POST   /tasks/{id}/checklist          add an item {"text": "..."}
+//	PUT    /tasks/{id}/checklist          reorder items {"order": [3, 1, 2]}
+//	PUT    /tasks/{id}/checklist/{item}   update text and/or done state
+//	DELETE /tasks/{id}/checklist/{item}   remove an item
+func Checklist(w http.ResponseWriter, r *http.Request) {
+	taskID, itemID, err := parseChecklistPath(r)
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req checklistRequest
+	if r.Method == "POST" || r.Method == "PUT" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logErrorContext(r.Context(), "Failed to read request body in checklist %s", r.Method)
+			writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			logErrorContext(r.Context(), "Invalid JSON format in checklist %s", r.Method)
+			writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+	}
+
+	var text string
+	if req.Text != nil {
+		text = normalizeText(*req.Text)
+	}
+
+	if r.Method == "GET" {
+		task, err := storeFor(r).Get(taskID)
+		if err != nil {
+			logErrorContext(r.Context(), "Task not found with ID %d in checklist %s", taskID, r.Method)
+			writeJsonErrorCode(w, http.StatusNotFound, codeTaskNotFound, fmt.Sprintf("No task found with ID %d", taskID))
+			return
+		}
+		writeChecklist(w, http.StatusOK, &task)
+		return
+	}
+	// A PUT may leave an item's text out, but not blank it
+	if (r.Method == "POST" || r.Method == "PUT" && itemID != 0 && req.Text != nil) && strings.TrimSpace(text) == "" {
+		logErrorContext(r.Context(), "Empty checklist item text in checklist %s", r.Method)
+		writeJsonError(w, http.StatusBadRequest, "Checklist item text cannot be empty")
+		return
+	}
+
+	// status is the response status on success, failStatus the one used when the change is rejected
+	status, failStatus := http.StatusOK, http.StatusBadRequest
+	task, err := storeFor(r).Update(taskID, func(task *Task) error {
+		switch {
+		case r.Method == "POST":
+			// Item IDs are scoped to the task and never reused while the item list is non-empty
+			nextID := 1
+			for _, item := range task.Checklist {
+				if item.ID >= nextID {
+					nextID = item.ID + 1
+				}
+			}
+			done := req.Done != nil && *req.Done
+			task.Checklist = append(task.Checklist, ChecklistItem{ID: nextID, Text: text, Done: done})
+			status = http.StatusCreated
+		case r.Method == "PUT" && itemID == 0:
+			if err := reorderChecklist(task, req.Order); err != nil {
+				return err
+			}
+		case r.Method == "PUT":
+			itemIndex := findChecklistItemIndex(task, itemID)
+			if itemIndex == -1 {
+				failStatus = http.StatusNotFound
+				return fmt.Errorf("No checklist item found with ID %d", itemID)
+			}
+			item := &task.Checklist[itemIndex]
+			if req.Text != nil {
+				item.Text = text
+			}
+			// Omitting "done" toggles the item, so a bare PUT flips its state
+			if req.Done != nil {
+				item.Done = *req.Done
+			} else if req.Text == nil {
+				item.Done = !item.Done
+			}
+		case r.Method == "DELETE":
+			itemIndex := findChecklistItemIndex(task, itemID)
+			if itemIndex == -1 {
+				failStatus = http.StatusNotFound
+				return fmt.Errorf("No checklist item found with ID %d", itemID)
+			}
+			task.Checklist = append(task.Checklist[:itemIndex], task.Checklist[itemIndex+1:]...)
+		}
+		updateChecklistCompletion(task)
+		task.touch(clock.Now())
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		logErrorContext(r.Context(), "Task not found with ID %d in checklist %s", taskID, r.Method)
+		writeJsonErrorCode(w, http.StatusNotFound, codeTaskNotFound, fmt.Sprintf("No task found with ID %d", taskID))
+		return
+	}
+	if err != nil {
+		logErrorContext(r.Context(), "Checklist %s on task %d rejected: %v", r.Method, taskID, err)
+		writeJsonError(w, failStatus, err.Error())
+		return
+	}
+	writeChecklist(w, status, &task)
+}
+
+// writeChecklist responds with the task's checklist items and completion percentage
+func writeChecklist(w http.ResponseWriter, status int, task *Task) {
+	items := task.Checklist
+	if items == nil {
+		items = []ChecklistItem{}
+	}
+	completion := 0
+	if task.ChecklistCompletion != nil {
+		completion = *task.ChecklistCompletion
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id":    task.ID,
+		"items":      items,
+		"completion": completion,
+	})
+}
+
+func findChecklistItemIndex(task *Task, itemID int) int {
+	for i, item := range task.Checklist {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// reorderChecklist rearranges items to match order, which must list every item ID exactly once
+func reorderChecklist(task *Task, order []int) error {
+	if len(order) != len(task.Checklist) {
+		return fmt.Errorf("Order must list all %d checklist items", len(task.Checklist))
+	}
+	reordered := make([]ChecklistItem, 0, len(order))
+	seen := make(map[int]bool, len(order))
+	for _, id := range order {
+		if seen[id] {
+			return fmt.Errorf("Duplicate checklist item ID %d in order", id)
+		}
+		seen[id] = true
+		index := findChecklistItemIndex(task, id)
+		if index == -1 {
+			return fmt.Errorf("No checklist item found with ID %d", id)
+		}
+		reordered = append(reordered, task.Checklist[index])
+	}
+	task.Checklist = reordered
+	return nil
+}
+
+// normalizeChecklist checks items sent with a new task against what the checklist endpoints allow:
+// text that isn't blank, normalized like other task text, and item IDs unique to the task. Items
+// without an ID are numbered after the highest one.
+func normalizeChecklist(items []ChecklistItem) ([]ChecklistItem, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	normalized := make([]ChecklistItem, len(items))
+	seen := make(map[int]bool, len(items))
+	nextID := 1
+	for i, item := range items {
+		item.Text = normalizeText(item.Text)
+		if strings.TrimSpace(item.Text) == "" {
+			return nil, fmt.Errorf("Checklist item text cannot be empty")
+		}
+		if item.ID < 0 {
+			return nil, fmt.Errorf("Checklist item ID %d must be positive", item.ID)
+		}
+		if item.ID > 0 {
+			if seen[item.ID] {
+				return nil, fmt.Errorf("Duplicate checklist item ID %d", item.ID)
+			}
+			seen[item.ID] = true
+			nextID = max(nextID, item.ID+1)
+		}
+		normalized[i] = item
+	}
+	for i := range normalized {
+		if normalized[i].ID == 0 {
+			normalized[i].ID = nextID
+			nextID++
+		}
+	}
+	return normalized, nil
+}
+
+// updateChecklistCompletion recalculates the task's completion percentage after a checklist change
+func updateChecklistCompletion(task *Task) {
+	if len(task.Checklist) == 0 {
+		task.Checklist = nil
+		task.ChecklistCompletion = nil
+		return
+	}
+	done := 0
+	for _, item := range task.Checklist {
+		if item.Done {
+			done++
+		}
+	}
+	completion := done * 100 / len(task.Checklist)
+	task.ChecklistCompletion = &completion
+}
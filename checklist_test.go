@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type checklistTestCase struct {
+	name       string // Test case name
+	method     string // HTTP method
+	url        string // Endpoint
+	payload    string // The JSON payload sent in the request
+	wantStatus int    // Expected HTTP status code
+	wantBody   string // Expected response body
+}
+
+// checklistTests run in order, each building on the state left by the previous one
+var checklistTests = []checklistTestCase{
+	{
+		name:       "Empty Checklist",
+		method:     http.MethodGet,
+		url:        "/tasks/1/checklist",
+		wantStatus: http.StatusOK,
+		wantBody:   `{"completion":0,"items":[],"task_id":1}`,
+	},
+	{
+		name:       "Add First Item",
+		method:     http.MethodPost,
+		url:        "/tasks/1/checklist",
+		payload:    `{"text": "Move the couch"}`,
+		wantStatus: http.StatusCreated,
+		wantBody:   `{"completion":0,"items":[{"id":1,"text":"Move the couch","done":false}],"task_id":1}`,
+	},
+	{
+		name:       "Add Second Item",
+		method:     http.MethodPost,
+		url:        "/tasks/1/checklist",
+		payload:    `{"text": "Vacuum"}`,
+		wantStatus: http.StatusCreated,
+		wantBody:   `{"completion":0,"items":[{"id":1,"text":"Move the couch","done":false},{"id":2,"text":"Vacuum","done":false}],"task_id":1}`,
+	},
+	{
+		name:       "Add Empty Item",
+		method:     http.MethodPost,
+		url:        "/tasks/1/checklist",
+		payload:    `{"text": ""}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Checklist item text cannot be empty","code":"invalid_request"}`,
+	},
+	{
+		name:       "Toggle Item",
+		method:     http.MethodPut,
+		url:        "/tasks/1/checklist/1",
+		payload:    `{}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"completion":50,"items":[{"id":1,"text":"Move the couch","done":true},{"id":2,"text":"Vacuum","done":false}],"task_id":1}`,
+	},
+	{
+		name:       "Blank Item Text",
+		method:     http.MethodPut,
+		url:        "/tasks/1/checklist/2",
+		payload:    `{"text": "  "}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Checklist item text cannot be empty","code":"invalid_request"}`,
+	},
+	{
+		name:       "Reorder Items",
+		method:     http.MethodPut,
+		url:        "/tasks/1/checklist",
+		payload:    `{"order": [2, 1]}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"completion":50,"items":[{"id":2,"text":"Vacuum","done":false},{"id":1,"text":"Move the couch","done":true}],"task_id":1}`,
+	},
+	{
+		name:       "Reorder With Missing Item",
+		method:     http.MethodPut,
+		url:        "/tasks/1/checklist",
+		payload:    `{"order": [2]}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Order must list all 2 checklist items","code":"invalid_request"}`,
+	},
+	{
+		name:       "Remove Item",
+		method:     http.MethodDelete,
+		url:        "/tasks/1/checklist/2",
+		wantStatus: http.StatusOK,
+		wantBody:   `{"completion":100,"items":[{"id":1,"text":"Move the couch","done":true}],"task_id":1}`,
+	},
+	{
+		name:       "Remove Missing Item",
+		method:     http.MethodDelete,
+		url:        "/tasks/1/checklist/99",
+		wantStatus: http.StatusNotFound,
+		wantBody:   `{"error":"No checklist item found with ID 99","code":"not_found"}`,
+	},
+	{
+		name:       "Task Not Found",
+		method:     http.MethodGet,
+		url:        "/tasks/999/checklist",
+		wantStatus: http.StatusNotFound,
+		wantBody:   `{"error":"No task found with ID 999","code":"task_not_found"}`,
+	},
+	{
+		name:       "Parent Reflects Completion",
+		method:     http.MethodGet,
+		url:        "/tasks",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":1,"title":"Clean the carpet","completed":false,"checklist":[{"id":1,"text":"Move the couch","done":true}],"checklist_completion":100,"updated_at":"2024-05-01T12:00:00Z","version":5}]`,
+	},
+}
+
+func TestChecklist(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Clean the carpet", Completed: false},
+	})
+
+	for _, tt := range checklistTests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create the request
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+
+			// Call the handler
+			Tasks(rec, req)
+
+			// Validate the status code
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Test %s: got status %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			}
+
+			// Validate the response body
+			gotBody := strings.TrimSpace(rec.Body.String())
+			if gotBody != tt.wantBody {
+				t.Errorf("Test %s: got body %s, want %s", tt.name, gotBody, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestCreateTaskChecklist(t *testing.T) {
+	useFakeClock(t, testNow)
+	tests := []struct {
+		name          string
+		payload       string
+		wantStatus    int
+		wantChecklist string // Expected checklist and completion, or error body
+	}{
+		{
+			name:          "Completion Recomputed",
+			payload:       `{"title":"Paint","checklist":[{"text":"Tape","done":true},{"id":5,"text":"Cafe\u0301"}],"checklist_completion":100}`,
+			wantStatus:    http.StatusCreated,
+			wantChecklist: `[{"id":6,"text":"Tape","done":true},{"id":5,"text":"Café","done":false}] 50`,
+		},
+		{
+			name:          "Completion Without Checklist",
+			payload:       `{"title":"Paint","checklist":[],"checklist_completion":100}`,
+			wantStatus:    http.StatusCreated,
+			wantChecklist: `null`,
+		},
+		{
+			name:          "Empty Item Text",
+			payload:       `{"title":"Paint","checklist":[{"text":"  "}]}`,
+			wantStatus:    http.StatusBadRequest,
+			wantChecklist: `{"error":"Checklist item text cannot be empty","code":"validation_failed"}`,
+		},
+		{
+			name:          "Duplicate Item IDs",
+			payload:       `{"title":"Paint","checklist":[{"id":2,"text":"Tape"},{"id":2,"text":"Prime"}]}`,
+			wantStatus:    http.StatusBadRequest,
+			wantChecklist: `{"error":"Duplicate checklist item ID 2","code":"validation_failed"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTasks(t, nil)
+			rec := httptest.NewRecorder()
+			Tasks(rec, httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tt.payload)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			got := strings.TrimSpace(rec.Body.String())
+			if rec.Code == http.StatusCreated {
+				var task Task
+				if err := json.Unmarshal(rec.Body.Bytes(), &task); err != nil {
+					t.Fatal(err)
+				}
+				items, _ := json.Marshal(task.Checklist)
+				got = string(items)
+				if task.ChecklistCompletion != nil {
+					got = fmt.Sprintf("%s %d", items, *task.ChecklistCompletion)
+				}
+			}
+			if got != tt.wantChecklist {
+				t.Errorf("got %s, want %s", got, tt.wantChecklist)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes shared by the CLI commands
+const (
+	exitOK       = 0
+	exitProblems = 1
+	exitUsage    = 2
+)
+
+// runCommand dispatches a CLI subcommand and returns the process exit code
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "validate":
+		return runValidate(args[1:], stdout, stderr)
+	case "verify-backup":
+		return runVerifyBackup(args[1:], stdout, stderr)
+	case "convert":
+		return runConvert(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		fmt.Fprintln(stderr, "usage: task-tracker [validate <file> | verify-backup <file> | convert <from> <to>]")
+		return exitUsage
+	}
+}
+
+// ValidationProblem describes one issue found in a tasks file
+type ValidationProblem struct {
+	Index   int    `json:"index"`
+	ID      int    `json:"id,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationReport is the machine-readable output of the validate command
+type ValidationReport struct {
+	File      string              `json:"file"`
+	Valid     bool                `json:"valid"`
+	TaskCount int                 `json:"task_count"`
+	Problems  []ValidationProblem `json:"problems"`
+}
+
+// runValidate implements `task-tracker validate [--force] [--users <file>] <file>`, printing a JSON report
+// and exiting non-zero when the file has problems so it can gate deploys
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	force := fs.Bool("force", false, "read the file even if another process holds its lock")
+	usersFile := fs.String("users", os.Getenv("USERS_FILE"), "users file to check task owners against")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: task-tracker validate [--force] [--users <file>] <file>")
+		return exitUsage
+	}
+	filename := fs.Arg(0)
+
+	// owners stays nil without a users file, and then owners aren't checked
+	var owners map[string]bool
+	if *usersFile != "" {
+		loaded, err := loadUsers(*usersFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "validate: %v\n", err)
+			return exitUsage
+		}
+		owners = make(map[string]bool, len(loaded))
+		for _, u := range loaded {
+			owners[u.Name] = true
+		}
+	}
+
+	if !*force {
+		unlock, err := acquireFileLock(filename)
+		if err != nil {
+			fmt.Fprintf(stderr, "validate: %v (use --force to read anyway)\n", err)
+			return exitUsage
+		}
+		defer unlock()
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(stderr, "validate: %v\n", err)
+		return exitUsage
+	}
+
+	report := validateTasksData(data, owners)
+	report.File = filename
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if !report.Valid {
+		return exitProblems
+	}
+	return exitOK
+}
+
+// validateTasksData checks raw tasks file contents for schema errors, duplicate IDs, invalid field values,
+// missing parents, and, when owners is non-nil, owners that aren't among them
+func validateTasksData(data []byte, owners map[string]bool) ValidationReport {
+	report := ValidationReport{Problems: []ValidationProblem{}}
+
+	var rows []json.RawMessage
+	if err := json.Unmarshal(data, &rows); err != nil {
+		report.Problems = append(report.Problems, ValidationProblem{Index: -1, Message: "file is not a JSON array of tasks: " + err.Error()})
+		return report
+	}
+	report.TaskCount = len(rows)
+
+	seen := make(map[int]int, len(rows))
+	// tasks holds the rows that decoded, and indexes their positions in the file
+	var tasks []Task
+	var indexes []int
+	for i, row := range rows {
+		// Decode strictly so misspelled or unexpected fields are reported instead of silently dropped
+		var stored storedTask
+		decoder := json.NewDecoder(bytes.NewReader(row))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&stored); err != nil {
+			report.Problems = append(report.Problems, ValidationProblem{Index: i, Message: "schema error: " + err.Error()})
+			continue
+		}
+		task := stored.Task
+		for _, problem := range validateTask(task) {
+			problem.Index = i
+			report.Problems = append(report.Problems, problem)
+		}
+		if owners != nil && task.Owner != "" && !owners[task.Owner] {
+			report.Problems = append(report.Problems, ValidationProblem{Index: i, ID: task.ID, Field: "owner", Message: fmt.Sprintf("owner %q is not in the users file", task.Owner)})
+		}
+		if first, ok := seen[task.ID]; ok {
+			report.Problems = append(report.Problems, ValidationProblem{Index: i, ID: task.ID, Field: "id", Message: fmt.Sprintf("duplicate ID, first used at index %d", first)})
+		} else {
+			seen[task.ID] = i
+		}
+		tasks, indexes = append(tasks, task), append(indexes, i)
+	}
+	for _, i := range danglingParents(tasks) {
+		report.Problems = append(report.Problems, ValidationProblem{Index: indexes[i], ID: tasks[i].ID, Field: "parent_id", Message: danglingParentMessage(tasks[i].ParentID)})
+	}
+
+	report.Valid = len(report.Problems) == 0
+	return report
+}
+
+// validateTask reports field-level problems with a single stored task
+func validateTask(task Task) []ValidationProblem {
+	var problems []ValidationProblem
+	if task.ID <= 0 {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: "id", Message: "ID must be a positive integer"})
+	} else if task.ID > maxTaskID {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: "id", Message: "ID out of range"})
+	}
+	if task.Title == "" {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: "title", Message: "title cannot be empty"})
+	}
+	var invalidDate *dateError
+	if err := validateTaskDates(&task); errors.As(err, &invalidDate) {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: invalidDate.field, Message: invalidDate.message})
+	}
+	if err := validatePriority(task.Priority); err != nil {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: "priority", Message: err.Error()})
+	}
+	if _, err := normalizeTags(task.Tags); err != nil {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: "tags", Message: err.Error()})
+	}
+	if _, err := normalizeNotes(task.Notes); err != nil {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: "notes", Message: err.Error()})
+	}
+	if _, err := normalizeLinks(task.Links); err != nil {
+		problems = append(problems, ValidationProblem{ID: task.ID, Field: "links", Message: err.Error()})
+	}
+	itemIDs := make(map[int]bool, len(task.Checklist))
+	for _, item := range task.Checklist {
+		if itemIDs[item.ID] {
+			problems = append(problems, ValidationProblem{ID: task.ID, Field: "checklist", Message: fmt.Sprintf("duplicate checklist item ID %d", item.ID)})
+		}
+		itemIDs[item.ID] = true
+		if item.Text == "" {
+			problems = append(problems, ValidationProblem{ID: task.ID, Field: "checklist", Message: fmt.Sprintf("checklist item %d has empty text", item.ID)})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+type validateTestCase struct {
+	name         string // Test case name
+	contents     string // Tasks file contents
+	wantExit     int    // Expected exit code
+	wantProblems int    // Expected number of reported problems
+}
+
+var validateTests = []validateTestCase{
+	{
+		name:         "Valid File",
+		contents:     `[{"id":1,"title":"Task 1","completed":false},{"id":2,"title":"Task 2","completed":true}]`,
+		wantExit:     exitOK,
+		wantProblems: 0,
+	},
+	{
+		name:         "Positions",
+		contents:     `[{"id":1,"title":"Task 1","position":1},{"id":2,"title":"Task 2","position":0}]`,
+		wantExit:     exitOK,
+		wantProblems: 0,
+	},
+	{
+		name:         "Duplicate IDs",
+		contents:     `[{"id":1,"title":"Task 1"},{"id":1,"title":"Task 2"}]`,
+		wantExit:     exitProblems,
+		wantProblems: 1,
+	},
+	{
+		name:         "Missing Parent",
+		contents:     `[{"id":2,"title":"Subtask","parent_id":1},{"id":3,"title":"Child","parent_id":4},{"id":4,"title":"Parent"}]`,
+		wantExit:     exitProblems,
+		wantProblems: 1,
+	},
+	{
+		name:         "Bad Link",
+		contents:     `[{"id":1,"title":"Task 1","links":[{"url":"https://example.com/a"},{"url":"ftp://example.com/b"}]}]`,
+		wantExit:     exitProblems,
+		wantProblems: 1,
+	},
+	{
+		name:         "Empty Title And Bad ID",
+		contents:     `[{"id":0,"title":""}]`,
+		wantExit:     exitProblems,
+		wantProblems: 2,
+	},
+	{
+		name:         "Unknown Field",
+		contents:     `[{"id":1,"title":"Task 1","complete":true}]`,
+		wantExit:     exitProblems,
+		wantProblems: 1,
+	},
+	{
+		name:         "Wrong Type",
+		contents:     `[{"id":"one","title":"Task 1"}]`,
+		wantExit:     exitProblems,
+		wantProblems: 1,
+	},
+	{
+		name:         "Not An Array",
+		contents:     `{"id":1}`,
+		wantExit:     exitProblems,
+		wantProblems: 1,
+	},
+}
+
+func TestValidateCommand(t *testing.T) {
+	tempFile := "test_validate_tasks.json"
+	defer os.Remove(tempFile)
+
+	for _, tt := range validateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(tempFile, []byte(tt.contents), 0644); err != nil {
+				t.Fatalf("Failed to write tasks file: %v", err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			exit := runCommand([]string{"validate", tempFile}, &stdout, &stderr)
+			if exit != tt.wantExit {
+				t.Errorf("Test %s: got exit %d, want %d; stderr: %s", tt.name, exit, tt.wantExit, stderr.String())
+			}
+
+			var report ValidationReport
+			if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+				t.Fatalf("Test %s: report is not valid JSON: %v", tt.name, err)
+			}
+			if len(report.Problems) != tt.wantProblems {
+				t.Errorf("Test %s: got %d problems, want %d: %+v", tt.name, len(report.Problems), tt.wantProblems, report.Problems)
+			}
+		})
+	}
+}
+
+func TestValidateCommandUsers(t *testing.T) {
+	tasksFile, usersFile := "test_validate_tasks.json", "test_validate_users.json"
+	defer os.Remove(tasksFile)
+	defer os.Remove(usersFile)
+	if err := os.WriteFile(usersFile, []byte(`[{"name":"alice"}]`), 0644); err != nil {
+		t.Fatalf("Failed to write users file: %v", err)
+	}
+	tasks := `[{"id":1,"title":"Task 1","owner":"alice"},{"id":2,"title":"Task 2","owner":"bob"},{"id":3,"title":"Task 3"}]`
+	if err := os.WriteFile(tasksFile, []byte(tasks), 0644); err != nil {
+		t.Fatalf("Failed to write tasks file: %v", err)
+	}
+
+	// Owners are only checked when there is a users file to check them against
+	var stdout, stderr bytes.Buffer
+	if exit := runCommand([]string{"validate", tasksFile}, &stdout, &stderr); exit != exitOK {
+		t.Errorf("got exit %d without a users file, want %d; stdout: %s", exit, exitOK, stdout.String())
+	}
+
+	stdout.Reset()
+	if exit := runCommand([]string{"validate", "--users", usersFile, tasksFile}, &stdout, &stderr); exit != exitProblems {
+		t.Errorf("got exit %d with a users file, want %d", exit, exitProblems)
+	}
+	var report ValidationReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	want := []ValidationProblem{{Index: 1, ID: 2, Field: "owner", Message: `owner "bob" is not in the users file`}}
+	if !reflect.DeepEqual(report.Problems, want) {
+		t.Errorf("got problems %+v, want %+v", report.Problems, want)
+	}
+}
+
+func TestValidateCommandMissingFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if exit := runCommand([]string{"validate", "nonexistent_tasks.json"}, &stdout, &stderr); exit != exitUsage {
+		t.Errorf("got exit %d for missing file, want %d", exit, exitUsage)
+	}
+}
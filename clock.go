@@ -0,0 +1,31 @@
+package main
+
+import "time"
+
+// Clock is the source of time for the store, background jobs, and middleware.
+// Swapping it out lets tests pin timestamps and elapsed durations.
+type Clock interface {
+	// Now returns the current wall-clock time in UTC
+	Now() time.Time
+	// Stopwatch starts a measurement and returns a function reporting the time elapsed since
+	Stopwatch() func() time.Duration
+}
+
+// systemClock reads the real clock, measuring elapsed time with the monotonic reading
+// so wall-clock adjustments can't produce negative or inflated durations
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+func (systemClock) Stopwatch() func() time.Duration {
+	// time.Now carries a monotonic reading that UTC() would strip, so keep it unconverted here
+	start := time.Now()
+	return func() time.Duration {
+		return time.Since(start)
+	}
+}
+
+// clock is the Clock used throughout the server
+var clock Clock = systemClock{}
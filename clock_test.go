@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced Clock for deterministic tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now.UTC()}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Stopwatch() func() time.Duration {
+	start := c.Now()
+	return func() time.Duration {
+		return c.Now().Sub(start)
+	}
+}
+
+// Advance moves the fake time forward by d
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// useFakeClock installs a fake clock for the duration of the test
+func useFakeClock(t *testing.T, now time.Time) *fakeClock {
+	t.Helper()
+	fake := newFakeClock(now)
+	original := clock
+	clock = fake
+	t.Cleanup(func() {
+		clock = original
+	})
+	return fake
+}
+
+func TestSystemClockIsUTC(t *testing.T) {
+	if loc := (systemClock{}).Now().Location(); loc != time.UTC {
+		t.Errorf("got location %v, want UTC", loc)
+	}
+}
+
+func TestMutationsUseInjectedClock(t *testing.T) {
+	fixed := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	useFakeClock(t, fixed)
+	useTasks(t, []Task{})
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title": "Clocked task"}`))
+	rec := httptest.NewRecorder()
+	Tasks(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if !taskStore.Modified().Equal(fixed) {
+		t.Errorf("got Modified() %v, want %v", taskStore.Modified(), fixed)
+	}
+}
+
+func TestDailyCounterResetFollowsClock(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC))
+	counters = []Counter{}
+
+	req := httptest.NewRequest(http.MethodPost, "/counters", strings.NewReader(`{"name": "water", "reset": "daily"}`))
+	Counters(httptest.NewRecorder(), req)
+	Counters(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/counters/water/increment", nil))
+	if counters[0].Value != 1 {
+		t.Fatalf("got value %d, want 1", counters[0].Value)
+	}
+
+	// Crossing midnight UTC resets the counter on next access
+	fake.Advance(2 * time.Hour)
+	Counters(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/counters/water", nil))
+	if counters[0].Value != 0 || counters[0].LastReset != "2024-01-02" {
+		t.Errorf("expected reset on the new day, got %+v", counters[0])
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// stress lengthens the concurrency tests; run with `go test -race -run Concurrent . -args -stress`
+var stress = flag.Bool("stress", false, "run concurrency tests with many more workers and iterations")
+
+// concurrencyLoad returns the number of workers and iterations per worker for the current mode
+func concurrencyLoad() (int, int) {
+	if *stress {
+		return 64, 500
+	}
+	return 16, 50
+}
+
+func TestConcurrentMixedRequests(t *testing.T) {
+	store := useTasks(t, []Task{})
+
+	workers, iterations := concurrencyLoad()
+
+	var (
+		wg         sync.WaitGroup
+		createdMu  sync.Mutex
+		createdIDs []int
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				// Create a task and remember its ID
+				payload := fmt.Sprintf(`{"title": "Worker %d task %d"}`, worker, i)
+				req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(payload))
+				rec := httptest.NewRecorder()
+				Tasks(rec, req)
+				if rec.Code != http.StatusCreated {
+					t.Errorf("POST failed for worker %d: got status %d", worker, rec.Code)
+					return
+				}
+				var created Task
+				if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+					t.Errorf("POST returned invalid JSON for worker %d: %v", worker, err)
+					return
+				}
+				createdMu.Lock()
+				createdIDs = append(createdIDs, created.ID)
+				createdMu.Unlock()
+
+				// Read the full list while others write
+				req = httptest.NewRequest(http.MethodGet, "/tasks", nil)
+				rec = httptest.NewRecorder()
+				Tasks(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Errorf("GET failed for worker %d: got status %d", worker, rec.Code)
+				}
+
+				// Update our own task, then delete every other one
+				payload = fmt.Sprintf(`{"title": "Worker %d task %d (updated)", "completed": true}`, worker, i)
+				req = httptest.NewRequest(http.MethodPut, fmt.Sprintf("/tasks/%d", created.ID), strings.NewReader(payload))
+				rec = httptest.NewRecorder()
+				Tasks(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Errorf("PUT failed for worker %d: got status %d", worker, rec.Code)
+				}
+				if i%2 == 0 {
+					req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/tasks/%d", created.ID), nil)
+					rec = httptest.NewRecorder()
+					Tasks(rec, req)
+					if rec.Code != http.StatusOK {
+						t.Errorf("DELETE failed for worker %d: got status %d", worker, rec.Code)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Every issued ID is unique and lastID matches the number of creations
+	total := workers * iterations
+	seen := make(map[int]bool, len(createdIDs))
+	for _, id := range createdIDs {
+		if seen[id] {
+			t.Errorf("ID %d was issued more than once", id)
+		}
+		seen[id] = true
+	}
+	if len(createdIDs) != total || store.lastID != total {
+		t.Errorf("Expected %d creations and lastID %d, got %d creations and lastID %d", total, total, len(createdIDs), store.lastID)
+	}
+
+	// Exactly the odd iterations survive, all updated, with no duplicates in the store
+	wantRemaining := workers * (iterations / 2)
+	tasks := taskStore.List()
+	if len(tasks) != wantRemaining {
+		t.Errorf("Expected %d remaining tasks, got %d", wantRemaining, len(tasks))
+	}
+	stored := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		if stored[task.ID] {
+			t.Errorf("Task %d appears more than once in the store", task.ID)
+		}
+		stored[task.ID] = true
+		if !task.Completed || !strings.HasSuffix(task.Title, "(updated)") {
+			t.Errorf("Task %d was not updated: %+v", task.ID, task)
+		}
+	}
+}
+
+func TestConcurrentChecklistAndFeed(t *testing.T) {
+	useTasks(t, []Task{{ID: 1, Title: "Shared task"}})
+
+	workers, iterations := concurrencyLoad()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				payload := fmt.Sprintf(`{"text": "Worker %d item %d"}`, worker, i)
+				req := httptest.NewRequest(http.MethodPost, "/tasks/1/checklist", strings.NewReader(payload))
+				rec := httptest.NewRecorder()
+				Tasks(rec, req)
+				if rec.Code != http.StatusCreated {
+					t.Errorf("checklist POST failed for worker %d: got status %d", worker, rec.Code)
+				}
+
+				req = httptest.NewRequest(http.MethodGet, "/feed.json", nil)
+				rec = httptest.NewRecorder()
+				JSONFeedHandler(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Errorf("feed GET failed for worker %d: got status %d", worker, rec.Code)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Checklist item IDs stay unique under contention
+	items := taskStore.List()[0].Checklist
+	if len(items) != workers*iterations {
+		t.Fatalf("Expected %d checklist items, got %d", workers*iterations, len(items))
+	}
+	seen := make(map[int]bool, len(items))
+	for _, item := range items {
+		if seen[item.ID] {
+			t.Errorf("Checklist item ID %d was issued more than once", item.ID)
+		}
+		seen[item.ID] = true
+	}
+}
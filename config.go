@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config says where the server listens and where it keeps tasks. Each setting comes from, in order of
+// precedence: a command-line flag, an environment variable, the config file, then the default.
+type Config struct {
+	// Addr is the public API address, e.g. ":8000" or "127.0.0.1:9000"
+	Addr string
+	// Storage is the backend: "file", "gob", or "sqlite"
+	Storage string
+	// TasksFile is the tasks file for file and gob storage; it defaults to tasks.json or tasks.gob
+	TasksFile string
+	// DBPath is the SQLite database for sqlite storage
+	DBPath string
+	// CountersFile is where counters are kept; it defaults to counters.json beside the tasks file or database
+	CountersFile string
+	// LogLevel is the least severe level logged: "trace", "debug", "warn", "error", or "" for info
+	LogLevel string
+	// LogFile is where logs are written instead of stderr, rotated by size
+	LogFile string
+}
+
+// configSetting ties one Config field to its flag, environment variable, and config file key
+type configSetting struct {
+	name  string // flag name and config file key, with "-" for "_" in the file
+	env   string
+	usage string
+	field func(*Config) *string
+}
+
+var configSettings = []configSetting{
+	{"addr", "TASKS_ADDR", "public API listen address (default \":$PORT\", or \":8000\")", func(c *Config) *string { return &c.Addr }},
+	{"storage", "TASKS_STORAGE", "task storage backend: file (tasks.json), gob (tasks.gob), or sqlite (default \"file\")", func(c *Config) *string { return &c.Storage }},
+	{"tasks-file", "TASKS_FILE", "tasks file for file or gob storage (default tasks.json or tasks.gob)", func(c *Config) *string { return &c.TasksFile }},
+	{"db", "TASKS_DB", "SQLite database path when --storage=sqlite (default \"tasks.db\")", func(c *Config) *string { return &c.DBPath }},
+	{"counters-file", "TASKS_COUNTERS_FILE", "counters file (default counters.json beside the tasks file or database)", func(c *Config) *string { return &c.CountersFile }},
+	{"log-level", "TASKS_LOG_LEVEL", "least severe log level written: trace, debug, info, warn, or error (default \"info\"); -v and -vv override it", func(c *Config) *string { return &c.LogLevel }},
+	{"log-file", "TASKS_LOG_FILE", "write logs to this file instead of stderr, rotating it by size", func(c *Config) *string { return &c.LogFile }},
+}
+
+// defineConfigFlags registers a flag for every setting, plus --config for the config file, on fs
+func defineConfigFlags(fs *flag.FlagSet) {
+	for _, setting := range configSettings {
+		fs.String(setting.name, "", setting.usage)
+	}
+	fs.String("config", "", "config file of `key = \"value\"` lines (default $TASKS_CONFIG)")
+}
+
+// resolveConfig builds the Config from the flags parsed on fs, getenv, and the config file they name
+func resolveConfig(fs *flag.FlagSet, getenv func(string) string) (Config, error) {
+	config := Config{Addr: ":8000", Storage: "file", DBPath: "tasks.db"}
+	// PORT predates TASKS_ADDR and still sets the default port
+	if port := getenv("PORT"); port != "" {
+		config.Addr = ":" + port
+	}
+
+	path := getenv("TASKS_CONFIG")
+	if f := fs.Lookup("config"); f != nil && f.Value.String() != "" {
+		path = f.Value.String()
+	}
+	if path != "" {
+		if err := readConfigFile(path, &config); err != nil {
+			return Config{}, err
+		}
+	}
+	for _, setting := range configSettings {
+		if value := getenv(setting.env); value != "" {
+			*setting.field(&config) = value
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, setting := range configSettings {
+			if f.Name == setting.name {
+				*setting.field(&config) = f.Value.String()
+			}
+		}
+	})
+
+	switch config.Storage {
+	case "file":
+		if config.TasksFile == "" {
+			config.TasksFile = "tasks.json"
+		}
+	case "gob":
+		if config.TasksFile == "" {
+			config.TasksFile = "tasks.gob"
+		}
+	case "sqlite":
+	default:
+		return Config{}, fmt.Errorf("unknown storage %q, must be file, gob, or sqlite", config.Storage)
+	}
+	if config.CountersFile == "" {
+		store := config.TasksFile
+		if config.Storage == "sqlite" {
+			store = config.DBPath
+		}
+		config.CountersFile = filepath.Join(filepath.Dir(store), "counters.json")
+	}
+	if _, _, err := net.SplitHostPort(config.Addr); err != nil {
+		return Config{}, fmt.Errorf("invalid listen address %q: %w", config.Addr, err)
+	}
+	if _, err := parseLogLevel(config.LogLevel); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// readConfigFile applies the settings in a TOML-style file of top-level `key = "value"` lines to config.
+// Blank lines and # comments are ignored; an unknown key is an error so typos don't go unnoticed.
+func readConfigFile(path string, config *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected key = value", path, n)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return fmt.Errorf("%s:%d: invalid string for %s", path, n, key)
+			}
+		}
+		found := false
+		for _, setting := range configSettings {
+			if key == strings.ReplaceAll(setting.name, "-", "_") {
+				*setting.field(config) = value
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s:%d: unknown setting %q", path, n, key)
+		}
+	}
+	return scanner.Err()
+}
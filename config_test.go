@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tasks.toml")
+	os.WriteFile(file, []byte("# instance two\naddr = \"127.0.0.1:9001\"\ntasks_file = \"/data/file.json\"\nstorage = gob\n"), 0644)
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    Config
+		wantErr bool
+	}{
+		{"Defaults", nil, nil, Config{Addr: ":8000", Storage: "file", TasksFile: "tasks.json", DBPath: "tasks.db", CountersFile: "counters.json"}, false},
+		{"PORT sets the default port", nil, map[string]string{"PORT": "8080"}, Config{Addr: ":8080", Storage: "file", TasksFile: "tasks.json", DBPath: "tasks.db", CountersFile: "counters.json"}, false},
+		{"Gob default file", []string{"--storage=gob"}, nil, Config{Addr: ":8000", Storage: "gob", TasksFile: "tasks.gob", DBPath: "tasks.db", CountersFile: "counters.json"}, false},
+		{"Environment", nil, map[string]string{"TASKS_ADDR": "127.0.0.1:9000", "TASKS_FILE": "/data/tasks.json", "PORT": "8080"},
+			Config{Addr: "127.0.0.1:9000", Storage: "file", TasksFile: "/data/tasks.json", DBPath: "tasks.db", CountersFile: "/data/counters.json"}, false},
+		{"Config file", []string{"--config", file}, nil, Config{Addr: "127.0.0.1:9001", Storage: "gob", TasksFile: "/data/file.json", DBPath: "tasks.db", CountersFile: "/data/counters.json"}, false},
+		{"Config file from environment", nil, map[string]string{"TASKS_CONFIG": file}, Config{Addr: "127.0.0.1:9001", Storage: "gob", TasksFile: "/data/file.json", DBPath: "tasks.db", CountersFile: "/data/counters.json"}, false},
+		{"Environment beats config file", nil, map[string]string{"TASKS_CONFIG": file, "TASKS_ADDR": ":7000"},
+			Config{Addr: ":7000", Storage: "gob", TasksFile: "/data/file.json", DBPath: "tasks.db", CountersFile: "/data/counters.json"}, false},
+		{"Flag beats environment", []string{"--addr=:6000", "--tasks-file=mine.json"}, map[string]string{"TASKS_CONFIG": file, "TASKS_ADDR": ":7000"},
+			Config{Addr: ":6000", Storage: "gob", TasksFile: "mine.json", DBPath: "tasks.db", CountersFile: "counters.json"}, false},
+		{"Log settings", []string{"--log-file=/var/log/tasks.log"}, map[string]string{"TASKS_LOG_LEVEL": "debug"},
+			Config{Addr: ":8000", Storage: "file", TasksFile: "tasks.json", DBPath: "tasks.db", CountersFile: "counters.json", LogLevel: "debug", LogFile: "/var/log/tasks.log"}, false},
+		{"Counters beside the database", []string{"--storage=sqlite", "--db=/var/lib/tasks/tasks.db"}, nil,
+			Config{Addr: ":8000", Storage: "sqlite", DBPath: "/var/lib/tasks/tasks.db", CountersFile: "/var/lib/tasks/counters.json"}, false},
+		{"Counters file setting", []string{"--tasks-file=/data/tasks.json"}, map[string]string{"TASKS_COUNTERS_FILE": "/srv/counters.json"},
+			Config{Addr: ":8000", Storage: "file", TasksFile: "/data/tasks.json", DBPath: "tasks.db", CountersFile: "/srv/counters.json"}, false},
+		{"Unknown storage", []string{"--storage=csv"}, nil, Config{}, true},
+		{"Unknown log level", []string{"--log-level=loud"}, nil, Config{}, true},
+		{"Address without port", []string{"--addr=localhost"}, nil, Config{}, true},
+		{"Missing config file", []string{"--config=/does/not/exist"}, nil, Config{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			defineConfigFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			got, err := resolveConfig(fs, func(key string) string { return tt.env[key] })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Unknown key", "adress = \":8000\"\n"},
+		{"Missing equals", "addr\n"},
+		{"Bad string", "addr = \":8000\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "tasks.toml")
+			os.WriteFile(file, []byte(tt.content), 0644)
+			var config Config
+			if err := readConfigFile(file, &config); err == nil {
+				t.Errorf("accepted %q", tt.content)
+			}
+		})
+	}
+}
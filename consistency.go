@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ConsistencyIssue is one problem found in the stored tasks
+type ConsistencyIssue struct {
+	// Check names the rule broken: "field", "duplicate_id", "dangling_parent", "last_id", or "checklist_completion"
+	Check    string `json:"check"`
+	TaskID   int    `json:"task_id,omitempty"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+	Repaired bool   `json:"repaired"`
+}
+
+// ConsistencyReport is the result of one consistency check
+type ConsistencyReport struct {
+	CheckedAt time.Time          `json:"checked_at"`
+	TaskCount int                `json:"task_count"`
+	Issues    []ConsistencyIssue `json:"issues"`
+	Repaired  int                `json:"repaired"`
+}
+
+// CheckConsistency looks for invalid fields, duplicate IDs, subtasks of missing parents, an ID counter behind
+// the highest ID, and stale checklist percentages. With repair, orphaned subtasks become top-level tasks and
+// the counter and percentages are fixed; the rest need a person.
+func (s *memoryStore) CheckConsistency(repair bool) ConsistencyReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	report := ConsistencyReport{CheckedAt: clock.Now(), TaskCount: len(s.tasks), Issues: []ConsistencyIssue{}}
+
+	seen := make(map[int]bool, len(s.tasks))
+	// fixed holds the IDs of repaired tasks, to journal
+	fixed := map[int]bool{}
+	maxID := 0
+	for i := range s.tasks {
+		task := &s.tasks[i]
+		for _, problem := range validateTask(*task) {
+			report.Issues = append(report.Issues, ConsistencyIssue{Check: "field", TaskID: task.ID, Field: problem.Field, Message: problem.Message})
+		}
+		if seen[task.ID] {
+			report.Issues = append(report.Issues, ConsistencyIssue{Check: "duplicate_id", TaskID: task.ID, Message: fmt.Sprintf("ID %d is used by more than one task", task.ID)})
+		}
+		seen[task.ID] = true
+		if task.ID > maxID {
+			maxID = task.ID
+		}
+
+		expected := *task
+		updateChecklistCompletion(&expected)
+		if !sameCompletion(task.ChecklistCompletion, expected.ChecklistCompletion) {
+			issue := ConsistencyIssue{Check: "checklist_completion", TaskID: task.ID, Field: "checklist_completion",
+				Message: fmt.Sprintf("checklist_completion is %s, expected %s", formatCompletion(task.ChecklistCompletion), formatCompletion(expected.ChecklistCompletion))}
+			if repair {
+				task.Checklist, task.ChecklistCompletion = expected.Checklist, expected.ChecklistCompletion
+				issue.Repaired = true
+				fixed[task.ID] = true
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	for _, i := range danglingParents(s.tasks) {
+		task := &s.tasks[i]
+		issue := ConsistencyIssue{Check: "dangling_parent", TaskID: task.ID, Field: "parent_id", Message: danglingParentMessage(task.ParentID)}
+		if repair {
+			task.ParentID = 0
+			issue.Repaired = true
+			fixed[task.ID] = true
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+	if s.lastID < maxID {
+		issue := ConsistencyIssue{Check: "last_id", Message: fmt.Sprintf("next ID counter is at %d but task %d exists", s.lastID, maxID)}
+		if repair {
+			s.lastID = maxID
+			issue.Repaired = true
+		}
+		report.Issues = append(report.Issues, issue)
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Repaired {
+			report.Repaired++
+		}
+	}
+	if report.Repaired > 0 {
+		var repaired []journalEntry
+		for _, t := range s.tasks {
+			if fixed[t.ID] {
+				repaired = append(repaired, putEntry(t))
+			}
+		}
+		if err := s.record(repaired...); err != nil {
+			logError("Failed to journal consistency repairs: %v", err)
+		}
+		s.changed()
+	}
+	return report
+}
+
+// danglingParents returns the indexes of the tasks in list that are subtasks of a task not in list
+func danglingParents(list []Task) []int {
+	ids := make(map[int]bool, len(list))
+	for _, task := range list {
+		ids[task.ID] = true
+	}
+	// Checked once every ID is known, since a parent may come after its subtasks in the list
+	var dangling []int
+	for i, task := range list {
+		if task.ParentID != 0 && !ids[task.ParentID] {
+			dangling = append(dangling, i)
+		}
+	}
+	return dangling
+}
+
+func danglingParentMessage(parentID int) string {
+	return fmt.Sprintf("parent task %d does not exist", parentID)
+}
+
+func sameCompletion(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func formatCompletion(completion *int) string {
+	if completion == nil {
+		return "unset"
+	}
+	return fmt.Sprintf("%d%%", *completion)
+}
+
+// consistencyChecker is implemented by stores that can check and repair themselves
+type consistencyChecker interface {
+	CheckConsistency(repair bool) ConsistencyReport
+}
+
+// ConsistencyCheck serves /admin/check: GET reports issues, POST also repairs what it can
+func ConsistencyCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	checker, ok := taskStore.(consistencyChecker)
+	if !ok {
+		writeJsonError(w, http.StatusNotImplemented, "The task store does not support consistency checks")
+		return
+	}
+	report := checker.CheckConsistency(r.Method == "POST")
+	logConsistencyReport(report)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func logConsistencyReport(report ConsistencyReport) {
+	if len(report.Issues) == 0 {
+		logDebug("Consistency check found no issues in %d tasks", report.TaskCount)
+		return
+	}
+	for _, issue := range report.Issues {
+		logError("Consistency check: task %d: %s (repaired: %t)", issue.TaskID, issue.Message, issue.Repaired)
+	}
+	logInfo("Consistency check found %d issues in %d tasks, repaired %d", len(report.Issues), report.TaskCount, report.Repaired)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConsistencyCheck(t *testing.T) {
+	stale := 100
+	store := useTasks(t, []Task{
+		{ID: 1, Title: "Fine"},
+		{ID: 2, Title: "Stale", Checklist: []ChecklistItem{{ID: 1, Text: "Step"}}, ChecklistCompletion: &stale},
+		{ID: 2, Title: "", Priority: "urgent"},
+		{ID: 3, Title: "Orphan", ParentID: 9},
+	})
+	store.lastID = 1
+
+	check := func(method string) ConsistencyReport {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		ConsistencyCheck(rec, httptest.NewRequest(method, "/admin/check", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s got status %d", method, rec.Code)
+		}
+		var report ConsistencyReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("invalid report: %v", err)
+		}
+		return report
+	}
+
+	report := check(http.MethodGet)
+	want := []ConsistencyIssue{
+		{Check: "checklist_completion", TaskID: 2, Field: "checklist_completion", Message: "checklist_completion is 100%, expected 0%"},
+		{Check: "field", TaskID: 2, Field: "title", Message: "title cannot be empty"},
+		{Check: "field", TaskID: 2, Field: "priority", Message: "priority must be low, medium, or high"},
+		{Check: "duplicate_id", TaskID: 2, Message: "ID 2 is used by more than one task"},
+		{Check: "dangling_parent", TaskID: 3, Field: "parent_id", Message: "parent task 9 does not exist"},
+		{Check: "last_id", Message: "next ID counter is at 1 but task 3 exists"},
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatalf("got issues %+v, want %+v", report.Issues, want)
+	}
+	for i := range want {
+		if report.Issues[i] != want[i] {
+			t.Errorf("issue %d: got %+v, want %+v", i, report.Issues[i], want[i])
+		}
+	}
+	if store.lastID != 1 {
+		t.Errorf("GET changed lastID to %d", store.lastID)
+	}
+
+	// POST repairs the counter, the percentage, and the orphan, leaving the rest for a person
+	if report = check(http.MethodPost); report.Repaired != 3 {
+		t.Errorf("got %d repairs, want 3", report.Repaired)
+	}
+	if report = check(http.MethodGet); len(report.Issues) != 3 {
+		t.Errorf("got %d issues after repair, want 3: %+v", len(report.Issues), report.Issues)
+	}
+	if store.lastID != 3 || *taskStore.List()[1].ChecklistCompletion != 0 || taskStore.List()[3].ParentID != 0 {
+		t.Errorf("repairs not applied: lastID %d, tasks %+v", store.lastID, taskStore.List())
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Counter is a named tally for habits and other things that are counted rather than completed
+type Counter struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+	Step  int    `json:"step"`
+	// Reset is the reset schedule: "daily" zeroes the value at the start of each UTC day, "" never resets
+	Reset     string `json:"reset,omitempty"`
+	LastReset string `json:"last_reset,omitempty"`
+	// Owner is the name of the user the counter belongs to, empty when authentication is off
+	Owner string `json:"owner,omitempty"`
+}
+
+// counterDateFormat is the layout of Counter.LastReset
+const counterDateFormat = "2006-01-02"
+
+var counters = []Counter{}
+
+var counterMutex sync.Mutex
+
+// counterGeneration is bumped by every change to counters, and savedCounterGeneration is the generation
+// last saved, so autosave can skip saving counters that haven't changed. Both are guarded by counterMutex.
+var counterGeneration, savedCounterGeneration uint64
+
+// Counters handles the /counters collection and per-counter operations.
+//
+//	GET    /counters                    list counters
+//	POST   /counters                    create {"name": "water", "step": 1, "reset": "daily"}
+//	GET    /counters/{name}             fetch one counter
+//	DELETE /counters/{name}             remove a counter
+//	POST   /counters/{name}/increment   add step to the value
+//	POST   /counters/{name}/decrement   subtract step from the value
+//
+// With USERS_FILE set, each user has their own counters.
+func Counters(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+
+	parts := strings.Split(strings.Trim(path.Clean(normalizeText(r.URL.Path)), "/"), "/")
+	if len(parts) > 3 || parts[0] != "counters" {
+		writeJsonError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	counterMutex.Lock()
+	defer counterMutex.Unlock()
+	resetDueCounters(clock.Now())
+	owner := userFor(r)
+
+	switch {
+	case len(parts) == 1 && r.Method == "GET":
+		owned := []Counter{}
+		for _, c := range counters {
+			if c.Owner == owner {
+				owned = append(owned, c)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(owned)
+	case len(parts) == 1 && r.Method == "POST":
+		createCounter(w, r, owner)
+	case len(parts) == 2 && (r.Method == "GET" || r.Method == "DELETE"):
+		index := findCounterIndex(owner, parts[1])
+		if index == -1 {
+			logErrorContext(r.Context(), "Counter not found: %s", parts[1])
+			writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No counter found with name %s", parts[1]))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "GET" {
+			json.NewEncoder(w).Encode(counters[index])
+			return
+		}
+		counters = append(counters[:index], counters[index+1:]...)
+		counterGeneration++
+		json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Counter deleted"})
+	case len(parts) == 3 && r.Method == "POST" && (parts[2] == "increment" || parts[2] == "decrement"):
+		index := findCounterIndex(owner, parts[1])
+		if index == -1 {
+			logErrorContext(r.Context(), "Counter not found: %s", parts[1])
+			writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No counter found with name %s", parts[1]))
+			return
+		}
+		if parts[2] == "increment" {
+			counters[index].Value += counters[index].Step
+		} else {
+			counters[index].Value -= counters[index].Step
+		}
+		counterGeneration++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(counters[index])
+	default:
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+// createCounter adds a counter owned by owner from the request body. Callers must hold counterMutex.
+func createCounter(w http.ResponseWriter, r *http.Request, owner string) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to read request body")
+		writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	var newCounter Counter
+	if err := json.Unmarshal(body, &newCounter); err != nil {
+		logErrorContext(r.Context(), "Invalid JSON format in counter POST")
+		writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	newCounter.Name = normalizeText(newCounter.Name)
+	// "." and ".." would be cleaned out of the /counters/{name} path before routing
+	if newCounter.Name == "" || newCounter.Name == "." || newCounter.Name == ".." || strings.Contains(newCounter.Name, "/") {
+		logErrorContext(r.Context(), "Invalid counter name %q", newCounter.Name)
+		writeJsonError(w, http.StatusBadRequest, "Counter name must be non-empty, cannot be '.' or '..', and cannot contain '/'")
+		return
+	}
+	if newCounter.Reset != "" && newCounter.Reset != "daily" {
+		logErrorContext(r.Context(), "Invalid counter reset schedule %q", newCounter.Reset)
+		writeJsonError(w, http.StatusBadRequest, "Counter reset must be \"daily\" or omitted")
+		return
+	}
+	if findCounterIndex(owner, newCounter.Name) != -1 {
+		logErrorContext(r.Context(), "Counter already exists: %s", newCounter.Name)
+		writeJsonError(w, http.StatusConflict, fmt.Sprintf("Counter %s already exists", newCounter.Name))
+		return
+	}
+	if newCounter.Step == 0 {
+		newCounter.Step = 1
+	}
+	newCounter.LastReset = ""
+	if newCounter.Reset == "daily" {
+		newCounter.LastReset = clock.Now().Format(counterDateFormat)
+	}
+	newCounter.Owner = owner
+	counters = append(counters, newCounter)
+	counterGeneration++
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newCounter)
+}
+
+// findCounterIndex returns the index of owner's counter with the given name, or -1 if they have none.
+// Callers must hold counterMutex.
+func findCounterIndex(owner, name string) int {
+	for i, c := range counters {
+		if c.Owner == owner && c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// resetDueCounters zeroes daily counters whose last reset was before today. Callers must hold counterMutex.
+func resetDueCounters(now time.Time) {
+	today := now.Format(counterDateFormat)
+	for i := range counters {
+		if counters[i].Reset == "daily" && counters[i].LastReset != today {
+			counters[i].Value = 0
+			counters[i].LastReset = today
+			counterGeneration++
+		}
+	}
+}
+
+// LoadCountersFromFile reads counters from filename, the configured counters file. A missing file means no counters yet.
+func LoadCountersFromFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	counterMutex.Lock()
+	defer counterMutex.Unlock()
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return err
+	}
+	savedCounterGeneration = counterGeneration
+	logInfo("Counters loaded successfully from %s", filename)
+	return nil
+}
+
+// SaveCountersToFile writes all counters as indented JSON
+func SaveCountersToFile(filename string) error {
+	counterMutex.Lock()
+	data, err := marshalCanonical(counters)
+	generation := counterGeneration
+	counterMutex.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filename, 0644, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+	counterMutex.Lock()
+	// A save that started earlier may finish later
+	savedCounterGeneration = max(savedCounterGeneration, generation)
+	counterMutex.Unlock()
+	logInfo("Counters successfully saved to %s", filename)
+	return nil
+}
+
+// saveCounters saves counters to filename, retried and timed as saving tasks is
+func saveCounters(filename string, retry storageRetry) error {
+	return retry.run("save counters", func() error {
+		return timeStorage("save_counters", filename, func() error { return SaveCountersToFile(filename) })
+	})
+}
+
+// saveUnsavedCounters saves counters to filename if they changed since the last save. The autosave job
+// runs it along with saving the tasks.
+func saveUnsavedCounters(filename string) error {
+	if !countersUnsaved() {
+		return nil
+	}
+	return saveCounters(filename, storageRetry{})
+}
+
+// countersUnsaved reports whether counters changed since they were last saved or loaded
+func countersUnsaved() bool {
+	counterMutex.Lock()
+	defer counterMutex.Unlock()
+	return counterGeneration != savedCounterGeneration
+}
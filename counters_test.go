@@ -0,0 +1,200 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type counterTestCase struct {
+	name       string // Test case name
+	method     string // HTTP method
+	url        string // Endpoint
+	payload    string // The JSON payload sent in the request
+	wantStatus int    // Expected HTTP status code
+	wantBody   string // Expected response body
+}
+
+// counterTests run in order, each building on the state left by the previous one
+var counterTests = []counterTestCase{
+	{
+		name:       "Create Counter",
+		method:     http.MethodPost,
+		url:        "/counters",
+		payload:    `{"name": "pushups", "step": 10}`,
+		wantStatus: http.StatusCreated,
+		wantBody:   `{"name":"pushups","value":0,"step":10}`,
+	},
+	{
+		name:       "Duplicate Counter",
+		method:     http.MethodPost,
+		url:        "/counters",
+		payload:    `{"name": "pushups"}`,
+		wantStatus: http.StatusConflict,
+		wantBody:   `{"error":"Counter pushups already exists","code":"conflict"}`,
+	},
+	{
+		name:       "Dot Dot Name",
+		method:     http.MethodPost,
+		url:        "/counters",
+		payload:    `{"name": ".."}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Counter name must be non-empty, cannot be '.' or '..', and cannot contain '/'","code":"invalid_request"}`,
+	},
+	{
+		name:       "Invalid Reset Schedule",
+		method:     http.MethodPost,
+		url:        "/counters",
+		payload:    `{"name": "water", "reset": "hourly"}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Counter reset must be \"daily\" or omitted","code":"invalid_request"}`,
+	},
+	{
+		name:       "Increment",
+		method:     http.MethodPost,
+		url:        "/counters/pushups/increment",
+		wantStatus: http.StatusOK,
+		wantBody:   `{"name":"pushups","value":10,"step":10}`,
+	},
+	{
+		name:       "Decrement",
+		method:     http.MethodPost,
+		url:        "/counters/pushups/decrement",
+		wantStatus: http.StatusOK,
+		wantBody:   `{"name":"pushups","value":0,"step":10}`,
+	},
+	{
+		name:       "Increment Missing Counter",
+		method:     http.MethodPost,
+		url:        "/counters/situps/increment",
+		wantStatus: http.StatusNotFound,
+		wantBody:   `{"error":"No counter found with name situps","code":"not_found"}`,
+	},
+	{
+		name:       "Delete Counter",
+		method:     http.MethodDelete,
+		url:        "/counters/pushups",
+		wantStatus: http.StatusOK,
+		wantBody:   `{"message":"Counter deleted","status":"success"}`,
+	},
+	{
+		name:       "List Counters",
+		method:     http.MethodGet,
+		url:        "/counters",
+		wantStatus: http.StatusOK,
+		wantBody:   `[]`,
+	},
+}
+
+func TestCounters(t *testing.T) {
+	counters = []Counter{}
+
+	for _, tt := range counterTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+
+			Counters(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Test %s: got status %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			}
+			gotBody := strings.TrimSpace(rec.Body.String())
+			if gotBody != tt.wantBody {
+				t.Errorf("Test %s: got body %s, want %s", tt.name, gotBody, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDailyCounterReset(t *testing.T) {
+	counters = []Counter{
+		{Name: "water", Value: 5, Step: 1, Reset: "daily", LastReset: "2024-01-01"},
+		{Name: "books", Value: 3, Step: 1},
+	}
+
+	resetDueCounters(time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC))
+
+	if counters[0].Value != 0 || counters[0].LastReset != "2024-01-02" {
+		t.Errorf("Expected daily counter to reset, got %+v", counters[0])
+	}
+	if counters[1].Value != 3 {
+		t.Errorf("Expected non-resetting counter to keep its value, got %+v", counters[1])
+	}
+}
+
+func TestLoadAndSaveCounters(t *testing.T) {
+	tempFile := "test_counters.json"
+	defer os.Remove(tempFile)
+
+	counters = []Counter{{Name: "pushups", Value: 20, Step: 10}}
+	if err := SaveCountersToFile(tempFile); err != nil {
+		t.Fatalf("Failed to save counters: %v", err)
+	}
+
+	counters = nil
+	if err := LoadCountersFromFile(tempFile); err != nil {
+		t.Fatalf("Failed to load counters: %v", err)
+	}
+	if len(counters) != 1 || counters[0].Value != 20 {
+		t.Errorf("Loaded counters do not match expected values: %+v", counters)
+	}
+}
+
+func TestCountersPerUser(t *testing.T) {
+	counters = []Counter{}
+	do := func(user, method, url, payload string) (int, string) {
+		rec := httptest.NewRecorder()
+		Counters(rec, withUser(httptest.NewRequest(method, url, strings.NewReader(payload)), user))
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	// Names are per user, and a user only sees and changes their own counters
+	if status, _ := do("alice", http.MethodPost, "/counters", `{"name":"water","owner":"bob"}`); status != http.StatusCreated {
+		t.Fatalf("alice creating water = %d, want 201", status)
+	}
+	if status, _ := do("bob", http.MethodPost, "/counters", `{"name":"water"}`); status != http.StatusCreated {
+		t.Fatalf("bob creating water = %d, want 201", status)
+	}
+	do("alice", http.MethodPost, "/counters/water/increment", "")
+	if _, body := do("bob", http.MethodGet, "/counters", ""); body != `[{"name":"water","value":0,"step":1,"owner":"bob"}]` {
+		t.Errorf("bob's counters = %s, want only his own, untouched", body)
+	}
+	if status, _ := do("bob", http.MethodDelete, "/counters/water", ""); status != http.StatusOK {
+		t.Errorf("bob deleting his water = %d, want 200", status)
+	}
+	if status, body := do("alice", http.MethodGet, "/counters/water", ""); status != http.StatusOK || body != `{"name":"water","value":1,"step":1,"owner":"alice"}` {
+		t.Errorf("alice's water = %d %s, want it kept at 1", status, body)
+	}
+}
+
+func TestSaveUnsavedCounters(t *testing.T) {
+	resetStorageBreaker(t)
+	tempFile := filepath.Join(t.TempDir(), "counters.json")
+	counters = []Counter{}
+	if err := SaveCountersToFile(tempFile); err != nil || countersUnsaved() {
+		t.Fatalf("after save got %v, unsaved %v; want nothing unsaved", err, countersUnsaved())
+	}
+
+	// Unchanged counters aren't written again
+	os.Remove(tempFile)
+	if err := saveUnsavedCounters(tempFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(tempFile); !os.IsNotExist(err) {
+		t.Errorf("unchanged counters were saved: %v", err)
+	}
+
+	Counters(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/counters", strings.NewReader(`{"name":"pushups"}`)))
+	if err := saveUnsavedCounters(tempFile); err != nil || countersUnsaved() {
+		t.Fatalf("autosave = %v, unsaved %v; want the new counter saved", err, countersUnsaved())
+	}
+	counters = nil
+	if err := LoadCountersFromFile(tempFile); err != nil || len(counters) != 1 {
+		t.Errorf("reloaded %+v, %v; want the saved counter", counters, err)
+	}
+}
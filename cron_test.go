@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	tests := []struct {
+		name      string
+		spec      string
+		wantNext  string
+		wantError string
+	}{
+		{"Every", "@every 90s", "2024-05-01T12:02:00Z", ""},
+		{"Every Minute", "* * * * *", "2024-05-01T12:01:00Z", ""},
+		{"Daily Macro", "@daily", "2024-05-02T00:00:00Z", ""},
+		{"Hourly Macro", "@hourly", "2024-05-01T13:00:00Z", ""},
+		{"Fixed Time", "30 3 * * *", "2024-05-02T03:30:00Z", ""},
+		{"Later Today", "15 14 * * *", "2024-05-01T14:15:00Z", ""},
+		{"Step", "*/20 * * * *", "2024-05-01T12:20:00Z", ""},
+		{"Range With Step", "0 9-17/4 * * *", "2024-05-01T13:00:00Z", ""},
+		{"List", "0 6,18 * * *", "2024-05-01T18:00:00Z", ""},
+		{"Weekdays", "0 8 * * 1-5", "2024-05-02T08:00:00Z", ""},
+		{"Sunday As 7", "0 0 * * 7", "2024-05-05T00:00:00Z", ""},
+		{"Day Of Month Or Week", "0 0 15 * 6", "2024-05-04T00:00:00Z", ""},
+		{"Next Month", "0 0 1 * *", "2024-06-01T00:00:00Z", ""},
+		{"Leap Day", "0 0 29 2 *", "2028-02-29T00:00:00Z", ""},
+		{"Too Few Fields", "0 0 * *", "", "must be a cron expression"},
+		{"Out Of Range", "60 * * * *", "", `invalid minute in schedule "60 * * * *": "60" is outside 0-59`},
+		{"Bad Step", "*/0 * * * *", "", `invalid step "0"`},
+		{"Never", "0 0 31 2 *", "", "never runs"},
+		{"Bad Interval", "@every soon", "", "invalid interval"},
+		{"Zero Interval", "@every 0s", "", "invalid interval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched, err := parseSchedule(tt.spec)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := sched.Next(from).Format(time.RFC3339); got != tt.wantNext {
+				t.Errorf("next run = %s, want %s", got, tt.wantNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// taskDateFormat is the layout of calendar-date task fields such as StartDate. Dates are compared
+// as UTC days, the same way daily counters reset.
+const taskDateFormat = "2006-01-02"
+
+// dateError names the date field that failed validation
+type dateError struct {
+	field   string
+	message string
+}
+
+func (e *dateError) Error() string {
+	return e.message
+}
+
+// validateTaskDates checks that start_date is a real calendar date in taskDateFormat and that due_date
+// is an RFC 3339 timestamp, rewriting due_date in UTC so stored due dates compare as strings
+func validateTaskDates(task *Task) error {
+	if task.StartDate != "" && !isTaskDate(task.StartDate) {
+		return &dateError{field: "start_date", message: "start_date must be a date in YYYY-MM-DD format"}
+	}
+	if task.DueDate != "" {
+		due, err := time.Parse(time.RFC3339, task.DueDate)
+		if err != nil {
+			return &dateError{field: "due_date", message: "due_date must be an RFC 3339 timestamp, e.g. 2024-05-03T17:00:00Z"}
+		}
+		task.DueDate = due.UTC().Format(time.RFC3339)
+	}
+	return nil
+}
+
+func isTaskDate(value string) bool {
+	parsed, err := time.Parse(taskDateFormat, value)
+	return err == nil && parsed.Format(taskDateFormat) == value
+}
+
+// isAvailable reports whether the task's start date has arrived as of now
+func (t Task) isAvailable(now time.Time) bool {
+	// Both sides are zero-padded YYYY-MM-DD, so string order is date order
+	return t.StartDate == "" || t.StartDate <= now.UTC().Format(taskDateFormat)
+}
+
+// isOverdue reports whether the task is still open after its due date
+func (t Task) isOverdue(now time.Time) bool {
+	if t.Completed || t.DueDate == "" {
+		return false
+	}
+	due, err := time.Parse(time.RFC3339, t.DueDate)
+	return err == nil && now.After(due)
+}
+
+// isDueBetween reports whether the task is due at or after from and before before; a nil bound is open.
+// Tasks without a due date are never in range.
+func (t Task) isDueBetween(from, before *time.Time) bool {
+	due, err := time.Parse(time.RFC3339, t.DueDate)
+	if err != nil {
+		return false
+	}
+	return (from == nil || !due.Before(*from)) && (before == nil || due.Before(*before))
+}
+
+// hasJSONField reports whether the JSON object in body sets name, even to null, so a PUT
+// can leave fields the client didn't mention untouched
+func hasJSONField(body []byte, name string) bool {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&fields); err != nil {
+		return false
+	}
+	_, ok := fields[name]
+	return ok
+}
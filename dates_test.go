@@ -0,0 +1,201 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type startDateTestCase struct {
+	name       string // Test case name
+	method     string // HTTP method
+	url        string // Request URL
+	payload    string // The JSON payload sent in the request
+	wantStatus int    // Expected HTTP status code
+	wantBody   string // Expected response body
+}
+
+// startDateTests run in order against the same store, on 2024-05-01
+var startDateTests = []startDateTestCase{
+	{
+		name:       "Create Deferred Task",
+		method:     http.MethodPost,
+		url:        "/tasks",
+		payload:    `{"title": "File taxes", "start_date": "2024-05-03"}`,
+		wantStatus: http.StatusCreated,
+		wantBody:   `{"id":2,"title":"File taxes","completed":false,"start_date":"2024-05-03","created_at":"2024-05-01T23:00:00Z","updated_at":"2024-05-01T23:00:00Z","version":1}`,
+	},
+	{
+		name:       "Invalid Date",
+		method:     http.MethodPost,
+		url:        "/tasks",
+		payload:    `{"title": "Bad", "start_date": "2024-02-30"}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"start_date must be a date in YYYY-MM-DD format","code":"validation_failed"}`,
+	},
+	{
+		name:       "Unpadded Date",
+		method:     http.MethodPost,
+		url:        "/tasks",
+		payload:    `{"title": "Bad", "start_date": "2024-5-3"}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"start_date must be a date in YYYY-MM-DD format","code":"validation_failed"}`,
+	},
+	{
+		name:       "Available Only",
+		method:     http.MethodGet,
+		url:        "/tasks?available=true",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":1,"title":"Started","completed":false,"start_date":"2024-05-01"}]`,
+	},
+	{
+		name:       "Deferred Only",
+		method:     http.MethodGet,
+		url:        "/tasks?available=false&fields=id",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":2}]`,
+	},
+	{
+		name:       "PUT Without Start Date Keeps It",
+		method:     http.MethodPut,
+		url:        "/tasks/2",
+		payload:    `{"title": "File taxes early"}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"id":2,"title":"File taxes early","completed":false,"start_date":"2024-05-03","created_at":"2024-05-01T23:00:00Z","updated_at":"2024-05-01T23:00:00Z","version":2}`,
+	},
+	{
+		name:       "PUT Null Clears It",
+		method:     http.MethodPut,
+		url:        "/tasks/2",
+		payload:    `{"title": "File taxes early", "start_date": null}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"id":2,"title":"File taxes early","completed":false,"created_at":"2024-05-01T23:00:00Z","updated_at":"2024-05-01T23:00:00Z","version":3}`,
+	},
+}
+
+func TestStartDate(t *testing.T) {
+	useFakeClock(t, time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC))
+	useTasks(t, []Task{{ID: 1, Title: "Started", StartDate: "2024-05-01"}})
+
+	for _, tt := range startDateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestDeferredTaskBecomesAvailable(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, 5, 2, 23, 59, 0, 0, time.UTC))
+	deferred := Task{ID: 1, Title: "Later", StartDate: "2024-05-03"}
+	if deferred.isAvailable(fake.Now()) {
+		t.Fatal("task available before its start date")
+	}
+	fake.Advance(time.Minute)
+	if !deferred.isAvailable(fake.Now()) {
+		t.Error("task not available on its start date")
+	}
+}
+
+func TestDueDate(t *testing.T) {
+	useFakeClock(t, time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC))
+	useTasks(t, []Task{
+		{ID: 1, Title: "No due date"},
+		{ID: 2, Title: "Done late", Completed: true, DueDate: "2024-04-30T12:00:00Z"},
+		{ID: 3, Title: "Due tomorrow", DueDate: "2024-05-02T09:00:00Z"},
+	})
+	const stamps = `"created_at":"2024-05-01T23:00:00Z","updated_at":"2024-05-01T23:00:00Z"`
+
+	tests := []startDateTestCase{
+		{
+			name:       "Due Date Stored In UTC",
+			method:     http.MethodPost,
+			url:        "/tasks",
+			payload:    `{"title": "Call the bank", "due_date": "2024-05-01T20:00:00+02:00"}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":4,"title":"Call the bank","completed":false,"due_date":"2024-05-01T18:00:00Z",` + stamps + `,"version":1}`,
+		},
+		{
+			name:       "Plain Date Rejected",
+			method:     http.MethodPost,
+			url:        "/tasks",
+			payload:    `{"title": "Bad", "due_date": "2024-05-01"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"due_date must be an RFC 3339 timestamp, e.g. 2024-05-03T17:00:00Z","code":"validation_failed"}`,
+		},
+		{
+			name:       "Overdue Only",
+			method:     http.MethodGet,
+			url:        "/tasks?overdue=true&fields=id",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":4}]`,
+		},
+		{
+			name:       "Not Overdue",
+			method:     http.MethodGet,
+			url:        "/tasks?overdue=false&fields=id",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":1},{"id":2},{"id":3}]`,
+		},
+		{
+			name:       "Due This Month",
+			method:     http.MethodGet,
+			url:        "/tasks?due_from=2024-05-01&due_before=2024-06-01&fields=id",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":3},{"id":4}]`,
+		},
+		{
+			name:       "Due Before A Timestamp",
+			method:     http.MethodGet,
+			url:        "/tasks?due_before=2024-05-01T18:00:00Z&fields=id",
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":2}]`,
+		},
+		{
+			name:       "Bad Due Bound",
+			method:     http.MethodGet,
+			url:        "/tasks?due_from=May",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"due_from must be a YYYY-MM-DD date or an RFC 3339 timestamp","code":"invalid_request"}`,
+		},
+		{
+			name:       "PUT Without Due Date Keeps It",
+			method:     http.MethodPut,
+			url:        "/tasks/4",
+			payload:    `{"title": "Call the bank", "completed": true}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":4,"title":"Call the bank","completed":true,"due_date":"2024-05-01T18:00:00Z",` + stamps + `,"version":2}`,
+		},
+		{
+			name:       "Completing Clears Overdue",
+			method:     http.MethodGet,
+			url:        "/tasks?overdue=true",
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
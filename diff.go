@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// fieldChange is a field's value before and after an update; a side is null when the field was unset
+type fieldChange struct {
+	Old json.RawMessage `json:"old"`
+	New json.RawMessage `json:"new"`
+}
+
+// taskWithDiff is the response of PUT /tasks/{id}?include=diff: the updated task plus what changed
+type taskWithDiff struct {
+	Task
+	Diff map[string]fieldChange `json:"diff"`
+}
+
+// diffTasks returns the fields whose JSON values differ between before and after, keyed by field
+// name. updated_at and version are left out, since every update changes them.
+func diffTasks(before, after Task) (map[string]fieldChange, error) {
+	oldValues, err := taskFieldValues(before)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := taskFieldValues(after)
+	if err != nil {
+		return nil, err
+	}
+	diff := map[string]fieldChange{}
+	for _, name := range taskFieldNames {
+		if name == "updated_at" || name == "version" {
+			continue
+		}
+		if !bytes.Equal(oldValues[name], newValues[name]) {
+			diff[name] = fieldChange{Old: oldValues[name], New: newValues[name]}
+		}
+	}
+	return diff, nil
+}
+
+// taskFieldValues is the task's JSON encoding split into fields
+func taskFieldValues(t Task) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	err = json.Unmarshal(data, &values)
+	return values, err
+}
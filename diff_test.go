@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpdateTaskDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		payload    string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "Changed Fields",
+			query:      "?include=diff",
+			payload:    `{"title": "Clean the rug", "completed": true, "priority": null, "tags": ["home", "chores"]}`,
+			wantStatus: http.StatusOK,
+			wantBody: `{"id":1,"title":"Clean the rug","completed":true,"tags":["home","chores"],"updated_at":"2024-05-01T12:00:00Z","version":1,` +
+				`"diff":{"completed":{"old":false,"new":true},"priority":{"old":"high","new":null},` +
+				`"tags":{"old":["home"],"new":["home","chores"]},"title":{"old":"Clean the carpet","new":"Clean the rug"}}}`,
+		},
+		{
+			name:       "Nothing Changed",
+			query:      "?include=diff",
+			payload:    `{"title": "Clean the carpet"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":1,"title":"Clean the carpet","completed":false,"priority":"high","tags":["home"],"updated_at":"2024-05-01T12:00:00Z","version":1,"diff":{}}`,
+		},
+		{
+			name:       "Without Diff",
+			payload:    `{"title": "Clean the carpet"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `{"id":1,"title":"Clean the carpet","completed":false,"priority":"high","tags":["home"],"updated_at":"2024-05-01T12:00:00Z","version":1}`,
+		},
+		{
+			name:       "Unknown Include",
+			query:      "?include=history",
+			payload:    `{"title": "Clean the carpet"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Unknown include \"history\", must be diff","code":"invalid_request"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeClock(t, testNow)
+			useTasks(t, []Task{{ID: 1, Title: "Clean the carpet", Priority: "high", Tags: []string{"home"}}})
+			rec := httptest.NewRecorder()
+			Tasks(rec, httptest.NewRequest(http.MethodPut, "/tasks/1"+tt.query, strings.NewReader(tt.payload)))
+			if rec.Code != tt.wantStatus || strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("got %d %s, want %d %s", rec.Code, rec.Body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
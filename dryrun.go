@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PendingChange is a mutating request captured in dry-run mode instead of being applied
+type PendingChange struct {
+	ID     int    `json:"id"`
+	Method string `json:"method"`
+	// Path is the request's path with its query string, such as /tasks/1?subtasks=cascade
+	Path string          `json:"path"`
+	Body json.RawMessage `json:"body,omitempty"`
+	// IfMatch is the request's If-Match, so an update is applied only to the task version it was based on
+	IfMatch string `json:"if_match,omitempty"`
+	// Owner is the user who made the request; the change is applied as them
+	Owner      string    `json:"owner,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// pendingMutex serializes access to the pending-changes file
+var pendingMutex sync.Mutex
+
+// DryRun captures POST/PUT/DELETE requests to pendingFile after validating them, responding 202 Accepted
+// without touching the live store. Admin routes pass through so pending changes can be reviewed and applied.
+func DryRun(next http.Handler, pendingFile string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Token management isn't task data, so it is applied at once rather than staged
+		if strings.HasPrefix(r.URL.Path, "/admin/") || strings.HasPrefix(r.URL.Path, "/me/") || (r.Method != "POST" && r.Method != "PUT" && r.Method != "DELETE") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logErrorContext(r.Context(), "Failed to read request body in dry-run mode")
+			writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		if status, code, message := validateDryRunRequest(r, body); status != 0 {
+			logErrorContext(r.Context(), "Dry-run rejected %s %s: %s", r.Method, r.URL.Path, message)
+			writeJsonErrorCode(w, status, code, message)
+			return
+		}
+
+		change := PendingChange{Method: r.Method, Path: r.URL.RequestURI(), IfMatch: r.Header.Get("If-Match"), Owner: userFor(r), ReceivedAt: clock.Now()}
+		if len(body) > 0 {
+			change.Body = json.RawMessage(body)
+		}
+		change, err = appendPendingChange(pendingFile, change)
+		if err != nil {
+			logErrorContext(r.Context(), "Failed to record pending change: %v", err)
+			writeJsonError(w, http.StatusInternalServerError, "Failed to record pending change")
+			return
+		}
+		logInfoContext(r.Context(), "Dry-run captured %s %s as pending change %d", r.Method, r.URL.Path, change.ID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "pending", "change": change})
+	})
+}
+
+// validateDryRunRequest applies the checks the live handlers would, returning a status, code, and
+// message on failure
+func validateDryRunRequest(r *http.Request, body []byte) (int, string, string) {
+	if r.URL.Path == "/imports" || r.URL.Path == "/import" {
+		return http.StatusBadRequest, codeInvalidRequest, "Imports are not supported in dry-run mode"
+	}
+	var route string
+	if strings.HasPrefix(r.URL.Path, "/tasks") {
+		route = taskRoute(r)
+	}
+	// The snapshot a bulk delete is based on would be stale by the time it is applied
+	if route == "DELETE /tasks" {
+		return http.StatusBadRequest, codeInvalidRequest, "Bulk delete is not supported in dry-run mode"
+	}
+	if len(body) > 0 && !json.Valid(body) {
+		return http.StatusBadRequest, codeInvalidRequest, "Invalid JSON format"
+	}
+	if route != "POST /tasks" && route != "PUT /tasks/{id}" && route != "DELETE /tasks/{id}" {
+		return 0, "", ""
+	}
+	if r.Method == "POST" || r.Method == "PUT" {
+		var task Task
+		if err := json.Unmarshal(body, &task); err != nil {
+			return http.StatusBadRequest, codeInvalidRequest, "Invalid JSON format"
+		}
+		if task.Title == "" {
+			return http.StatusBadRequest, codeValidationFailed, "Task title cannot be empty"
+		}
+		if err := validateTaskDates(&task); err != nil {
+			return http.StatusBadRequest, codeValidationFailed, err.Error()
+		}
+		if err := validatePriority(task.Priority); err != nil {
+			return http.StatusBadRequest, codeValidationFailed, err.Error()
+		}
+		if _, err := normalizeTags(task.Tags); err != nil {
+			return http.StatusBadRequest, codeValidationFailed, err.Error()
+		}
+		if _, err := normalizeLinks(task.Links); err != nil {
+			return http.StatusBadRequest, codeValidationFailed, err.Error()
+		}
+		if _, err := normalizeNotes(task.Notes); err != nil {
+			return http.StatusBadRequest, codeValidationFailed, err.Error()
+		}
+		// PUT ignores checklist fields, so only a new task's items are checked
+		if r.Method == "POST" {
+			if _, err := normalizeChecklist(task.Checklist); err != nil {
+				return http.StatusBadRequest, codeValidationFailed, err.Error()
+			}
+		}
+	}
+	if r.Method == "PUT" {
+		// Whether the version is still current is only known when the change is applied
+		_, stated, err := expectedVersion(r, body)
+		if err != nil {
+			return http.StatusBadRequest, codeInvalidRequest, err.Error()
+		}
+		if !stated && requireTaskVersion {
+			return http.StatusPreconditionRequired, codePreconditionRequired, "Updates must send If-Match or version with the task version they replace"
+		}
+	}
+	if r.Method == "PUT" || r.Method == "DELETE" {
+		// The request hasn't been routed yet, so the ID is read from the path
+		ID, err := parseTaskIDString(path.Base(r.URL.Path))
+		if err != nil {
+			return http.StatusBadRequest, codeInvalidRequest, err.Error()
+		}
+		if _, err := storeFor(r).Get(ID); err != nil {
+			return http.StatusNotFound, codeTaskNotFound, fmt.Sprintf("No task found with ID %d", ID)
+		}
+	}
+	return 0, "", ""
+}
+
+// appendPendingChange assigns the next sequence number to change and appends it as a JSON line
+func appendPendingChange(pendingFile string, change PendingChange) (PendingChange, error) {
+	pendingMutex.Lock()
+	defer pendingMutex.Unlock()
+
+	existing, err := readPendingChanges(pendingFile)
+	if err != nil {
+		return change, err
+	}
+	change.ID = 1
+	if len(existing) > 0 {
+		change.ID = existing[len(existing)-1].ID + 1
+	}
+
+	file, err := os.OpenFile(pendingFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return change, err
+	}
+	defer file.Close()
+	line, err := json.Marshal(change)
+	if err != nil {
+		return change, err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return change, err
+}
+
+// readPendingChanges loads all captured changes; a missing file means none are pending. Callers must hold pendingMutex.
+func readPendingChanges(pendingFile string) ([]PendingChange, error) {
+	file, err := os.Open(pendingFile)
+	if os.IsNotExist(err) {
+		return []PendingChange{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	changes := []PendingChange{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var change PendingChange
+		if err := json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, scanner.Err()
+}
+
+// PendingChanges serves the dry-run review endpoints:
+//
+//	GET    /admin/pending         list captured changes
+//	POST   /admin/pending/apply   replay every change against live, then clear the file
+//	DELETE /admin/pending         discard all captured changes
+func PendingChanges(live http.Handler, pendingFile string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pendingMutex.Lock()
+		defer pendingMutex.Unlock()
+
+		changes, err := readPendingChanges(pendingFile)
+		if err != nil {
+			logErrorContext(r.Context(), "Failed to read pending changes: %v", err)
+			writeJsonError(w, http.StatusInternalServerError, "Failed to read pending changes")
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/admin/pending" && r.Method == "GET":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(changes)
+		case r.URL.Path == "/admin/pending" && r.Method == "DELETE":
+			if err := os.Remove(pendingFile); err != nil && !os.IsNotExist(err) {
+				logErrorContext(r.Context(), "Failed to discard pending changes: %v", err)
+				writeJsonError(w, http.StatusInternalServerError, "Failed to discard pending changes")
+				return
+			}
+			logInfoContext(r.Context(), "Discarded %d pending changes", len(changes))
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "discarded": len(changes)})
+		case r.URL.Path == "/admin/pending/apply" && r.Method == "POST":
+			results := make([]map[string]interface{}, 0, len(changes))
+			for _, change := range changes {
+				status := replayChange(live, change)
+				results = append(results, map[string]interface{}{"id": change.ID, "method": change.Method, "path": change.Path, "status": status})
+			}
+			if err := os.Remove(pendingFile); err != nil && !os.IsNotExist(err) {
+				logErrorContext(r.Context(), "Failed to clear applied pending changes: %v", err)
+			}
+			logInfoContext(r.Context(), "Applied %d pending changes", len(changes))
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "applied": results})
+		default:
+			logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+			writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		}
+	})
+}
+
+// replayChange sends a captured change through the live handler and returns the resulting status code
+func replayChange(live http.Handler, change PendingChange) int {
+	req, err := http.NewRequest(change.Method, change.Path, bytes.NewReader(change.Body))
+	if err != nil {
+		logError("Failed to rebuild pending change %d: %v", change.ID, err)
+		return http.StatusBadRequest
+	}
+	if len(change.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if change.IfMatch != "" {
+		req.Header.Set("If-Match", change.IfMatch)
+	}
+	if change.Owner != "" {
+		req = withUser(req, change.Owner)
+	}
+	rec := newBufferedResponse()
+	live.ServeHTTP(rec, req)
+	return rec.status
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunCapturesAndApplies(t *testing.T) {
+	pendingFile := "test_pending_changes.jsonl"
+	defer os.Remove(pendingFile)
+
+	useTasks(t, []Task{{ID: 1, Title: "Clean the carpet", Completed: false}})
+
+	live := http.NewServeMux()
+	live.HandleFunc("/tasks", Tasks)
+	live.HandleFunc("/tasks/", Tasks)
+	dryRun := DryRun(live, pendingFile)
+	admin := PendingChanges(live, pendingFile)
+
+	// A valid mutation is captured, not applied
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title":"Staged Task"}`))
+	rec := httptest.NewRecorder()
+	dryRun.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d; body %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	if got := taskStore.List(); len(got) != 1 {
+		t.Fatalf("expected live store to be untouched, got %d tasks", len(got))
+	}
+
+	// An invalid mutation is rejected up front
+	req = httptest.NewRequest(http.MethodDelete, "/tasks/999", nil)
+	rec = httptest.NewRecorder()
+	dryRun.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got status %d for missing task, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	// Reads still go to the live store
+	req = httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	rec = httptest.NewRecorder()
+	dryRun.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("got status %d for GET, want %d", rec.Code, http.StatusOK)
+	}
+
+	// Review the pending changes
+	req = httptest.NewRequest(http.MethodGet, "/admin/pending", nil)
+	rec = httptest.NewRecorder()
+	admin.ServeHTTP(rec, req)
+	var pending []PendingChange
+	if err := json.Unmarshal(rec.Body.Bytes(), &pending); err != nil {
+		t.Fatalf("pending list is not valid JSON: %v", err)
+	}
+	if len(pending) != 1 || pending[0].Method != http.MethodPost || pending[0].Path != "/tasks" {
+		t.Fatalf("unexpected pending changes: %+v", pending)
+	}
+
+	// Apply replays against the live handler and clears the file
+	req = httptest.NewRequest(http.MethodPost, "/admin/pending/apply", nil)
+	rec = httptest.NewRecorder()
+	admin.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d applying changes, want %d", rec.Code, http.StatusOK)
+	}
+	if got := taskStore.List(); len(got) != 2 || got[1].Title != "Staged Task" {
+		t.Errorf("expected staged task to be applied, got %+v", got)
+	}
+	if _, err := os.Stat(pendingFile); !os.IsNotExist(err) {
+		t.Errorf("expected pending file to be removed after apply, got %v", err)
+	}
+}
+
+func TestDryRunKeepsIfMatch(t *testing.T) {
+	pendingFile := filepath.Join(t.TempDir(), "pending.jsonl")
+	store := useTasks(t, []Task{{ID: 1, Title: "Draft", Version: 2}})
+	live := http.NewServeMux()
+	live.HandleFunc("/tasks/", Tasks)
+	dryRun := DryRun(live, pendingFile)
+	admin := PendingChanges(live, pendingFile)
+	stage := func(ifMatch string) int {
+		req := httptest.NewRequest(http.MethodPut, "/tasks/1", strings.NewReader(`{"title":"Staged"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		dryRun.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	requireTaskVersion = true
+	t.Cleanup(func() { requireTaskVersion = false })
+	if status := stage(""); status != http.StatusPreconditionRequired {
+		t.Errorf("staging without a version got %d, want 428", status)
+	}
+	if status := stage(`"2"`); status != http.StatusAccepted {
+		t.Fatalf("staging with If-Match got %d, want 202", status)
+	}
+
+	// Someone else changes the task before the staged update is applied
+	store.Update(1, func(task *Task) error { task.Title = "Edited live"; return nil })
+	rec := httptest.NewRecorder()
+	admin.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/pending/apply", nil))
+	if !strings.Contains(rec.Body.String(), `"status":409`) {
+		t.Errorf("apply got %s, want the stale update refused with 409", rec.Body)
+	}
+	if got, _ := store.Get(1); got.Title != "Edited live" {
+		t.Errorf("title = %q, want the live edit kept", got.Title)
+	}
+}
+
+func TestDryRunKeepsQuery(t *testing.T) {
+	pendingFile := filepath.Join(t.TempDir(), "pending.jsonl")
+	store := useTasks(t, []Task{{ID: 1, Title: "Parent"}, {ID: 2, Title: "Child", ParentID: 1}})
+	live := http.NewServeMux()
+	live.HandleFunc("/tasks/", Tasks)
+	dryRun := DryRun(live, pendingFile)
+
+	req := httptest.NewRequest(http.MethodPut, "/tasks/1?subtasks=cascade", strings.NewReader(`{"title":"Parent","completed":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	dryRun.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("staging got %d, want 202: %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	PendingChanges(live, pendingFile).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/pending/apply", nil))
+	if !strings.Contains(rec.Body.String(), `"path":"/tasks/1?subtasks=cascade"`) {
+		t.Errorf("apply got %s, want the query kept", rec.Body)
+	}
+	// The cascade completed the subtask along with its parent
+	if got, _ := store.Get(2); !got.Completed {
+		t.Errorf("subtask %+v not completed by the cascade", got)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes are the stable "code" member of every JSON error response. Messages are for people and
+// may be reworded; clients branch on codes. Most responses carry their status's code from
+// statusErrorCodes, and a few name the problem more precisely.
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeValidationFailed     = "validation_failed"
+	codeUnauthorized         = "unauthorized"
+	codeForbidden            = "forbidden"
+	codeNotFound             = "not_found"
+	codeTaskNotFound         = "task_not_found"
+	codeMethodNotAllowed     = "method_not_allowed"
+	codeConflict             = "conflict"
+	codeVersionConflict      = "version_conflict"
+	codeUnsupportedMedia     = "unsupported_media_type"
+	codePreconditionRequired = "precondition_required"
+	codeInternal             = "internal_error"
+	codeNotImplemented       = "not_implemented"
+	codeUnavailable          = "unavailable"
+	codeTimeout              = "timeout"
+	codeInsufficientStorage  = "insufficient_storage"
+)
+
+// statusErrorCodes is the code an error response with each status carries unless its handler gives
+// a more specific one
+var statusErrorCodes = map[int]string{
+	http.StatusBadRequest:           codeInvalidRequest,
+	http.StatusUnauthorized:         codeUnauthorized,
+	http.StatusForbidden:            codeForbidden,
+	http.StatusNotFound:             codeNotFound,
+	http.StatusMethodNotAllowed:     codeMethodNotAllowed,
+	http.StatusConflict:             codeConflict,
+	http.StatusUnsupportedMediaType: codeUnsupportedMedia,
+	http.StatusPreconditionRequired: codePreconditionRequired,
+	http.StatusInternalServerError:  codeInternal,
+	http.StatusNotImplemented:       codeNotImplemented,
+	http.StatusServiceUnavailable:   codeUnavailable,
+	http.StatusGatewayTimeout:       codeTimeout,
+	http.StatusInsufficientStorage:  codeInsufficientStorage,
+}
+
+// errorCodeFor returns the default code for an error status
+func errorCodeFor(status int) string {
+	if code, ok := statusErrorCodes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return codeInternal
+	}
+	return codeInvalidRequest
+}
+
+// errorResponse is the body of every JSON error response; handlers that report details embed it
+type errorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+func writeJsonError(w http.ResponseWriter, status int, message string) {
+	writeJsonErrorCode(w, status, errorCodeFor(status), message)
+}
+
+// writeJsonErrorCode writes an error response whose code is more specific than its status's
+func writeJsonErrorCode(w http.ResponseWriter, status int, code, message string) {
+	writeJsonErrorBody(w, status, errorResponse{Error: message, Code: code})
+}
+
+// writeJsonErrorBody writes body, an errorResponse or a struct embedding one, as an error response
+func writeJsonErrorBody(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestErrorCodes(t *testing.T) {
+	useTasks(t, []Task{{ID: 1, Title: "Existing"}})
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		payload  string
+		wantCode string
+	}{
+		{"Task Not Found", "DELETE", "/tasks/9", "", codeTaskNotFound},
+		{"Invalid ID", "DELETE", "/tasks/x", "", codeInvalidRequest},
+		{"Invalid JSON", "POST", "/tasks", "{", codeInvalidRequest},
+		{"Empty Title", "POST", "/tasks", `{"title": ""}`, codeValidationFailed},
+		{"Bad Priority", "PUT", "/tasks/1", `{"title": "Existing", "priority": "urgent"}`, codeValidationFailed},
+		{"Bad Filter", "GET", "/tasks?completed=maybe", "", codeInvalidRequest},
+		{"Method", "PATCH", "/tasks/1", "", codeMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Tasks(rec, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload)))
+			var body errorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == "" {
+				t.Fatalf("status %d body %s is not an error response", rec.Code, rec.Body)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q (status %d)", body.Code, tt.wantCode, rec.Code)
+			}
+		})
+	}
+}
+
+// errorCodeConst matches a code declaration in errorcodes.go, e.g. codeNotFound = "not_found"
+var errorCodeConst = regexp.MustCompile(`(?m)^\s+code\w+\s+= "([a-z_]+)"$`)
+
+// TestErrorCodesDocumented keeps the spec's ErrorCode enum in step with the codes the server sends
+func TestErrorCodesDocumented(t *testing.T) {
+	source, err := os.ReadFile("errorcodes.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var codes []string
+	for _, match := range errorCodeConst.FindAllStringSubmatch(string(source), -1) {
+		codes = append(codes, match[1])
+	}
+	for status, code := range statusErrorCodes {
+		if !strings.Contains(string(source), `"`+code+`"`) {
+			t.Errorf("status %d maps to undeclared code %q", status, code)
+		}
+	}
+
+	raw, err := os.ReadFile("api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Components struct {
+			Schemas struct {
+				ErrorCode struct {
+					Enum []string `json:"enum"`
+				} `json:"ErrorCode"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+	documented := spec.Components.Schemas.ErrorCode.Enum
+	sort.Strings(codes)
+	sort.Strings(documented)
+	if !reflect.DeepEqual(documented, codes) {
+		t.Errorf("spec documents codes %v, server declares %v", documented, codes)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"Empty", "", false},
+		{"Same", `"abc"`, true},
+		{"Different", `"abd"`, false},
+		{"Weak", `W/"abc"`, true},
+		{"List", `"xyz", "abc"`, true},
+		{"Any", "*", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, `"abc"`); got != tt.want {
+				t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListTasksETag(t *testing.T) {
+	store := useTasks(t, []Task{{ID: 1, Title: "Read"}, {ID: 2, Title: "Write", Completed: true}})
+	get := func(url, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		Tasks(rec, req)
+		return rec
+	}
+
+	first := get("/tasks", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q, want 200 with an ETag", first.Code, etag)
+	}
+	if got := first.Header().Get("Cache-Control"); got != "private, no-cache" {
+		t.Errorf("Cache-Control = %q, want private, no-cache", got)
+	}
+
+	unchanged := get("/tasks", etag)
+	if unchanged.Code != http.StatusNotModified || unchanged.Body.Len() != 0 {
+		t.Fatalf("status = %d, body %q, want an empty 304", unchanged.Code, unchanged.Body)
+	}
+	if got := unchanged.Header().Get("ETag"); got != etag {
+		t.Errorf("304 ETag = %q, want %q", got, etag)
+	}
+
+	if filtered := get("/tasks?completed=false", etag); filtered.Code != http.StatusOK || filtered.Header().Get("ETag") == etag {
+		t.Errorf("filtered list: status = %d, ETag = %q, want 200 with a different ETag", filtered.Code, filtered.Header().Get("ETag"))
+	}
+
+	if _, err := store.Create(Task{Title: "Edit"}); err != nil {
+		t.Fatal(err)
+	}
+	changed := get("/tasks", etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Errorf("after a change: status = %d, ETag = %q, want 200 with a new ETag", changed.Code, changed.Header().Get("ETag"))
+	}
+}
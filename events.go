@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// taskEventCounters tallies task lifecycle events since startup for /metrics.
+// Rates such as tasks created per hour come from these counters via rate() in the dashboard.
+type taskEventCounters struct {
+	mu        sync.Mutex
+	created   int
+	completed int
+	// completionSeconds sums created-to-completed time over completedTimed tasks; tasks saved
+	// before created_at was recorded are counted as completed but left out of the average
+	completionSeconds float64
+	completedTimed    int
+}
+
+// taskEvents is fed by the memory store so every path that changes tasks is counted
+var taskEvents = &taskEventCounters{}
+
+func (c *taskEventCounters) recordCreated() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.created++
+}
+
+// recordCompleted counts a task moving to completed at now
+func (c *taskEventCounters) recordCompleted(task Task, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed++
+	if task.CreatedAt != nil {
+		c.completionSeconds += now.Sub(*task.CreatedAt).Seconds()
+		c.completedTimed++
+	}
+}
+
+// taskEventStats is a snapshot of taskEventCounters
+type taskEventStats struct {
+	Created           int
+	Completed         int
+	CompletionSeconds float64
+	CompletedTimed    int
+}
+
+func (c *taskEventCounters) stats() taskEventStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return taskEventStats{Created: c.created, Completed: c.completed, CompletionSeconds: c.completionSeconds, CompletedTimed: c.completedTimed}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Kinds of task change published on taskChanges
+const (
+	changeCreated = "created"
+	changeUpdated = "updated"
+	changeDeleted = "deleted"
+)
+
+// taskChange is one change to a task: the task as created or updated, or as it was when deleted
+type taskChange struct {
+	Kind string
+	Task Task
+}
+
+// changeBufferSize is how many changes a subscriber may fall behind by before it is dropped
+const changeBufferSize = 64
+
+// changeBus fans task changes out to subscribers. Publishing never blocks: a subscriber that falls
+// changeBufferSize changes behind has its channel closed, so its client reconnects and reloads the
+// list rather than silently missing changes.
+type changeBus struct {
+	mu          sync.Mutex
+	subscribers map[chan taskChange]bool
+	closed      bool
+}
+
+// taskChanges is fed by the memory store, once each change has committed, so every path that
+// changes tasks is published
+var taskChanges = newChangeBus()
+
+func newChangeBus() *changeBus {
+	return &changeBus{subscribers: map[chan taskChange]bool{}}
+}
+
+// subscribe returns a channel receiving every change from now on, and false once the bus is closed
+func (b *changeBus) subscribe() (chan taskChange, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, false
+	}
+	ch := make(chan taskChange, changeBufferSize)
+	b.subscribers[ch] = true
+	return ch, true
+}
+
+// unsubscribe stops deliveries to ch and closes it, if publish hasn't already
+func (b *changeBus) unsubscribe(ch chan taskChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscribers[ch] {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (b *changeBus) publish(kind string, task Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- taskChange{Kind: kind, Task: task}:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// close ends every subscription and turns new ones away, so open streams end ahead of the drain
+func (b *changeBus) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// subscriberCount reports how many subscribers are connected
+func (b *changeBus) subscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
+
+// publishChange publishes kind for task once the change making it commits. Callers must hold s.mu.
+func (s *memoryStore) publishChange(kind string, task Task) {
+	task = task.clone()
+	s.afterCommit(func() { taskChanges.publish(kind, task) })
+}
+
+// eventKeepAlive is how often an idle stream gets a comment line, so proxies don't time it out
+var eventKeepAlive = 30 * time.Second
+
+// Events streams task changes as Server-Sent Events until the client disconnects. Each event is
+// named created, updated, or deleted, and its data is the task as JSON. With USERS_FILE set, a user
+// only hears about their own tasks.
+func Events(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method: %s", r.Method)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	changes, ok := taskChanges.subscribe()
+	if !ok {
+		writeJsonError(w, http.StatusServiceUnavailable, "The server is shutting down")
+		return
+	}
+	defer taskChanges.unsubscribe(changes)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	// Tell nginx not to buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		logErrorContext(r.Context(), "Cannot stream events: %v", err)
+		return
+	}
+
+	owner := userFor(r)
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case change, open := <-changes:
+			if !open {
+				// Dropped for falling behind, or the server is shutting down
+				return
+			}
+			if owner != "" && change.Task.Owner != owner {
+				continue
+			}
+			data, err := json.Marshal(change.Task)
+			if err != nil {
+				logErrorContext(r.Context(), "Failed to encode task %d: %v", change.Task.ID, err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.Kind, data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useChangeBus gives the test its own taskChanges bus
+func useChangeBus(t *testing.T) *changeBus {
+	t.Helper()
+	bus, original := newChangeBus(), taskChanges
+	taskChanges = bus
+	t.Cleanup(func() { taskChanges = original })
+	return bus
+}
+
+// drain returns the changes waiting on ch as "kind title" strings
+func drain(ch chan taskChange) []string {
+	var got []string
+	for {
+		select {
+		case change := <-ch:
+			got = append(got, change.Kind+" "+change.Task.Title)
+		default:
+			return got
+		}
+	}
+}
+
+func TestStorePublishesChanges(t *testing.T) {
+	bus := useChangeBus(t)
+	changes, _ := bus.subscribe()
+	store := newMemoryStore([]Task{{ID: 1, Title: "Old"}})
+
+	created, _ := store.Create(Task{Title: "New"})
+	store.Update(created.ID, func(t *Task) error { t.Completed = true; return nil })
+	store.Delete(1)
+	store.Insert([]Task{{ID: 7, Title: "Restored"}}, conflictFail)
+	store.DeleteWhere(store.Generation(), func(t Task) bool { return t.ID == 7 })
+	want := []string{"created New", "updated New", "deleted Old", "created Restored", "deleted Restored"}
+	if got := drain(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("published %v, want %v", got, want)
+	}
+
+	// A transaction publishes only once it commits, and not at all when it fails
+	store.WithTx(func(tx TaskStore) error {
+		tx.Create(Task{Title: "Kept"})
+		if got := drain(changes); len(got) != 0 {
+			t.Errorf("published %v before commit", got)
+		}
+		return nil
+	})
+	store.WithTx(func(tx TaskStore) error {
+		tx.Create(Task{Title: "Dropped"})
+		return errors.New("rolled back")
+	})
+	if got := drain(changes); !reflect.DeepEqual(got, []string{"created Kept"}) {
+		t.Errorf("transactions published %v, want [created Kept]", got)
+	}
+}
+
+func TestChangeBusDropsSlowSubscribers(t *testing.T) {
+	bus := newChangeBus()
+	slow, _ := bus.subscribe()
+	for i := 0; i <= changeBufferSize; i++ {
+		bus.publish(changeCreated, Task{ID: i})
+	}
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != changeBufferSize || bus.subscriberCount() != 0 {
+		t.Errorf("received %d then closed with %d subscribers, want %d and 0", received, bus.subscriberCount(), changeBufferSize)
+	}
+
+	// Closing ends the remaining subscriptions and refuses new ones
+	open, _ := bus.subscribe()
+	bus.close()
+	if _, ok := <-open; ok {
+		t.Error("subscription still open after close")
+	}
+	if _, ok := bus.subscribe(); ok {
+		t.Error("subscribed to a closed bus")
+	}
+}
+
+// readEvent reads one event from an SSE stream, skipping comments
+func readEvent(t *testing.T, stream *bufio.Reader) string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && len(lines) > 0:
+			return strings.Join(lines, "\n")
+		case line == "" || strings.HasPrefix(line, ":"):
+		default:
+			lines = append(lines, line)
+		}
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	bus := useChangeBus(t)
+	server, client := StartTestServer(t)
+	resp, err := server.Client().Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("got %d %q, want 200 text/event-stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	stream := bufio.NewReader(resp.Body)
+
+	client.Post("/tasks", `{"title":"Stream me"}`)
+	client.Put("/tasks/1", `{"title":"Stream me","completed":true}`)
+	client.Delete("/tasks/1")
+	for _, want := range []struct{ event, version string }{
+		{"created", `"version":1`},
+		{"updated", `"version":2`},
+		{"deleted", `"version":2`},
+	} {
+		got := readEvent(t, stream)
+		if !strings.HasPrefix(got, "event: "+want.event+"\ndata: {\"id\":1,\"title\":\"Stream me\"") || !strings.Contains(got, want.version) {
+			t.Errorf("got event\n%s\nwant %s of task 1 at %s", got, want.event, want.version)
+		}
+	}
+
+	// Shutting down ends the stream
+	bus.close()
+	if _, err := stream.ReadString('\n'); err == nil {
+		t.Error("stream still open after the bus closed")
+	}
+	if status, _ := client.Get("/events"); status != http.StatusServiceUnavailable {
+		t.Errorf("GET /events after close = %d, want 503", status)
+	}
+}
+
+func TestEventsOnlyOwnTasks(t *testing.T) {
+	bus := useChangeBus(t)
+	eventKeepAlive = 10 * time.Millisecond
+	t.Cleanup(func() { eventKeepAlive = 30 * time.Second })
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Events(w, withUser(r, "alice"))
+	}))
+	t.Cleanup(server.Close)
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	stream := bufio.NewReader(resp.Body)
+
+	bus.publish(changeCreated, Task{ID: 1, Title: "Bob's", Owner: "bob"})
+	bus.publish(changeCreated, Task{ID: 2, Title: "Alice's", Owner: "alice"})
+	if got := readEvent(t, stream); !strings.Contains(got, `"Alice's"`) {
+		t.Errorf("first event = %q, want Alice's task", got)
+	}
+	// Idle streams get keep-alive comments
+	if line, _ := stream.ReadString('\n'); line != ": keep-alive\n" {
+		t.Errorf("idle stream sent %q, want a keep-alive comment", line)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// exportSchemaVersion is the version of the envelope GET /export writes. Bump it when the task model
+// changes in a way older exports can't be read as, and teach readExport to upgrade the older version.
+const exportSchemaVersion = 1
+
+// ExportEnvelope is the body of GET /export and POST /import
+type ExportEnvelope struct {
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	// Tasks are in display order
+	Tasks []Task `json:"tasks"`
+}
+
+// ExportTasks serves GET /export, every task the caller can see in a versioned envelope. The GET /tasks
+// filters and ?sort= narrow and order it; ?fields= and pagination aren't accepted, since the export has
+// to hold whole tasks to be imported again.
+func ExportTasks(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method: %s", r.Method)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	query, err := parseTaskListQuery(r.URL.Query())
+	if err == nil && (query.Fields != nil || query.Page != nil) {
+		err = errors.New("export doesn't support fields, limit, offset, or after_id")
+	}
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := clock.Now()
+	data, err := marshalCanonical(ExportEnvelope{SchemaVersion: exportSchemaVersion, ExportedAt: now, Tasks: selectTasks(storeFor(r).List(), query)})
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to encode export: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to encode export")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks-export-%s.json"`, now.Format("20060102T150405Z")))
+	w.Write(data)
+}
+
+// ImportTasks serves POST /import, restoring the tasks of an export with their IDs. Nothing is imported
+// unless every task is valid. ?on_conflict= says what to do with tasks whose IDs are already in use:
+// fail the whole import (the default), skip them, or remap them to new IDs.
+func ImportTasks(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	if r.Method != "POST" {
+		logErrorContext(r.Context(), "Unsupported method: %s", r.Method)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	onConflict := r.URL.Query().Get("on_conflict")
+	switch onConflict {
+	case "":
+		onConflict = conflictFail
+	case conflictFail, conflictSkip, conflictRemap:
+	default:
+		logErrorContext(r.Context(), "Invalid on_conflict %q", onConflict)
+		writeJsonError(w, http.StatusBadRequest, "on_conflict must be fail, skip, or remap")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to read import body: %v", err)
+		writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	export, err := readExport(body)
+	if err != nil {
+		logErrorContext(r.Context(), "Rejected import: %v", err)
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if problems := prepareExportedTasks(export.Tasks); len(problems) > 0 {
+		logErrorContext(r.Context(), "Rejected import with %d problems", len(problems))
+		writeJsonErrorBody(w, http.StatusBadRequest, struct {
+			errorResponse
+			Problems []ValidationProblem `json:"problems"`
+		}{errorResponse{"Import contains invalid tasks", codeValidationFailed}, problems})
+		return
+	}
+
+	store := storeFor(r)
+	result, err := store.Insert(export.Tasks, onConflict)
+	if errors.Is(err, ErrTaskIDTaken) {
+		logErrorContext(r.Context(), "Rejected import: %v", err)
+		writeJsonError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, ErrParentNotFound) {
+		logErrorContext(r.Context(), "Rejected import: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to import tasks: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to import tasks")
+		return
+	}
+	for _, t := range result.Inserted {
+		queueLinkTitles(store, t)
+	}
+	logInfoContext(r.Context(), "Imported %d tasks from a schema version %d export (%d skipped, %d remapped)",
+		len(result.Inserted), export.SchemaVersion, len(result.Skipped), len(result.IDMap))
+	response := map[string]interface{}{"status": "success", "imported": len(result.Inserted), "schema_version": export.SchemaVersion}
+	switch onConflict {
+	case conflictSkip:
+		response["skipped"] = result.Skipped
+	case conflictRemap:
+		// JSON object keys are strings, so the old IDs are written as "7": 12
+		response["id_map"] = result.IDMap
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// readExport decodes an export, upgrading older versions to the current task model. A bare array is
+// a tasks.json file, which has no version marker, and is read as version 0.
+func readExport(body []byte) (ExportEnvelope, error) {
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("[")) {
+		var stored []storedTask
+		if err := json.Unmarshal(body, &stored); err != nil {
+			return ExportEnvelope{}, errors.New("Invalid JSON format")
+		}
+		return ExportEnvelope{Tasks: displayOrder(stored)}, nil
+	}
+
+	var envelope struct {
+		SchemaVersion *int            `json:"schema_version"`
+		ExportedAt    time.Time       `json:"exported_at"`
+		Tasks         json.RawMessage `json:"tasks"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return ExportEnvelope{}, errors.New("Invalid JSON format")
+	}
+	switch {
+	case envelope.SchemaVersion == nil:
+		return ExportEnvelope{}, errors.New("schema_version is required")
+	case *envelope.SchemaVersion > exportSchemaVersion:
+		return ExportEnvelope{}, fmt.Errorf("schema_version %d is newer than this server supports (%d)", *envelope.SchemaVersion, exportSchemaVersion)
+	case *envelope.SchemaVersion < 1:
+		return ExportEnvelope{}, fmt.Errorf("schema_version %d is not supported", *envelope.SchemaVersion)
+	}
+	export := ExportEnvelope{SchemaVersion: *envelope.SchemaVersion, ExportedAt: envelope.ExportedAt}
+	if err := json.Unmarshal(envelope.Tasks, &export.Tasks); err != nil {
+		return ExportEnvelope{}, errors.New("tasks must be an array of tasks")
+	}
+	return export, nil
+}
+
+// prepareExportedTasks validates and normalizes imported tasks in place, reporting every problem.
+// IDs, owners, and timestamps are kept, since an import restores tasks rather than creating them;
+// edit locks are dropped and checklist percentages recomputed. Parents are checked by Insert, once
+// conflicts are resolved.
+func prepareExportedTasks(tasks []Task) []ValidationProblem {
+	problems := []ValidationProblem{}
+	seen := make(map[int]int, len(tasks))
+	for i := range tasks {
+		t := &tasks[i]
+		t.Title = normalizeText(t.Title)
+		for _, problem := range validateTask(*t) {
+			problem.Index = i
+			problems = append(problems, problem)
+		}
+		if first, ok := seen[t.ID]; ok {
+			problems = append(problems, ValidationProblem{Index: i, ID: t.ID, Field: "id", Message: fmt.Sprintf("duplicate ID, first used at index %d", first)})
+		} else {
+			seen[t.ID] = i
+		}
+		var err error
+		t.Tags, _ = normalizeTags(t.Tags)
+		t.Notes, _ = normalizeNotes(t.Notes)
+		if t.Links, err = normalizeLinks(t.Links); err != nil {
+			problems = append(problems, ValidationProblem{Index: i, ID: t.ID, Field: "links", Message: err.Error()})
+		}
+		t.Lock = nil
+		updateChecklistCompletion(t)
+	}
+	return problems
+}
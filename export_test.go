@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	useFakeClock(t, testNow)
+	original := []Task{
+		{ID: 7, Title: "Seventh", Tags: []string{"work"}, Notes: "a <b> & c"},
+		{ID: 2, Title: "Second", Completed: true, CreatedAt: &testNow},
+	}
+	useTasks(t, original)
+
+	rec := httptest.NewRecorder()
+	ExportTasks(rec, httptest.NewRequest("GET", "/export", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="tasks-export-20240501T120000Z.json"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	exported := rec.Body.String()
+	var export ExportEnvelope
+	if err := json.Unmarshal([]byte(exported), &export); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	if export.SchemaVersion != exportSchemaVersion || !export.ExportedAt.Equal(testNow) || !reflect.DeepEqual(export.Tasks, original) {
+		t.Fatalf("export = %+v", export)
+	}
+
+	store := useTasks(t, nil)
+	rec = httptest.NewRecorder()
+	ImportTasks(rec, httptest.NewRequest("POST", "/import", strings.NewReader(exported)))
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"imported":2,"schema_version":1,"status":"success"}`+"\n" {
+		t.Fatalf("import = %d %s", rec.Code, rec.Body)
+	}
+	if got := store.List(); !reflect.DeepEqual(got, original) {
+		t.Errorf("restored %+v, want %+v", got, original)
+	}
+	if created, _ := store.Create(Task{Title: "Next"}); created.ID != 8 {
+		t.Errorf("next ID = %d, want 8", created.ID)
+	}
+}
+
+func TestExportFilters(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Report", Tags: []string{"work"}, DueDate: "2024-05-20T09:00:00Z"},
+		{ID: 2, Title: "Invoice", Tags: []string{"work"}, DueDate: "2024-06-03T09:00:00Z"},
+		{ID: 3, Title: "Filed", Tags: []string{"work"}, Completed: true, DueDate: "2024-05-02T09:00:00Z"},
+		{ID: 4, Title: "Groceries", DueDate: "2024-05-04T09:00:00Z"},
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []int
+		wantError  string
+	}{
+		{"Everything", "", http.StatusOK, []int{1, 2, 3, 4}, ""},
+		{"Open Work Due This Month", "completed=false&tag=work&due_from=2024-05-01&due_before=2024-06-01", http.StatusOK, []int{1}, ""},
+		{"Sorted", "sort=title", http.StatusOK, []int{3, 4, 2, 1}, ""},
+		{"Bad Filter", "completed=maybe", http.StatusBadRequest, nil, "completed must be true or false"},
+		{"Projection", "fields=id", http.StatusBadRequest, nil, "export doesn't support fields, limit, offset, or after_id"},
+		{"Page", "limit=1", http.StatusBadRequest, nil, "export doesn't support fields, limit, offset, or after_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ExportTasks(rec, httptest.NewRequest("GET", "/export?"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantError != "" {
+				if !strings.Contains(rec.Body.String(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", rec.Body, tt.wantError)
+				}
+				return
+			}
+			var export ExportEnvelope
+			json.Unmarshal(rec.Body.Bytes(), &export)
+			var ids []int
+			for _, task := range export.Tasks {
+				ids = append(ids, task.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("exported IDs %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestImportRejections(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "no schema version",
+			body:       `{"tasks": []}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"schema_version is required","code":"invalid_request"}`,
+		},
+		{
+			name:       "newer schema version",
+			body:       `{"schema_version": 2, "tasks": []}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"schema_version 2 is newer than this server supports (1)","code":"invalid_request"}`,
+		},
+		{
+			name:       "not JSON",
+			body:       `{"schema_version": 1,`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"Invalid JSON format","code":"invalid_request"}`,
+		},
+		{
+			name:       "invalid tasks",
+			body:       `{"schema_version": 1, "tasks": [{"id": 5, "title": ""}, {"id": 5, "title": "Dup", "priority": "urgent"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody: `{"error":"Import contains invalid tasks","code":"validation_failed","problems":[{"index":0,"id":5,"field":"title","message":"title cannot be empty"},` +
+				`{"index":1,"id":5,"field":"priority","message":"priority must be low, medium, or high"},` +
+				`{"index":1,"id":5,"field":"id","message":"duplicate ID, first used at index 0"}]}`,
+		},
+		{
+			name:       "missing parent",
+			body:       `{"schema_version": 1, "tasks": [{"id": 3, "title": "New"}, {"id": 4, "title": "Orphan", "parent_id": 9}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   `{"error":"parent task does not exist: task 4 has parent_id 9","code":"validation_failed"}`,
+		},
+		{
+			name:       "ID in use",
+			body:       `{"schema_version": 1, "tasks": [{"id": 3, "title": "New"}, {"id": 1, "title": "Clash"}]}`,
+			wantStatus: http.StatusConflict,
+			wantBody:   `{"error":"task ID already in use: 1","code":"conflict"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useTasks(t, []Task{{ID: 1, Title: "Existing"}})
+			rec := httptest.NewRecorder()
+			ImportTasks(rec, httptest.NewRequest("POST", "/import", strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus || strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("got %d %s, want %d %s", rec.Code, rec.Body, tt.wantStatus, tt.wantBody)
+			}
+			if n := len(store.List()); n != 1 {
+				t.Errorf("store has %d tasks after a rejected import, want 1", n)
+			}
+		})
+	}
+}
+
+func TestImportTasksFile(t *testing.T) {
+	store := useTasks(t, nil)
+	// A tasks.json file has no schema version; its positions give the display order
+	body := `[{"id": 1, "title": "One", "position": 1}, {"id": 2, "title": "Two", "position": 0, "lock": {"owner": "Bob"}}]`
+	rec := httptest.NewRecorder()
+	ImportTasks(rec, httptest.NewRequest("POST", "/import", strings.NewReader(body)))
+	if rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"schema_version":0`) {
+		t.Fatalf("import = %d %s", rec.Code, rec.Body)
+	}
+	want := []Task{{ID: 2, Title: "Two"}, {ID: 1, Title: "One"}}
+	if got := store.List(); !reflect.DeepEqual(got, want) {
+		t.Errorf("imported %+v, want %+v", got, want)
+	}
+}
+
+func TestImportAsUser(t *testing.T) {
+	store := useTasks(t, []Task{{ID: 1, Title: "Bob's", Owner: "bob"}})
+	body := `{"schema_version": 1, "tasks": [{"id": 4, "title": "Mine", "owner": "bob"}]}`
+	rec := httptest.NewRecorder()
+	ImportTasks(rec, withUser(httptest.NewRequest("POST", "/import", strings.NewReader(body)), "alice"))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("import = %d %s", rec.Code, rec.Body)
+	}
+	if task, _ := store.Get(4); task.Owner != "alice" {
+		t.Errorf("owner = %q, want alice", task.Owner)
+	}
+
+	rec = httptest.NewRecorder()
+	ExportTasks(rec, withUser(httptest.NewRequest("GET", "/export", nil), "alice"))
+	var export ExportEnvelope
+	json.Unmarshal(rec.Body.Bytes(), &export)
+	if len(export.Tasks) != 1 || export.Tasks[0].ID != 4 {
+		t.Errorf("alice's export = %+v, want only task 4", export.Tasks)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	body := `{"schema_version": 1, "tasks": [{"id": 1, "title": "Parent"}, {"id": 3, "title": "Child", "parent_id": 1}, {"id": 2, "title": "Clash"}]}`
+	existing := []Task{{ID: 1, Title: "Existing"}, {ID: 2, Title: "Other"}}
+	tests := []struct {
+		name       string
+		onConflict string
+		wantStatus int
+		wantBody   string
+		wantAdded  []Task
+	}{
+		{"Default", "", http.StatusConflict, `{"error":"task ID already in use: 1, 2","code":"conflict"}`, nil},
+		{"Fail", "fail", http.StatusConflict, `{"error":"task ID already in use: 1, 2","code":"conflict"}`, nil},
+		{
+			"Skip", "skip", http.StatusCreated,
+			`{"imported":1,"schema_version":1,"skipped":[1,2],"status":"success"}`,
+			[]Task{{ID: 3, Title: "Child", ParentID: 1}},
+		},
+		{
+			"Remap", "remap", http.StatusCreated,
+			`{"id_map":{"1":4,"2":5},"imported":3,"schema_version":1,"status":"success"}`,
+			[]Task{{ID: 4, Title: "Parent"}, {ID: 3, Title: "Child", ParentID: 4}, {ID: 5, Title: "Clash"}},
+		},
+		{"Unknown", "merge", http.StatusBadRequest, `{"error":"on_conflict must be fail, skip, or remap","code":"invalid_request"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useTasks(t, existing)
+			rec := httptest.NewRecorder()
+			ImportTasks(rec, httptest.NewRequest("POST", "/import?on_conflict="+tt.onConflict, strings.NewReader(body)))
+			if rec.Code != tt.wantStatus || strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("got %d %s, want %d %s", rec.Code, rec.Body, tt.wantStatus, tt.wantBody)
+			}
+			want := append(append([]Task(nil), existing...), tt.wantAdded...)
+			if got := store.List(); !reflect.DeepEqual(got, want) {
+				t.Errorf("tasks = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestImportRemapNoConflicts(t *testing.T) {
+	useTasks(t, nil)
+	rec := httptest.NewRecorder()
+	ImportTasks(rec, httptest.NewRequest("POST", "/import?on_conflict=remap", strings.NewReader(`{"schema_version": 1, "tasks": [{"id": 1, "title": "One"}]}`)))
+	if want := `{"id_map":{},"imported":1,"schema_version":1,"status":"success"}`; strings.TrimSpace(rec.Body.String()) != want {
+		t.Errorf("body = %s, want %s", rec.Body, want)
+	}
+}
+
+func TestImportParentsStayWithOwner(t *testing.T) {
+	store := useTasks(t, []Task{{ID: 1, Title: "Bob's", Owner: "bob"}, {ID: 2, Title: "Alice's", Owner: "alice"}})
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		// Skipping task 1 would otherwise leave the child under Bob's task 1
+		{"Skipped Parent Is Someone Else's", `{"schema_version": 1, "tasks": [{"id": 1, "title": "Parent"}, {"id": 5, "title": "Child", "parent_id": 1}]}`, http.StatusBadRequest},
+		{"Someone Else's Parent", `{"schema_version": 1, "tasks": [{"id": 5, "title": "Child", "parent_id": 1}]}`, http.StatusBadRequest},
+		{"Own Parent", `{"schema_version": 1, "tasks": [{"id": 5, "title": "Child", "parent_id": 2, "checklist": [{"id": 1, "text": "A", "done": true}], "checklist_completion": 0}]}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		ImportTasks(rec, withUser(httptest.NewRequest("POST", "/import?on_conflict=skip", strings.NewReader(tt.body)), "alice"))
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: got %d %s, want %d", tt.name, rec.Code, rec.Body, tt.wantStatus)
+		}
+	}
+	if task, _ := store.Get(5); task.ParentID != 2 || task.ChecklistCompletion == nil || *task.ChecklistCompletion != 100 {
+		t.Errorf("imported %+v, want task 5 under task 2 with its completion recomputed", task)
+	}
+}
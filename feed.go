@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// feedLimit caps how many tasks appear in a feed, newest first
+const feedLimit = 50
+
+// feedMaxAge is how long (in seconds) clients, and shared caches while no users are configured, may reuse
+// a feed response
+const feedMaxAge = 60
+
+// JSONFeed is the top-level document of the JSON Feed 1.1 format (https://jsonfeed.org/version/1.1)
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+// JSONFeedItem is a single task entry in a JSON Feed
+type JSONFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url,omitempty"`
+	Title         string   `json:"title"`
+	ContentText   string   `json:"content_text"`
+	DatePublished string   `json:"date_published,omitempty"`
+	DateModified  string   `json:"date_modified,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// JSONFeedHandler serves GET /feed.json, a read-only JSON Feed of recent task activity
+func JSONFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	baseURL := requestBaseURL(r)
+	store := storeFor(r)
+	modified := store.Modified()
+	recent := recentTasks(store.List(), nil)
+
+	feed := JSONFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       "Task Tracker",
+		HomePageURL: baseURL + "/tasks",
+		FeedURL:     baseURL + "/feed.json",
+		Description: "Recently created and completed tasks",
+		Items:       []JSONFeedItem{},
+	}
+	for _, t := range recent {
+		item := JSONFeedItem{
+			ID:          fmt.Sprintf("%s/tasks/%d", baseURL, t.ID),
+			URL:         fmt.Sprintf("%s/tasks/%d", baseURL, t.ID),
+			Title:       t.Title,
+			ContentText: "Created: " + t.Title,
+			Tags:        []string{"created"},
+		}
+		if t.CreatedAt != nil {
+			item.DatePublished = t.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		if t.UpdatedAt != nil {
+			item.DateModified = t.UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		if t.Completed {
+			item.ContentText = "Completed: " + t.Title
+			item.Tags = []string{"completed"}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+
+	body, err := json.Marshal(feed)
+	if err != nil {
+		logErrorContext(r.Context(), "JSON marshalling of feed failed")
+		writeJsonError(w, http.StatusInternalServerError, "Internal server error: JSON marshalling failed")
+		return
+	}
+	writeCachedFeed(w, r, "application/feed+json", body, modified)
+}
+
+// AtomFeed is the root <feed> element of an Atom 1.0 document (RFC 4287)
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// AtomLink is an Atom <link> element
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+// AtomEntry is a single task event in an Atom feed
+type AtomEntry struct {
+	ID       string       `xml:"id"`
+	Title    string       `xml:"title"`
+	Updated  string       `xml:"updated"`
+	Link     AtomLink     `xml:"link"`
+	Category AtomCategory `xml:"category"`
+	Summary  string       `xml:"summary"`
+}
+
+// AtomCategory labels an entry as a created or completed event
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// AtomFeedHandler serves GET /feed.atom, an Atom feed of task activity.
+// An optional ?completed=true|false filter narrows the feed to completed or open tasks.
+func AtomFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	params := newQueryParams(r.URL.Query())
+	completedFilter := params.Bool("completed")
+	if err := params.Err(); err != nil {
+		logErrorContext(r.Context(), "Invalid feed query: %v", err)
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	baseURL := requestBaseURL(r)
+	store := storeFor(r)
+	modified := store.Modified()
+	recent := recentTasks(store.List(), completedFilter)
+
+	// The feed is as recent as the last list change. Atom requires every entry to have a time too, so
+	// tasks saved before timestamps were recorded fall back to it.
+	updated := modified
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	stamp := updated.UTC().Format(time.RFC3339)
+
+	feed := AtomFeed{
+		ID:      baseURL + "/feed.atom",
+		Title:   "Task Tracker",
+		Updated: stamp,
+		Links: []AtomLink{
+			{Href: baseURL + "/feed.atom", Rel: "self"},
+			{Href: baseURL + "/tasks", Rel: "alternate"},
+		},
+	}
+	for _, t := range recent {
+		term, summary := "created", "Created: "+t.Title
+		if t.Completed {
+			term, summary = "completed", "Completed: "+t.Title
+		}
+		entryStamp := stamp
+		if t.UpdatedAt != nil {
+			entryStamp = t.UpdatedAt.UTC().Format(time.RFC3339)
+		} else if t.CreatedAt != nil {
+			entryStamp = t.CreatedAt.UTC().Format(time.RFC3339)
+		}
+		feed.Entries = append(feed.Entries, AtomEntry{
+			ID:       fmt.Sprintf("%s/tasks/%d", baseURL, t.ID),
+			Title:    t.Title,
+			Updated:  entryStamp,
+			Link:     AtomLink{Href: fmt.Sprintf("%s/tasks/%d", baseURL, t.ID)},
+			Category: AtomCategory{Term: term},
+			Summary:  summary,
+		})
+	}
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		logErrorContext(r.Context(), "XML marshalling of feed failed")
+		writeJsonError(w, http.StatusInternalServerError, "Internal server error: XML marshalling failed")
+		return
+	}
+	writeCachedFeed(w, r, "application/atom+xml", append([]byte(xml.Header), body...), modified)
+}
+
+// recentTasks returns up to feedLimit tasks from list, most recently changed first, optionally restricted
+// by completion state
+func recentTasks(list []Task, completed *bool) []Task {
+	recent := make([]Task, 0, len(list))
+	for _, t := range list {
+		if completed == nil || t.Completed == *completed {
+			recent = append(recent, t)
+		}
+	}
+	// Most recently changed first, so an old task completed just now makes the cut. Tasks saved before
+	// timestamps were recorded sort last, newest ID first, as IDs are assigned in creation order.
+	sort.Slice(recent, func(i, j int) bool {
+		a, b := lastActivity(recent[i]), lastActivity(recent[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return recent[i].ID > recent[j].ID
+	})
+	if len(recent) > feedLimit {
+		recent = recent[:feedLimit]
+	}
+	return recent
+}
+
+// lastActivity is when t was last changed, or created if it never was, and zero if neither is known
+func lastActivity(t Task) time.Time {
+	switch {
+	case t.UpdatedAt != nil:
+		return *t.UpdatedAt
+	case t.CreatedAt != nil:
+		return *t.CreatedAt
+	}
+	return time.Time{}
+}
+
+// writeCachedFeed writes a feed body with strong validators, answering 304 when the client copy is current.
+// With users configured each feed holds one user's tasks, so shared caches must not keep it.
+func writeCachedFeed(w http.ResponseWriter, r *http.Request, contentType string, body []byte, modified time.Time) {
+	etag := bodyETag(body)
+	w.Header().Set("ETag", etag)
+	if len(users) > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", feedMaxAge))
+		w.Header().Add("Vary", "Authorization")
+		if authProxyHeader != "" {
+			w.Header().Add("Vary", authProxyHeader)
+		}
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", feedMaxAge))
+	}
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// requestBaseURL reconstructs the scheme and host the client used to reach the server
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if isHTTPS(r) {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONFeed(t *testing.T) {
+	useTasks(t, []Task{
+		{ID: 1, Title: "Clean the carpet", Completed: false},
+		{ID: 2, Title: "Pick up the groceries", Completed: true},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/feed.json", nil)
+	rec := httptest.NewRecorder()
+	JSONFeedHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/feed+json" {
+		t.Errorf("got Content-Type %q, want application/feed+json", got)
+	}
+	if rec.Header().Get("Cache-Control") == "" {
+		t.Errorf("expected a Cache-Control header")
+	}
+
+	// Newest task comes first
+	wantItems := `"items":[{"id":"http://example.com/tasks/2","url":"http://example.com/tasks/2","title":"Pick up the groceries","content_text":"Completed: Pick up the groceries","tags":["completed"]},` +
+		`{"id":"http://example.com/tasks/1","url":"http://example.com/tasks/1","title":"Clean the carpet","content_text":"Created: Clean the carpet","tags":["created"]}]`
+	gotBody := strings.TrimSpace(rec.Body.String())
+	if !strings.Contains(gotBody, wantItems) {
+		t.Errorf("got body %s, want items %s", gotBody, wantItems)
+	}
+
+	// A matching ETag short-circuits to 304
+	etag := rec.Header().Get("ETag")
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/feed.json", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	JSONFeedHandler(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("got status %d with matching ETag, want %d", rec.Code, http.StatusNotModified)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected empty body on 304, got %s", rec.Body.String())
+	}
+}
+
+func TestAtomFeed(t *testing.T) {
+	useTasks(t, []Task{
+		{ID: 1, Title: "Clean the carpet", Completed: false},
+		{ID: 2, Title: "Pick up the groceries", Completed: true},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/feed.atom?completed=true", nil)
+	rec := httptest.NewRecorder()
+	AtomFeedHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/atom+xml" {
+		t.Errorf("got Content-Type %q, want application/atom+xml", got)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `<category term="completed"></category>`) || !strings.Contains(body, "Pick up the groceries") {
+		t.Errorf("expected the completed task in the feed, got %s", body)
+	}
+	if strings.Contains(body, "Clean the carpet") {
+		t.Errorf("expected open tasks to be filtered out, got %s", body)
+	}
+
+	// An invalid filter is rejected
+	req = httptest.NewRequest(http.MethodGet, "http://example.com/feed.atom?completed=maybe", nil)
+	rec = httptest.NewRecorder()
+	AtomFeedHandler(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("got status %d for invalid filter, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFeedsArePrivateToUsers(t *testing.T) {
+	useUsers(t, "alice", "bob")
+	server, alice := StartTestServer(t)
+	alice.Key = "alice-key"
+	bob := &Client{t: t, baseURL: server.URL, http: server.Client(), Key: "bob-key"}
+	alice.Post("/tasks", `{"title": "Alice's task"}`)
+	bob.Post("/tasks", `{"title": "Bob's task"}`)
+
+	for _, tt := range []struct {
+		key, want, notWant string
+	}{
+		{"alice-key", "Alice's task", "Bob's task"},
+		{"bob-key", "Bob's task", "Alice's task"},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/feed.json", nil)
+		req.Header.Set("Authorization", "Bearer "+tt.key)
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !strings.Contains(string(body), tt.want) || strings.Contains(string(body), tt.notWant) {
+			t.Errorf("%s got feed %s, want only %q", tt.key, body, tt.want)
+		}
+		if cc := resp.Header.Get("Cache-Control"); !strings.HasPrefix(cc, "private") {
+			t.Errorf("%s got Cache-Control %q, want private", tt.key, cc)
+		}
+		if vary := resp.Header.Get("Vary"); vary != "Authorization" {
+			t.Errorf("%s got Vary %q, want Authorization", tt.key, vary)
+		}
+	}
+}
+
+func TestFeedsUseTaskTimestamps(t *testing.T) {
+	created, updated := testNow.Add(-2*time.Hour), testNow.Add(-time.Hour)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Untimed"},
+		{ID: 2, Title: "Created", CreatedAt: &created},
+		{ID: 3, Title: "Edited", CreatedAt: &created, UpdatedAt: &updated},
+	})
+
+	rec := httptest.NewRecorder()
+	JSONFeedHandler(rec, httptest.NewRequest(http.MethodGet, "http://example.com/feed.json", nil))
+	var feed JSONFeed
+	if err := json.Unmarshal(rec.Body.Bytes(), &feed); err != nil || len(feed.Items) != 3 {
+		t.Fatalf("got %d items, %v; want 3", len(feed.Items), err)
+	}
+	want := [][2]string{
+		{"2024-05-01T10:00:00Z", "2024-05-01T11:00:00Z"},
+		{"2024-05-01T10:00:00Z", ""},
+		{"", ""},
+	}
+	for i, item := range feed.Items {
+		if got := [2]string{item.DatePublished, item.DateModified}; got != want[i] {
+			t.Errorf("item %s published and modified %v, want %v", item.ID, got, want[i])
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	AtomFeedHandler(rec, httptest.NewRequest(http.MethodGet, "http://example.com/feed.atom", nil))
+	var atom AtomFeed
+	if err := xml.Unmarshal(rec.Body.Bytes(), &atom); err != nil || len(atom.Entries) != 3 {
+		t.Fatalf("got %d entries, %v; want 3", len(atom.Entries), err)
+	}
+	for i, wantUpdated := range []string{"2024-05-01T11:00:00Z", "2024-05-01T10:00:00Z", atom.Updated} {
+		if got := atom.Entries[i].Updated; got != wantUpdated {
+			t.Errorf("entry %s updated %s, want %s", atom.Entries[i].ID, got, wantUpdated)
+		}
+	}
+}
+
+func TestFeedShowsOldTaskCompletedRecently(t *testing.T) {
+	created := testNow.Add(-48 * time.Hour)
+	list := []Task{{ID: 1, Title: "Old chore", Completed: true, CreatedAt: &created, UpdatedAt: &testNow}}
+	for i := 0; i < feedLimit; i++ {
+		newer := created.Add(time.Duration(i+1) * time.Minute)
+		list = append(list, Task{ID: i + 2, Title: fmt.Sprintf("Newer %d", i), CreatedAt: &newer})
+	}
+	useTasks(t, list)
+
+	rec := httptest.NewRecorder()
+	JSONFeedHandler(rec, httptest.NewRequest(http.MethodGet, "http://example.com/feed.json", nil))
+	var feed JSONFeed
+	if err := json.Unmarshal(rec.Body.Bytes(), &feed); err != nil || len(feed.Items) != feedLimit {
+		t.Fatalf("got %d items, %v; want %d", len(feed.Items), err, feedLimit)
+	}
+	if feed.Items[0].Title != "Old chore" {
+		t.Errorf("first item = %q, want the task completed most recently", feed.Items[0].Title)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrStorageBusy is returned when another process holds the lock on a tasks file for longer than storageLockTimeout
+var ErrStorageBusy = errors.New("storage busy: tasks file is locked by another process")
+
+// storageLockTimeout bounds how long load/save wait for another process to release the tasks file
+var storageLockTimeout = 5 * time.Second
+
+// storageLockRetryInterval is the pause between attempts to take a contended lock
+const storageLockRetryInterval = 50 * time.Millisecond
+
+// heldLocks holds the paths of the lock files this process holds for its whole life. flock would have
+// the process wait on itself, so acquireFileLock returns at once for these.
+var (
+	heldLocksMu sync.Mutex
+	heldLocks   = map[string]bool{}
+)
+
+// holdFileLock takes the lock on filename+".lock" for as long as the process uses filename, failing
+// with ErrStorageBusy at once if another process has it. Until the returned function releases it,
+// acquireFileLock on filename succeeds without waiting.
+func holdFileLock(filename string) (func(), error) {
+	unlock, err := lockFile(filename, 0)
+	if err != nil {
+		return nil, err
+	}
+	lockPath := filename + ".lock"
+	heldLocksMu.Lock()
+	heldLocks[lockPath] = true
+	heldLocksMu.Unlock()
+	return func() {
+		heldLocksMu.Lock()
+		delete(heldLocks, lockPath)
+		heldLocksMu.Unlock()
+		unlock()
+	}, nil
+}
+
+// acquireFileLock takes an exclusive advisory lock on filename+".lock", retrying until storageLockTimeout.
+// The returned function releases the lock and removes the lock file.
+func acquireFileLock(filename string) (func(), error) {
+	heldLocksMu.Lock()
+	held := heldLocks[filename+".lock"]
+	heldLocksMu.Unlock()
+	if held {
+		return func() {}, nil
+	}
+	return lockFile(filename, storageLockTimeout)
+}
+
+// lockFile is acquireFileLock, retrying until timeout
+func lockFile(filename string, timeout time.Duration) (func(), error) {
+	lockPath := filename + ".lock"
+	deadline := time.Now().Add(timeout)
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if locked {
+			// The previous holder may have removed the lock file between our open and lock,
+			// in which case we hold a lock on an orphaned inode and must start over
+			if current, err := os.Stat(lockPath); err == nil {
+				if held, err := file.Stat(); err == nil && os.SameFile(current, held) {
+					return func() {
+						os.Remove(lockPath)
+						unlockFile(file)
+						file.Close()
+					}, nil
+				}
+			}
+			unlockFile(file)
+			file.Close()
+			continue
+		}
+		file.Close()
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w (%s)", ErrStorageBusy, lockPath)
+		}
+		time.Sleep(storageLockRetryInterval)
+	}
+}
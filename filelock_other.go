@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// tryLockFile always succeeds on platforms without flock; the lock file still marks intent
+func tryLockFile(file *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
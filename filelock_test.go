@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveTasksWhileLocked(t *testing.T) {
+	tempFile := "test_locked_tasks.json"
+	defer os.Remove(tempFile)
+
+	originalTimeout := storageLockTimeout
+	storageLockTimeout = 100 * time.Millisecond
+	defer func() {
+		storageLockTimeout = originalTimeout
+	}()
+
+	// Simulate another process holding the lock
+	unlock, err := acquireFileLock(tempFile)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	useTasks(t, []Task{{ID: 1, Title: "Task 1", Completed: false}})
+	err = SaveTasksToFile(tempFile)
+	if !errors.Is(err, ErrStorageBusy) {
+		t.Errorf("Expected ErrStorageBusy while locked, got %v", err)
+	}
+
+	// Once released, the save goes through and the lock file is cleaned up
+	unlock()
+	if err := SaveTasksToFile(tempFile); err != nil {
+		t.Fatalf("Failed to save tasks after unlock: %v", err)
+	}
+	if _, err := os.Stat(tempFile + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected lock file to be removed, got %v", err)
+	}
+}
+
+func TestHoldFileLock(t *testing.T) {
+	tempFile := filepath.Join(t.TempDir(), "tasks.json")
+	release, err := holdFileLock(tempFile)
+	if err != nil {
+		t.Fatalf("Failed to hold lock: %v", err)
+	}
+
+	// A second server is turned away at once
+	start := time.Now()
+	if _, err := holdFileLock(tempFile); !errors.Is(err, ErrStorageBusy) || time.Since(start) >= storageLockTimeout {
+		t.Errorf("Expected ErrStorageBusy without waiting, got %v after %v", err, time.Since(start))
+	}
+
+	// The holder's own loads and saves don't wait on its lock
+	useTasks(t, []Task{{ID: 1, Title: "Task 1"}})
+	if err := SaveTasksToFile(tempFile); err != nil {
+		t.Fatalf("Failed to save while holding the lock: %v", err)
+	}
+	if _, err := os.Stat(tempFile + ".lock"); err != nil {
+		t.Errorf("Expected the lock file to outlive the save, got %v", err)
+	}
+
+	release()
+	if _, err := os.Stat(tempFile + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected lock file to be removed on release, got %v", err)
+	}
+	if release, err := holdFileLock(tempFile); err != nil {
+		t.Errorf("Failed to hold the lock once released: %v", err)
+	} else {
+		release()
+	}
+}
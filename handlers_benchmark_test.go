@@ -9,10 +9,10 @@ import (
 
 func BenchmarkGetTasks(b *testing.B) {
 	// Prepare initial tasks
-	tasks = []Task{
+	useTasks(b, []Task{
 		{ID: 1, Title: "Task 1", Completed: false},
 		{ID: 2, Title: "Task 2", Completed: true},
-	}
+	})
 
 	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
 	rec := httptest.NewRecorder()
@@ -38,9 +38,9 @@ func BenchmarkPostTasks(b *testing.B) {
 
 func BenchmarkPutTasks(b *testing.B) {
 	// Prepare initial task
-	tasks = []Task{
+	useTasks(b, []Task{
 		{ID: 1, Title: "Initial Task", Completed: false},
-	}
+	})
 
 	payload := `{"title":"Updated Task","completed":true}`
 
@@ -57,10 +57,11 @@ func BenchmarkPutTasks(b *testing.B) {
 func BenchmarkDeleteTasks(b *testing.B) {
 	payload := Task{ID: 1, Title: "Task to Delete", Completed: false}
 
+	useTasks(b, nil)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		// Reset tasks before each delete request
-		tasks = []Task{payload}
+		taskStore = newMemoryStore([]Task{payload})
 
 		req := httptest.NewRequest(http.MethodDelete, "/tasks/1", nil)
 		rec := httptest.NewRecorder()
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 type invalidURLTestCase struct {
@@ -131,42 +132,45 @@ var getTests = []getTasksTestCase{
 	},
 }
 
+// testNow is the fake time the create and update tests run at, so timestamps are predictable
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
 var postTests = []postTaskTestCase{
 	{
 		name:       "First Valid Task",
 		payload:    `{"title": "New Task 1", "completed": false}`,
 		wantStatus: http.StatusCreated,
-		wantBody:   `{"id":124,"title":"New Task 1","completed":false}`,
+		wantBody:   `{"id":124,"title":"New Task 1","completed":false,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`,
 	},
 	{
 		name:       "Second Valid Task",
 		payload:    `{"title": "New Task 2", "completed": false}`,
 		wantStatus: http.StatusCreated,
-		wantBody:   `{"id":125,"title":"New Task 2","completed":false}`,
+		wantBody:   `{"id":125,"title":"New Task 2","completed":false,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`,
 	},
 	{
 		name:       "Third Valid Task",
 		payload:    `{"title": "New Task 3", "completed": false}`,
 		wantStatus: http.StatusCreated,
-		wantBody:   `{"id":126,"title":"New Task 3","completed":false}`,
+		wantBody:   `{"id":126,"title":"New Task 3","completed":false,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`,
 	},
 	{
 		name:       "Task Without status",
 		payload:    `{"title": "Task without status"}`,
 		wantStatus: http.StatusCreated,
-		wantBody:   `{"id":127,"title":"Task without status","completed":false}`,
+		wantBody:   `{"id":127,"title":"Task without status","completed":false,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`,
 	},
 	{
 		name:       "Task without title",
 		payload:    `{"title": "", "completed": false}`,
 		wantStatus: http.StatusBadRequest,
-		wantBody:   `{"error":"Task title cannot be empty"}`,
+		wantBody:   `{"error":"Task title cannot be empty","code":"validation_failed"}`,
 	},
 	{
 		name:       "Empty task",
 		payload:    `{}`,
 		wantStatus: http.StatusBadRequest,
-		wantBody:   `{"error":"Task title cannot be empty"}`,
+		wantBody:   `{"error":"Task title cannot be empty","code":"validation_failed"}`,
 	},
 }
 
@@ -176,42 +180,42 @@ var putTests = []putTaskTestCase{
 		id:         "1",
 		payload:    `{"title": "Updated Task", "completed": true}`,
 		wantStatus: http.StatusOK,
-		wantBody:   `{"id":1,"title":"Updated Task","completed":true}`,
+		wantBody:   `{"id":1,"title":"Updated Task","completed":true,"updated_at":"2024-05-01T12:00:00Z","version":1}`,
 	},
 	{
 		name:       "Task Not Found",
 		id:         "999",
 		payload:    `{"title": "Nonexistent Task", "completed": false}`,
 		wantStatus: http.StatusNotFound,
-		wantBody:   `{"error":"No task found with ID 999"}`,
+		wantBody:   `{"error":"No task found with ID 999","code":"task_not_found"}`,
 	},
 	{
 		name:       "Invalid JSON",
 		id:         "1",
 		payload:    `{"title": "Missing Comma"`,
 		wantStatus: http.StatusBadRequest,
-		wantBody:   `{"error":"Invalid JSON format"}`,
+		wantBody:   `{"error":"Invalid JSON format","code":"invalid_request"}`,
 	},
 	{
 		name:       "Empty Title",
 		id:         "1",
 		payload:    `{"title": "", "completed": false}`,
 		wantStatus: http.StatusBadRequest,
-		wantBody:   `{"error":"Task title cannot be empty"}`,
+		wantBody:   `{"error":"Task title cannot be empty","code":"validation_failed"}`,
 	},
 	{
 		name:       "Invalid ID",
 		id:         "abc",
 		payload:    `{"title": "Invalid ID", "completed": true}`,
 		wantStatus: http.StatusBadRequest,
-		wantBody:   `{"error":"Invalid Task ID"}`,
+		wantBody:   `{"error":"Invalid Task ID","code":"invalid_request"}`,
 	},
 	{
 		name:       "Missing Status (Defaults to False)",
 		id:         "1",
 		payload:    `{"title": "Task Without Status"}`,
 		wantStatus: http.StatusOK,
-		wantBody:   `{"id":1,"title":"Task Without Status","completed":false}`,
+		wantBody:   `{"id":1,"title":"Task Without Status","completed":false,"updated_at":"2024-05-01T12:00:00Z","version":2}`,
 	},
 }
 
@@ -226,13 +230,43 @@ var deleteTests = []deleteTaskTestCase{
 		name:       "Task Not Found",
 		id:         "999",
 		wantStatus: http.StatusNotFound,
-		wantBody:   `{"error":"No task found with ID 999"}`,
+		wantBody:   `{"error":"No task found with ID 999","code":"task_not_found"}`,
 	},
 	{
 		name:       "Invalid ID",
 		id:         "abc",
 		wantStatus: http.StatusBadRequest,
-		wantBody:   `{"error":"Invalid Task ID"}`,
+		wantBody:   `{"error":"Invalid Task ID","code":"invalid_request"}`,
+	},
+	{
+		name:       "Negative ID",
+		id:         "-1",
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Task ID must be a positive integer","code":"invalid_request"}`,
+	},
+	{
+		name:       "Zero ID",
+		id:         "0",
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Task ID must be a positive integer","code":"invalid_request"}`,
+	},
+	{
+		name:       "ID Above int32",
+		id:         "2147483648",
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Task ID out of range","code":"invalid_request"}`,
+	},
+	{
+		name:       "ID Overflowing int64",
+		id:         "99999999999999999999",
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Task ID out of range","code":"invalid_request"}`,
+	},
+	{
+		name:       "Non-canonical ID",
+		id:         "001",
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Invalid Task ID","code":"invalid_request"}`,
 	},
 }
 
@@ -273,23 +307,17 @@ func TestUnsupportedMethods(t *testing.T) {
 }
 
 func TestGetTasks(t *testing.T) {
-	tasks = []Task{
+	useTasks(t, []Task{
 		{ID: 1, Title: "Clean the carpet", Completed: false},
 		{ID: 2, Title: "Pick up the groceries", Completed: false},
 		{ID: 123, Title: "Doctor's appointment", Completed: true},
-	}
+	})
 
 	for _, tt := range getTests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.name == "No Tasks Available" {
-				// Backup the original tasks slice
-				originalTasks := tasks
-				defer func() {
-					tasks = originalTasks // Restore tasks after the test
-				}()
-
-				// Simulate no tasks
-				tasks = []Task{}
+				// Simulate no tasks; the original tasks come back when the subtest ends
+				useTasks(t, []Task{})
 			}
 
 			// Simulate GET request
@@ -314,8 +342,8 @@ func TestGetTasks(t *testing.T) {
 }
 
 func TestCreateTask(t *testing.T) {
-	lastID = 123 // Initialize lastID correctly
-	tasks = []Task{}
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{}).lastID = 123 // Initialize lastID correctly
 
 	for _, tt := range postTests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -344,11 +372,12 @@ func TestCreateTask(t *testing.T) {
 }
 
 func TestUpdateTask(t *testing.T) {
-	tasks = []Task{
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
 		{ID: 1, Title: "Clean the carpet", Completed: false},
 		{ID: 2, Title: "Pick up the groceries", Completed: false},
 		{ID: 123, Title: "Doctor's appointment", Completed: true},
-	}
+	})
 
 	for _, tt := range putTests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -374,11 +403,11 @@ func TestUpdateTask(t *testing.T) {
 }
 
 func TestDeleteTask(t *testing.T) {
-	tasks = []Task{
+	useTasks(t, []Task{
 		{ID: 1, Title: "Clean the carpet", Completed: false},
 		{ID: 2, Title: "Pick up the groceries", Completed: false},
 		{ID: 123, Title: "Doctor's appointment", Completed: true},
-	}
+	})
 
 	for _, tt := range deleteTests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -405,8 +434,7 @@ func TestDeleteTask(t *testing.T) {
 
 func TestTasksConcurrency(t *testing.T) {
 	// Start with an empty tasks slice
-	tasks = []Task{}
-	lastID = 123 // Start IDs from 124
+	useTasks(t, []Task{}).lastID = 123 // Start IDs from 124
 
 	var wg sync.WaitGroup
 	const numGoroutines = 100
@@ -434,6 +462,7 @@ func TestTasksConcurrency(t *testing.T) {
 	wg.Wait()
 
 	// Validate the number of tasks
+	tasks := taskStore.List()
 	if len(tasks) != numGoroutines {
 		t.Errorf("Expected %d tasks, got %d", numGoroutines, len(tasks))
 	}
@@ -453,19 +482,20 @@ func TestLoadAndSaveTasks(t *testing.T) {
 	defer os.Remove(tempFile)
 
 	// Test saving tasks
-	tasks = []Task{
+	useTasks(t, []Task{
 		{ID: 1, Title: "Task 1", Completed: false},
 		{ID: 2, Title: "Task 2", Completed: true},
-	}
+	})
 	if err := SaveTasksToFile(tempFile); err != nil {
 		t.Fatalf("Failed to save tasks: %v", err)
 	}
 
 	// Clear the current tasks and test loading from the file
-	tasks = nil
+	useTasks(t, nil)
 	if err := LoadTasksFromFile(tempFile); err != nil {
 		t.Fatalf("Failed to load tasks: %v", err)
 	}
+	tasks := taskStore.List()
 
 	// Validate loaded tasks
 	if len(tasks) != 2 {
@@ -478,10 +508,20 @@ func TestLoadAndSaveTasks(t *testing.T) {
 
 func TestLoadTasksFromNonExistentFile(t *testing.T) {
 	nonExistentFile := "nonexistent_tasks.json"
+	useTasks(t, []Task{{ID: 1, Title: "Replaced"}})
 
-	err := LoadTasksFromFile(nonExistentFile)
-	if err == nil {
-		t.Errorf("Expected an error when loading from a non-existent file, got nil")
+	// A first run starts with no tasks
+	if err := LoadTasksFromFile(nonExistentFile); err != nil {
+		t.Fatalf("Expected a missing file to load as an empty store, got %v", err)
+	}
+	if tasks := taskStore.List(); len(tasks) != 0 {
+		t.Errorf("Expected no tasks, got %d", len(tasks))
+	}
+
+	requireTasksFile = true
+	defer func() { requireTasksFile = false }()
+	if err := LoadTasksFromFile(nonExistentFile); err == nil {
+		t.Errorf("Expected an error when loading from a non-existent file with --require-existing-file, got nil")
 	}
 }
 
@@ -492,17 +532,17 @@ func TestSaveTasksCreatesBackup(t *testing.T) {
 	defer os.Remove(backupFile)
 
 	// Initial save
-	tasks = []Task{
+	useTasks(t, []Task{
 		{ID: 1, Title: "Original Task", Completed: false},
-	}
+	})
 	if err := SaveTasksToFile(tempFile); err != nil {
 		t.Fatalf("Failed to save tasks: %v", err)
 	}
 
 	// Modify tasks and save again
-	tasks = []Task{
+	useTasks(t, []Task{
 		{ID: 2, Title: "Updated Task", Completed: true},
-	}
+	})
 	if err := SaveTasksToFile(tempFile); err != nil {
 		t.Fatalf("Failed to save tasks again: %v", err)
 	}
@@ -525,3 +565,61 @@ func TestSaveTasksToInvalidLocation(t *testing.T) {
 		t.Errorf("Expected an error when saving to an invalid location, got nil")
 	}
 }
+
+func TestTaskTimestamps(t *testing.T) {
+	fake := useFakeClock(t, testNow)
+	useTasks(t, []Task{})
+
+	// Timestamps sent by the client are ignored
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title": "Stamped", "created_at": "2000-01-01T00:00:00Z"}`))
+	Tasks(httptest.NewRecorder(), req)
+
+	fake.Advance(time.Hour)
+	req = httptest.NewRequest(http.MethodPut, "/tasks/1", strings.NewReader(`{"title": "Stamped again", "updated_at": "2000-01-01T00:00:00Z"}`))
+	Tasks(httptest.NewRecorder(), req)
+
+	task, err := taskStore.Get(1)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if task.CreatedAt == nil || !task.CreatedAt.Equal(testNow) {
+		t.Errorf("created_at = %v, want %v", task.CreatedAt, testNow)
+	}
+	if want := testNow.Add(time.Hour); task.UpdatedAt == nil || !task.UpdatedAt.Equal(want) {
+		t.Errorf("updated_at = %v, want %v", task.UpdatedAt, want)
+	}
+}
+
+func TestTaskRoutes(t *testing.T) {
+	useTasks(t, []Task{{ID: 1, Title: "Routed"}})
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Trailing Slash", http.MethodPut, "/tasks/1/", http.StatusOK, ""},
+		{"Collection Trailing Slash", http.MethodGet, "/tasks/", http.StatusOK, ""},
+		{"GET on a task", http.MethodGet, "/tasks/1", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed","code":"method_not_allowed"}`},
+		{"POST on a task", http.MethodPost, "/tasks/1", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed","code":"method_not_allowed"}`},
+		{"PATCH on /tasks", http.MethodPatch, "/tasks", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed","code":"method_not_allowed"}`},
+		{"GET on lock", http.MethodGet, "/tasks/1/lock", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed","code":"method_not_allowed"}`},
+		{"DELETE on checklist", http.MethodDelete, "/tasks/1/checklist", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed","code":"method_not_allowed"}`},
+		{"Unknown Subresource", http.MethodGet, "/tasks/1/comments", http.StatusNotFound, `{"error":"Not Found","code":"not_found"}`},
+		{"Too Deep", http.MethodPut, "/tasks/1/checklist/2/3", http.StatusNotFound, `{"error":"Not Found","code":"not_found"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(`{"title": "Routed"}`))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); tt.wantBody != "" && got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// defaultSecurityHeaders are sent on every public response. The API serves only JSON and feeds,
+// so the content security policy forbids everything; a UI needing more overrides it.
+var defaultSecurityHeaders = map[string]string{
+	"X-Content-Type-Options":  "nosniff",
+	"X-Frame-Options":         "DENY",
+	"Referrer-Policy":         "no-referrer",
+	"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+}
+
+// hstsHeader is added only to responses served over HTTPS, since browsers ignore it on plain HTTP
+const hstsHeader = "max-age=31536000; includeSubDomains"
+
+// parseSecurityHeaders merges the JSON object in raw over the defaults. An empty value drops a header,
+// and "Strict-Transport-Security" replaces the HSTS policy.
+func parseSecurityHeaders(raw string) (map[string]string, error) {
+	headers := map[string]string{"Strict-Transport-Security": hstsHeader}
+	for name, value := range defaultSecurityHeaders {
+		headers[name] = value
+	}
+	if raw == "" {
+		return headers, nil
+	}
+	var overrides map[string]string
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		return nil, fmt.Errorf("must be a JSON object of header names to values: %w", err)
+	}
+	for name, value := range overrides {
+		name = http.CanonicalHeaderKey(name)
+		if value == "" {
+			delete(headers, name)
+		} else {
+			headers[name] = value
+		}
+	}
+	return headers, nil
+}
+
+// SecurityHeaders sets headers on every response before next runs, so handlers can still override them
+func SecurityHeaders(next http.Handler, headers map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			if name == "Strict-Transport-Security" && !isHTTPS(r) {
+				continue
+			}
+			w.Header().Set(name, value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
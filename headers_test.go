@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	headers, err := parseSecurityHeaders(`{"content-security-policy": "default-src 'self'", "X-Frame-Options": ""}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), headers)
+
+	tests := []struct {
+		name string            // Test case name
+		tls  bool              // Whether the request arrives over TLS
+		want map[string]string // Expected headers, "" meaning absent
+	}{
+		{"Plain HTTP", false, map[string]string{
+			"X-Content-Type-Options":    "nosniff",
+			"Referrer-Policy":           "no-referrer",
+			"Content-Security-Policy":   "default-src 'self'",
+			"X-Frame-Options":           "",
+			"Strict-Transport-Security": "",
+		}},
+		{"HTTPS Adds HSTS", true, map[string]string{
+			"Strict-Transport-Security": hstsHeader,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			for name, want := range tt.want {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSecurityHeadersRejectsGarbage(t *testing.T) {
+	if _, err := parseSecurityHeaders(`X-Frame-Options: DENY`); err == nil {
+		t.Error("expected an error for a non-JSON value")
+	}
+}
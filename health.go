@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// HealthReport is the ?verbose=true health response: an overall status plus one entry per subsystem,
+// so a monitor can alert on the part that is struggling rather than on a bare 503
+type HealthReport struct {
+	// Status is "ok", "degraded" while the storage circuit breaker is open, or "shutting_down" once
+	// shutdown has started
+	Status     string                     `json:"status"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+	Components map[string]ComponentHealth `json:"components"`
+}
+
+// ComponentHealth describes one subsystem. Status is "ok", "lagging", "backlogged", or "failing";
+// only the fields that apply to the component are set.
+type ComponentHealth struct {
+	Status string `json:"status"`
+	// Storage
+	ConsecutiveFailures *int       `json:"consecutive_failures,omitempty"`
+	Retries             *int       `json:"retries,omitempty"`
+	LastSave            *time.Time `json:"last_save,omitempty"`
+	SaveSeconds         *float64   `json:"save_seconds,omitempty"`
+	UnsavedChanges      *int       `json:"unsaved_changes,omitempty"`
+	// Autosave: how far behind its schedule the next save is
+	IntervalSeconds *float64 `json:"interval_seconds,omitempty"`
+	LagSeconds      *float64 `json:"lag_seconds,omitempty"`
+	// Queues
+	Queued   *int `json:"queued,omitempty"`
+	Capacity *int `json:"capacity,omitempty"`
+	InFlight *int `json:"in_flight,omitempty"`
+	Shed     *int `json:"shed,omitempty"`
+}
+
+// storeHealth is a snapshot of a memoryStore's persistence
+type storeHealth struct {
+	lastSaved        time.Time
+	saveLatency      time.Duration
+	unsaved          int
+	dirtySince       time.Time
+	autosaveInterval time.Duration
+}
+
+func (s *memoryStore) health() storeHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return storeHealth{lastSaved: s.lastSaved, saveLatency: s.saveLatency, unsaved: s.unsaved, dirtySince: s.dirtySince, autosaveInterval: s.autosaveInterval}
+}
+
+// buildHealthReport gathers component health as of now. Error messages are left out: the public
+// health check is unauthenticated, and /metrics and the logs carry the details.
+func buildHealthReport(store TaskStore, now time.Time) HealthReport {
+	report := HealthReport{Status: "ok", CheckedAt: now, Components: map[string]ComponentHealth{}}
+
+	breaker := storageBreaker.stats()
+	storage := ComponentHealth{Status: "ok", ConsecutiveFailures: &breaker.ConsecutiveFailures, Retries: &breaker.Retries}
+	if breaker.Degraded {
+		report.Status, storage.Status = "degraded", "failing"
+	}
+	if mem, ok := store.(*memoryStore); ok {
+		h := mem.health()
+		storage.UnsavedChanges = &h.unsaved
+		if !h.lastSaved.IsZero() {
+			seconds := h.saveLatency.Seconds()
+			storage.LastSave, storage.SaveSeconds = &h.lastSaved, &seconds
+		}
+		if h.autosaveInterval > 0 {
+			// Lag is how long the oldest unsaved change has waited past the autosave interval
+			interval, lag := h.autosaveInterval.Seconds(), 0.0
+			if overdue := now.Sub(h.dirtySince) - h.autosaveInterval; h.unsaved > 0 && overdue > 0 {
+				lag = overdue.Seconds()
+			}
+			autosave := ComponentHealth{Status: "ok", IntervalSeconds: &interval, LagSeconds: &lag}
+			if lag > interval {
+				autosave.Status = "lagging"
+			}
+			report.Components["autosave"] = autosave
+		}
+	}
+	report.Components["storage"] = storage
+	if shuttingDown.Load() {
+		report.Status = "shutting_down"
+	}
+
+	if linkTitles != nil {
+		queued, capacity := len(linkTitles), cap(linkTitles)
+		titles := ComponentHealth{Status: "ok", Queued: &queued, Capacity: &capacity}
+		if queued == capacity {
+			titles.Status = "backlogged"
+		}
+		report.Components["link_titles"] = titles
+	}
+	if concurrency != nil {
+		stats := concurrency.stats()
+		capacity := cap(concurrency.queue)
+		requests := ComponentHealth{Status: "ok", InFlight: &stats.InFlight, Queued: &stats.Queued, Capacity: &capacity, Shed: &stats.Shed}
+		if capacity > 0 && stats.Queued == capacity {
+			requests.Status = "backlogged"
+		}
+		report.Components["requests"] = requests
+	}
+	return report
+}
+
+// writeHealthReport serves the verbose health report, with a 503 when the overall status isn't ok
+func writeHealthReport(w http.ResponseWriter, store TaskStore) {
+	report := buildHealthReport(store, clock.Now())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthVerbose(t *testing.T) {
+	resetStorageBreaker(t)
+	fake := useFakeClock(t, testNow)
+	store, _ := openTaskStore(&countingBackend{}, false)
+	store.autosaveInterval = 30 * time.Second
+	handler := WithTaskStore(http.HandlerFunc(Health), store)
+
+	get := func() (int, HealthReport) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/tasks/health?verbose=true", nil))
+		var report HealthReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatalf("invalid report %s: %v", rec.Body, err)
+		}
+		return rec.Code, report
+	}
+
+	// Changes waiting well past the autosave interval mark autosave as lagging
+	store.Create(Task{Title: "Unsaved"})
+	fake.Advance(90 * time.Second)
+	status, report := get()
+	if status != http.StatusOK || report.Status != "ok" {
+		t.Errorf("got %d %q, want 200 ok", status, report.Status)
+	}
+	autosave := report.Components["autosave"]
+	if autosave.Status != "lagging" || autosave.LagSeconds == nil || *autosave.LagSeconds != 60 {
+		t.Errorf("autosave = %+v, want lagging by 60s", autosave)
+	}
+	if storage := report.Components["storage"]; storage.UnsavedChanges == nil || *storage.UnsavedChanges != 1 || storage.LastSave != nil {
+		t.Errorf("storage = %+v, want 1 unsaved change and no save yet", storage)
+	}
+
+	// A save clears the lag and is reported
+	store.Flush()
+	_, report = get()
+	if autosave := report.Components["autosave"]; autosave.Status != "ok" || *autosave.LagSeconds != 0 {
+		t.Errorf("autosave after save = %+v", autosave)
+	}
+	if storage := report.Components["storage"]; storage.LastSave == nil || !storage.LastSave.Equal(clock.Now()) || storage.SaveSeconds == nil {
+		t.Errorf("storage after save = %+v", storage)
+	}
+
+	// An open circuit breaker fails the storage component and the whole check
+	for i := 0; i < storageBreaker.threshold; i++ {
+		withStorageRetry("save", func() error { return errors.New("disk gone") })
+	}
+	status, report = get()
+	if status != http.StatusServiceUnavailable || report.Status != "degraded" || report.Components["storage"].Status != "failing" {
+		t.Errorf("got %d %+v while storage is down", status, report)
+	}
+}
+
+func TestHealthPlainByDefault(t *testing.T) {
+	resetStorageBreaker(t)
+	rec := httptest.NewRecorder()
+	Health(rec, httptest.NewRequest("GET", "/tasks/health?verbose=false", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "OK" {
+		t.Errorf("got %d %q", rec.Code, rec.Body)
+	}
+}
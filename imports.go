@@ -0,0 +1,378 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxImportSize bounds an uploaded import file; it is spooled to disk, not held in memory
+const maxImportSize = 256 << 20
+
+// ImportJob tracks a background import of an uploaded file. Jobs live in memory and are lost on restart.
+type ImportJob struct {
+	ID         int        `json:"id"`
+	Status     string     `json:"status"` // "running", "done", or "failed"
+	Filename   string     `json:"filename"`
+	Rows       int        `json:"rows"`
+	Imported   int        `json:"imported"`
+	Rejected   int        `json:"rejected"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Report     string     `json:"report"`
+
+	results []importResult
+	// owner is the user who started the job; only they can see it
+	owner string
+	// done is closed when the job finishes
+	done chan struct{}
+}
+
+// importResult is one line of a job's report
+type importResult struct {
+	Row    int
+	TaskID int
+	Err    string
+}
+
+var (
+	importJobs   = map[int]*ImportJob{}
+	lastImportID int
+	importMutex  sync.Mutex
+)
+
+// Imports starts an import job from a multipart upload whose "file" part is CSV (a header row naming
+// title, completed, start_date, due_date, priority, and tags columns) or newline-delimited JSON tasks. It responds 202 at once;
+// the job's progress is at /jobs/{id} and its per-row report at /jobs/{id}/report.csv.
+func Imports(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	if r.Method != "POST" {
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	spool, filename, err := spoolImportFile(r)
+	if err != nil {
+		logErrorContext(r.Context(), "Import upload rejected: %v", err)
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	importMutex.Lock()
+	lastImportID++
+	job := &ImportJob{ID: lastImportID, Status: "running", Filename: filename, StartedAt: clock.Now(), owner: userFor(r), done: make(chan struct{})}
+	job.Report = fmt.Sprintf("/jobs/%d/report.csv", job.ID)
+	importJobs[job.ID] = job
+	snapshot := *job
+	importMutex.Unlock()
+
+	store := storeFor(r)
+	go func() {
+		defer os.Remove(spool)
+		err := runImport(job, store, spool, strings.HasSuffix(strings.ToLower(filename), ".csv"))
+		importMutex.Lock()
+		defer importMutex.Unlock()
+		defer close(job.done)
+		now := clock.Now()
+		job.FinishedAt = &now
+		job.Status = "done"
+		if err != nil {
+			job.Status, job.Error = "failed", err.Error()
+			logErrorContext(r.Context(), "Import job %d failed after %d rows: %v", job.ID, job.Rows, err)
+			return
+		}
+		logInfoContext(r.Context(), "Import job %d finished: %d imported, %d rejected", job.ID, job.Imported, job.Rejected)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/jobs/%d", job.ID))
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// spoolImportFile copies the "file" part of a multipart upload to a temporary file, since the
+// job outlives the request. It returns the temporary file's path and the uploaded file name.
+func spoolImportFile(r *http.Request) (string, string, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return "", "", errors.New("Import must be a multipart/form-data upload")
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return "", "", errors.New(`Import upload has no "file" part`)
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("Failed to read import upload: %w", err)
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		spool, err := os.CreateTemp("", "task-import-*")
+		if err != nil {
+			return "", "", err
+		}
+		_, err = io.Copy(spool, part)
+		if closeErr := spool.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(spool.Name())
+			return "", "", fmt.Errorf("Failed to read import upload: %w", err)
+		}
+		return spool.Name(), part.FileName(), nil
+	}
+}
+
+// runImport creates a task for each valid row of the spooled file, recording a result per row.
+// A row that fails validation is rejected without stopping the job; an unreadable file stops it.
+func runImport(job *ImportJob, store TaskStore, spool string, isCSV bool) error {
+	file, err := os.Open(spool)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	next := ndjsonRows(file)
+	if isCSV {
+		if next, err = csvRows(file); err != nil {
+			return err
+		}
+	}
+	for row := 1; ; row++ {
+		task, err := next()
+		if err == io.EOF {
+			return nil
+		}
+		var rowErr *importRowError
+		if err != nil && !errors.As(err, &rowErr) {
+			return err
+		}
+		result := importResult{Row: row}
+		if err == nil {
+			err = importTask(store, &task)
+		}
+		if err != nil {
+			result.Err = err.Error()
+		} else {
+			result.TaskID = task.ID
+		}
+
+		importMutex.Lock()
+		job.Rows++
+		if result.Err != "" {
+			job.Rejected++
+		} else {
+			job.Imported++
+		}
+		job.results = append(job.results, result)
+		importMutex.Unlock()
+	}
+}
+
+// importRowError is a row that couldn't be parsed; the import continues past it
+type importRowError struct {
+	message string
+}
+
+func (e *importRowError) Error() string {
+	return e.message
+}
+
+// ndjsonRows reads one JSON task per non-blank line
+func ndjsonRows(r io.Reader) func() (Task, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	return func() (Task, error) {
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var task Task
+			if err := json.Unmarshal([]byte(line), &task); err != nil {
+				return Task{}, &importRowError{message: "Invalid JSON format"}
+			}
+			return task, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return Task{}, err
+		}
+		return Task{}, io.EOF
+	}
+}
+
+// csvRows reads tasks from CSV whose header row names its columns; unknown columns are ignored
+func csvRows(r io.Reader) (func() (Task, error), error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New(`CSV header has no "title" column`)
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	return func() (Task, error) {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return Task{}, io.EOF
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return Task{}, &importRowError{message: parseErr.Err.Error()}
+		}
+		if err != nil {
+			return Task{}, err
+		}
+		task := Task{Title: field(record, "title"), StartDate: field(record, "start_date"), DueDate: field(record, "due_date"), Priority: field(record, "priority"), Notes: field(record, "notes")}
+		// Tags share one column, separated by semicolons
+		if raw := field(record, "tags"); raw != "" {
+			task.Tags = strings.Split(raw, ";")
+		}
+		if raw := field(record, "completed"); raw != "" {
+			if task.Completed, err = strconv.ParseBool(raw); err != nil {
+				return Task{}, &importRowError{message: "completed must be true or false"}
+			}
+		}
+		return task, nil
+	}, nil
+}
+
+// importTask applies the checks POST /tasks does, then creates the task
+func importTask(store TaskStore, task *Task) error {
+	if err := prepareNewTask(store, task); err != nil {
+		return err
+	}
+	created, err := store.Create(*task)
+	if err != nil {
+		return err
+	}
+	*task = created
+	queueLinkTitles(store, created)
+	return nil
+}
+
+// prepareNewTask normalizes and validates a task to be created in store outside POST /tasks, setting
+// its timestamps. Rule violations are flattened into one error.
+func prepareNewTask(store TaskStore, task *Task) error {
+	task.Title = normalizeText(task.Title)
+	if task.Title == "" {
+		return errors.New("Task title cannot be empty")
+	}
+	if err := validateTaskDates(task); err != nil {
+		return err
+	}
+	if err := validatePriority(task.Priority); err != nil {
+		return err
+	}
+	tags, err := normalizeTags(task.Tags)
+	if err != nil {
+		return err
+	}
+	task.Tags = tags
+	if task.Links, err = normalizeLinks(task.Links); err != nil {
+		return err
+	}
+	if task.Notes, err = normalizeNotes(task.Notes); err != nil {
+		return err
+	}
+	if task.Checklist, err = normalizeChecklist(task.Checklist); err != nil {
+		return err
+	}
+	updateChecklistCompletion(task)
+	if err := validateParent(store.List(), 0, task.ParentID); err != nil {
+		return err
+	}
+	task.Lock, task.Owner = nil, ""
+	now := clock.Now()
+	task.CreatedAt = &now
+	task.touch(now)
+	if err := checkRules(*task, validationRules); err != nil {
+		var violated *ruleError
+		if errors.As(err, &violated) {
+			rules := make([]string, len(violated.violations))
+			for i, v := range violated.violations {
+				rules[i] = v.Rule + ": " + v.Message
+			}
+			return errors.New(strings.Join(rules, "; "))
+		}
+		return err
+	}
+	return nil
+}
+
+// Jobs reports on import jobs.
+//
+//	GET /jobs/{id}              job status and counts
+//	GET /jobs/{id}/report.csv   per-row results: row, status, task_id, error
+func Jobs(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+
+	parts := strings.Split(strings.Trim(path.Clean(r.URL.Path), "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "jobs" || (len(parts) == 3 && parts[2] != "report.csv") {
+		writeJsonError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	if r.Method != "GET" {
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		writeJsonError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	importMutex.Lock()
+	defer importMutex.Unlock()
+	job, ok := importJobs[id]
+	if !ok || job.owner != userFor(r) {
+		logErrorContext(r.Context(), "Job not found with ID %d", id)
+		writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No job found with ID %d", id))
+		return
+	}
+	if len(parts) == 2 {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job)
+		return
+	}
+	if job.Status == "running" {
+		writeJsonError(w, http.StatusConflict, fmt.Sprintf("Job %d is still running", id))
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-report.csv"`, id))
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"row", "status", "task_id", "error"})
+	for _, result := range job.results {
+		record := []string{strconv.Itoa(result.Row), "imported", strconv.Itoa(result.TaskID), ""}
+		if result.Err != "" {
+			record = []string{strconv.Itoa(result.Row), "rejected", "", result.Err}
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// uploadImport posts content as the "file" part of a multipart form and waits for the job to finish
+func uploadImport(t *testing.T, filename, content string) *ImportJob {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	Imports(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+
+	importMutex.Lock()
+	job := importJobs[lastImportID]
+	importMutex.Unlock()
+	<-job.done
+	return job
+}
+
+// jobReport fetches a job's status or report through the Jobs handler
+func jobReport(t *testing.T, url string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Jobs(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestImportCSV(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{{ID: 1, Title: "Existing"}})
+
+	job := uploadImport(t, "tasks.csv", "Title,Completed,Start_Date,Notes\n"+
+		"Water plants,false,,ignored\n"+
+		",true,,\n"+
+		"Renew passport,yes,,\n"+
+		"Book flights,true,2024-06-01,\n")
+
+	if job.Status != "done" || job.Rows != 4 || job.Imported != 2 || job.Rejected != 2 {
+		t.Errorf("got job %+v, want done with 4 rows, 2 imported, 2 rejected", *job)
+	}
+	wantReport := "row,status,task_id,error\n" +
+		"1,imported,2,\n" +
+		"2,rejected,,Task title cannot be empty\n" +
+		"3,rejected,,completed must be true or false\n" +
+		"4,imported,3,"
+	if status, report := jobReport(t, job.Report); status != http.StatusOK || report != wantReport {
+		t.Errorf("got report %d %q, want %q", status, report, wantReport)
+	}
+	if tasks := taskStore.List(); len(tasks) != 3 || tasks[2].Title != "Book flights" || tasks[2].StartDate != "2024-06-01" || !tasks[2].Completed {
+		t.Errorf("got tasks %+v", tasks)
+	}
+}
+
+func TestImportNDJSON(t *testing.T) {
+	useTasks(t, []Task{})
+
+	job := uploadImport(t, "tasks.ndjson", `{"title": "First"}`+"\n\n"+`{"title": "Broken"`+"\n"+`{"title": "Deferred", "start_date": "2024-02-30"}`+"\n")
+
+	wantReport := "row,status,task_id,error\n" +
+		"1,imported,1,\n" +
+		"2,rejected,,Invalid JSON format\n" +
+		"3,rejected,,start_date must be a date in YYYY-MM-DD format"
+	if _, report := jobReport(t, job.Report); report != wantReport {
+		t.Errorf("got report %q, want %q", report, wantReport)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	useTasks(t, []Task{})
+
+	// A CSV without a title column fails the whole job
+	job := uploadImport(t, "tasks.csv", "name\nWater plants\n")
+	if job.Status != "failed" || job.Error != `CSV header has no "title" column` {
+		t.Errorf("got job %+v, want failed for missing title column", *job)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/imports", strings.NewReader(`{"title": "Not a file"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	Imports(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("JSON body got status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if status, _ := jobReport(t, "/jobs/999"); status != http.StatusNotFound {
+		t.Errorf("unknown job got status %d, want %d", status, http.StatusNotFound)
+	}
+	if status, _ := jobReport(t, "/jobs/1/report.txt"); status != http.StatusNotFound {
+		t.Errorf("unknown report got status %d, want %d", status, http.StatusNotFound)
+	}
+}
+
+func TestImportChecksChecklistsAndParents(t *testing.T) {
+	useUsers(t, "alice", "bob")
+	original := taskStore
+	taskStore = newMemoryStore([]Task{{ID: 1, Title: "Alice's", Owner: "alice"}, {ID: 2, Title: "Bob's", Owner: "bob"}})
+	t.Cleanup(func() { taskStore = original })
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "tasks.ndjson")
+	part.Write([]byte(`{"title": "Blank item", "checklist": [{"text": " "}]}` + "\n" +
+		`{"title": "Duplicate items", "checklist": [{"id": 1, "text": "A"}, {"id": 1, "text": "B"}]}` + "\n" +
+		`{"title": "Made-up completion", "checklist": [{"text": "A", "done": true}, {"text": "B"}], "checklist_completion": 100}` + "\n" +
+		`{"title": "Missing parent", "parent_id": 99}` + "\n" +
+		`{"title": "Bob's parent", "parent_id": 2}` + "\n" +
+		`{"title": "Own parent", "parent_id": 1}` + "\n"))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	Imports(rec, withUser(req, "alice"))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("got status %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+	importMutex.Lock()
+	job := importJobs[lastImportID]
+	importMutex.Unlock()
+	<-job.done
+
+	wantReport := "row,status,task_id,error\n" +
+		"1,rejected,,Checklist item text cannot be empty\n" +
+		"2,rejected,,Duplicate checklist item ID 1\n" +
+		"3,imported,3,\n" +
+		"4,rejected,,parent_id 99 does not exist\n" +
+		"5,rejected,,parent_id 2 does not exist\n" +
+		"6,imported,4,"
+	rec = httptest.NewRecorder()
+	Jobs(rec, withUser(httptest.NewRequest(http.MethodGet, job.Report, nil), "alice"))
+	if report := strings.TrimSpace(rec.Body.String()); report != wantReport {
+		t.Errorf("got report %q, want %q", report, wantReport)
+	}
+	if task, _ := taskStore.Get(3); task.ChecklistCompletion == nil || *task.ChecklistCompletion != 50 {
+		t.Errorf("imported checklist completion = %v, want 50", task.ChecklistCompletion)
+	}
+}
@@ -1,99 +1,40 @@
 package main
 
 import (
-	"bytes"
 	"net/http"
-	"net/http/httptest"
-	"strings"
+	"regexp"
 	"testing"
 )
 
-func TestIntegrationWorkFlow(t *testing.T) {
-	// Reset global state for testing
-	tasks = []Task{}
-	lastID = 0
-
-	// Step 1: Test POST /tasks
-	reqBody := bytes.NewBuffer([]byte(`{"title":"Test Task","completed":false}`))
-	req := httptest.NewRequest(http.MethodPost, "/tasks", reqBody)
-	req.Header.Set("Content-Type", "application/json")
-
-	rec := httptest.NewRecorder()
-	Tasks(rec, req)
-
-	if rec.Code != http.StatusCreated {
-		t.Fatalf("expected status %d, got %d", http.StatusCreated, rec.Code)
-	}
-
-	expected := `{"id":1,"title":"Test Task","completed":false}`
-	actual := strings.TrimSpace(rec.Body.String())
-	if actual != expected {
-		t.Fatalf("expected body %s, got %s", expected, actual)
-	}
-
-	// Step 2: Test GET /tasks
-	req = httptest.NewRequest(http.MethodGet, "/tasks", nil)
-	rec = httptest.NewRecorder()
-
-	Tasks(rec, req)
-
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
-	}
-
-	expected = `[{"id":1,"title":"Test Task","completed":false}]`
-	actual = strings.TrimSpace(rec.Body.String())
-	if actual != expected {
-		t.Fatalf("expected body %s, got %s", expected, actual)
-	}
-
-	// Step 3: Test PUT /tasks/1
-	reqBody = bytes.NewBuffer([]byte(`{"title":"Updated Task","completed":true}`))
-	req = httptest.NewRequest(http.MethodPut, "/tasks/1", reqBody)
-	req.Header.Set("Content-Type", "application/json")
-
-	rec = httptest.NewRecorder()
-	Tasks(rec, req)
+// timestampPattern matches server-set timestamps, which a parallel test can't pin with a fake clock
+var timestampPattern = regexp.MustCompile(`"(created|updated)_at":"[^"]+"`)
 
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
-	}
-
-	expected = `{"id":1,"title":"Updated Task","completed":true}`
-	actual = strings.TrimSpace(rec.Body.String())
-	if actual != expected {
-		t.Fatalf("expected body %s, got %s", expected, actual)
-	}
-
-	// Step 4: Test DELETE /tasks/1
-	req = httptest.NewRequest(http.MethodDelete, "/tasks/1", nil)
-	rec = httptest.NewRecorder()
-
-	Tasks(rec, req)
-
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
-	}
-
-	expected = `{"message":"Task deleted","status":"success"}`
-	actual = strings.TrimSpace(rec.Body.String())
-	if actual != expected {
-		t.Fatalf("expected body %s, got %s", expected, actual)
-	}
-
-	// Step 5: Confirm task is deleted via GET /tasks
-	req = httptest.NewRequest(http.MethodGet, "/tasks", nil)
-	rec = httptest.NewRecorder()
-
-	Tasks(rec, req)
-
-	if rec.Code != http.StatusOK {
-		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
-	}
-
-	expected = `[]`
-	actual = strings.TrimSpace(rec.Body.String())
-	if actual != expected {
-		t.Fatalf("expected body %s, got %s", expected, actual)
+func TestIntegrationWorkFlow(t *testing.T) {
+	t.Parallel()
+	_, client := StartTestServer(t)
+
+	steps := []struct {
+		name       string // Step name
+		method     string // HTTP method
+		path       string // Request path
+		payload    string // The JSON payload sent in the request
+		wantStatus int    // Expected HTTP status code
+		wantBody   string // Expected response body
+	}{
+		{"Create", http.MethodPost, "/tasks", `{"title":"Test Task","completed":false}`, http.StatusCreated, `{"id":1,"title":"Test Task","completed":false,"created_at":"*","updated_at":"*","version":1}`},
+		{"List", http.MethodGet, "/tasks", "", http.StatusOK, `[{"id":1,"title":"Test Task","completed":false,"created_at":"*","updated_at":"*","version":1}]`},
+		{"Update", http.MethodPut, "/tasks/1", `{"title":"Updated Task","completed":true}`, http.StatusOK, `{"id":1,"title":"Updated Task","completed":true,"created_at":"*","updated_at":"*","version":2}`},
+		{"Delete", http.MethodDelete, "/tasks/1", "", http.StatusOK, `{"message":"Task deleted","status":"success"}`},
+		{"Confirm Deleted", http.MethodGet, "/tasks", "", http.StatusOK, `[]`},
+	}
+	for _, step := range steps {
+		status, body := client.Do(step.method, step.path, step.payload)
+		body = timestampPattern.ReplaceAllString(body, `"${1}_at":"*"`)
+		if status != step.wantStatus {
+			t.Fatalf("%s: expected status %d, got %d", step.name, step.wantStatus, status)
+		}
+		if body != step.wantBody {
+			t.Fatalf("%s: expected body %s, got %s", step.name, step.wantBody, body)
+		}
 	}
 }
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// journalEntry is one line of the journal: a task written, tasks deleted, or the list reordered
+type journalEntry struct {
+	Op   string `json:"op"` // "put", "delete", or "order"
+	Task *Task  `json:"task,omitempty"`
+	IDs  []int  `json:"ids,omitempty"`
+}
+
+func putEntry(task Task) journalEntry {
+	return journalEntry{Op: "put", Task: &task}
+}
+
+// taskJournal is an append-only log of mutations since the backend was last saved. Every entry is
+// synced to disk before the change it records is applied, so a hard crash loses nothing: on startup the
+// journal is replayed over the last save. A successful save truncates it.
+type taskJournal struct {
+	filename string
+	file     *os.File
+}
+
+// openJournal reads the entries already in filename and opens it for appending, creating it if needed.
+// A torn last line, left by a crash mid-write, is dropped; corruption anywhere else is an error.
+func openJournal(filename string) (*taskJournal, []journalEntry, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	var entries []journalEntry
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if i == len(lines)-1 {
+				logError("Dropping torn last entry of %s: %v", filename, err)
+				break
+			}
+			return nil, nil, fmt.Errorf("%s line %d: %w", filename, i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Rewrite without the torn line so new entries don't follow half a line
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if err := rewriteJournal(file, entries); err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+	return &taskJournal{filename: filename, file: file}, entries, nil
+}
+
+func rewriteJournal(file *os.File, entries []journalEntry) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	j := &taskJournal{file: file}
+	return j.append(entries...)
+}
+
+// append writes entries as one write and syncs them to disk
+func (j *taskJournal) append(entries ...journalEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return timeStorage("journal_append", j.filename, func() error {
+		if _, err := j.file.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		return j.file.Sync()
+	})
+}
+
+// truncate empties the journal once everything in it has been saved to the backend
+func (j *taskJournal) truncate() error {
+	return timeStorage("journal_truncate", j.filename, func() error {
+		if err := j.file.Truncate(0); err != nil {
+			return err
+		}
+		return j.file.Sync()
+	})
+}
+
+func (j *taskJournal) Close() error {
+	return j.file.Close()
+}
+
+// applyJournalEntry replays entry onto list. Replaying is idempotent: if a save landed but the journal
+// wasn't truncated before a crash, replaying it over the saved list gives the same list.
+func applyJournalEntry(list []Task, entry journalEntry) ([]Task, error) {
+	switch entry.Op {
+	case "put":
+		if entry.Task == nil {
+			return nil, errors.New("put entry without a task")
+		}
+		for i := range list {
+			if list[i].ID == entry.Task.ID {
+				list[i] = *entry.Task
+				return list, nil
+			}
+		}
+		return append(list, *entry.Task), nil
+	case "delete":
+		deleted := make(map[int]bool, len(entry.IDs))
+		for _, id := range entry.IDs {
+			deleted[id] = true
+		}
+		kept := list[:0]
+		for _, t := range list {
+			if !deleted[t.ID] {
+				kept = append(kept, t)
+			}
+		}
+		return kept, nil
+	case "order":
+		// Tasks the entry names come first in its order; any it doesn't know about keep their relative order after
+		byID := make(map[int]Task, len(list))
+		for _, t := range list {
+			byID[t.ID] = t
+		}
+		ordered := make([]Task, 0, len(list))
+		placed := make(map[int]bool, len(entry.IDs))
+		for _, id := range entry.IDs {
+			if t, ok := byID[id]; ok && !placed[id] {
+				ordered = append(ordered, t)
+				placed[id] = true
+			}
+		}
+		for _, t := range list {
+			if !placed[t.ID] {
+				ordered = append(ordered, t)
+			}
+		}
+		return ordered, nil
+	}
+	return nil, fmt.Errorf("unknown journal op %q", entry.Op)
+}
+
+// attachJournal replays the journal at filename over the loaded list and records every later change in it
+func (s *memoryStore) attachJournal(filename string) error {
+	journal, entries, err := openJournal(filename)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, entry := range entries {
+		if s.tasks, err = applyJournalEntry(s.tasks, entry); err != nil {
+			journal.Close()
+			return fmt.Errorf("%s entry %d: %w", filename, i+1, err)
+		}
+	}
+	for _, t := range s.tasks {
+		if t.ID > s.lastID {
+			s.lastID = t.ID
+		}
+	}
+	s.journal = journal
+	if len(entries) > 0 {
+		logInfo("Replayed %d journal entries from %s", len(entries), filename)
+		s.modified = clock.Now()
+		s.generation++
+		s.listJSON = nil
+		s.unsaved, s.dirtySince = len(entries), s.modified
+	}
+	return nil
+}
+
+// record journals entries before the change they describe is applied; a transaction's copy keeps them
+// for WithTx to journal at commit. Callers must hold s.mu.
+func (s *memoryStore) record(entries ...journalEntry) error {
+	if s.inTx {
+		s.txEntries = append(s.txEntries, entries...)
+		return nil
+	}
+	if s.journal == nil {
+		return nil
+	}
+	if err := s.journal.append(entries...); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// openJournaledStore opens the tasks file in dir with its journal, as main does with JOURNAL=true
+func openJournaledStore(t *testing.T, dir string) *memoryStore {
+	t.Helper()
+	filename := filepath.Join(dir, "tasks.json")
+	store, err := openTaskStore(fileBackend{filename: filename}, false)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	if err := store.attachJournal(filename + ".journal"); err != nil {
+		t.Fatalf("attach journal: %v", err)
+	}
+	return store
+}
+
+func TestJournalReplaysAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	store := openJournaledStore(t, dir)
+	for _, title := range []string{"One", "Two", "Three", "Four"} {
+		store.Create(Task{Title: title})
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	// Changes after the last save live only in the journal
+	store.Update(1, func(task *Task) error { task.Completed = true; return nil })
+	store.Delete(2)
+	store.Create(Task{Title: "Five"})
+	store.Reorder([]int{5, 4, 3, 1})
+	generation := store.Generation()
+	store.DeleteWhere(generation, func(task Task) bool { return task.Title == "Three" })
+	want := store.List()
+	store.Close() // crash: no Flush
+
+	reopened := openJournaledStore(t, dir)
+	defer reopened.Close()
+	if got := reopened.List(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("after replay got %+v, want %+v", got, want)
+	}
+	// IDs continue after the replayed tasks
+	if created, _ := reopened.Create(Task{Title: "Six"}); created.ID != 6 {
+		t.Errorf("next ID = %d, want 6", created.ID)
+	}
+}
+
+func TestJournalCompactedBySave(t *testing.T) {
+	dir := t.TempDir()
+	store := openJournaledStore(t, dir)
+	defer store.Close()
+	store.Create(Task{Title: "Saved"})
+	journal := filepath.Join(dir, "tasks.json.journal")
+	if info, err := os.Stat(journal); err != nil || info.Size() == 0 {
+		t.Fatalf("journal is empty before save (err %v)", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if info, _ := os.Stat(journal); info.Size() != 0 {
+		t.Errorf("journal is %d bytes after save, want 0", info.Size())
+	}
+}
+
+func TestJournalTornLastLine(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "tasks.json.journal")
+	content := `{"op":"put","task":{"id":1,"title":"Whole"}}` + "\n" + `{"op":"put","task":{"id":2,"ti`
+	if err := os.WriteFile(journal, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store := openJournaledStore(t, dir)
+	store.Create(Task{Title: "After"})
+	store.Close()
+
+	reopened := openJournaledStore(t, dir)
+	defer reopened.Close()
+	var titles []string
+	for _, task := range reopened.List() {
+		titles = append(titles, task.Title)
+	}
+	if want := []string{"Whole", "After"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("got titles %q, want %q", titles, want)
+	}
+}
+
+func TestApplyJournalEntry(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry journalEntry
+		want  []int
+	}{
+		{"put replaces", putEntry(Task{ID: 2, Title: "New"}), []int{1, 2, 3}},
+		{"put appends", putEntry(Task{ID: 4}), []int{1, 2, 3, 4}},
+		{"delete", journalEntry{Op: "delete", IDs: []int{1, 3, 9}}, []int{2}},
+		{"order", journalEntry{Op: "order", IDs: []int{3, 1}}, []int{3, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := applyJournalEntry([]Task{{ID: 1}, {ID: 2}, {ID: 3}}, tt.entry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, task := range list {
+				ids = append(ids, task.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+		})
+	}
+	if _, err := applyJournalEntry(nil, journalEntry{Op: "rename"}); err == nil {
+		t.Error("unknown op was accepted")
+	}
+}
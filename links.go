@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// Bounds on a task's links
+const (
+	maxLinks           = 50
+	maxLinkURLLength   = 2048
+	maxLinkTitleLength = 200
+)
+
+// TaskLink is a reference attached to a task, such as a design doc, pull request, or ticket
+type TaskLink struct {
+	// Title describes the link; when left empty it may be filled in from the page's title
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+// normalizeLinks trims titles and checks that every URL is an absolute http or https URL
+func normalizeLinks(links []TaskLink) ([]TaskLink, error) {
+	if len(links) == 0 {
+		return nil, nil
+	}
+	if len(links) > maxLinks {
+		return nil, fmt.Errorf("a task can have at most %d links", maxLinks)
+	}
+	normalized := make([]TaskLink, len(links))
+	for i, link := range links {
+		link.Title = strings.TrimSpace(normalizeText(link.Title))
+		link.URL = strings.TrimSpace(link.URL)
+		if utf8.RuneCountInString(link.Title) > maxLinkTitleLength {
+			return nil, fmt.Errorf("links[%d].title must be at most %d characters", i, maxLinkTitleLength)
+		}
+		if len(link.URL) > maxLinkURLLength {
+			return nil, fmt.Errorf("links[%d].url must be at most %d bytes", i, maxLinkURLLength)
+		}
+		u, err := url.Parse(link.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("links[%d].url must be an absolute http or https URL", i)
+		}
+		normalized[i] = link
+	}
+	return normalized, nil
+}
+
+// linkTitleJob asks the link title worker to fill in the untitled links of one task
+type linkTitleJob struct {
+	store  TaskStore
+	taskID int
+}
+
+// linkTitles queues tasks whose links need titles; it is nil unless LINK_TITLES is enabled
+var linkTitles chan linkTitleJob
+
+// linkTitleClient fetches pages for their titles. It refuses private and loopback addresses so
+// task links can't be used to probe the server's own network.
+var linkTitleClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 5 * time.Second, Control: refusePrivateAddresses}).DialContext,
+	},
+}
+
+// maxLinkPageBytes bounds how much of a page is read looking for its title
+const maxLinkPageBytes = 512 << 10
+
+// queueLinkTitles schedules title fetching for task if any of its links is untitled. The queue
+// never blocks a request; when it is full the titles are simply left empty.
+func queueLinkTitles(store TaskStore, task Task) {
+	if linkTitles == nil {
+		return
+	}
+	for _, link := range task.Links {
+		if link.Title == "" {
+			select {
+			case linkTitles <- linkTitleJob{store: store, taskID: task.ID}:
+			default:
+				logError("Link title queue full, skipping task %d", task.ID)
+			}
+			return
+		}
+	}
+}
+
+// runLinkTitles fetches titles for queued tasks until stop is closed
+func runLinkTitles(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case job := <-linkTitles:
+			fillLinkTitles(job)
+		}
+	}
+}
+
+// fillLinkTitles fetches a title for each untitled link of the job's task and stores the ones found.
+// A link edited or titled while its page was being fetched keeps the newer value.
+func fillLinkTitles(job linkTitleJob) {
+	task, err := job.store.Get(job.taskID)
+	if err != nil {
+		return
+	}
+	titles := map[string]string{}
+	for _, link := range task.Links {
+		if link.Title != "" || titles[link.URL] != "" {
+			continue
+		}
+		title, err := fetchLinkTitle(link.URL)
+		if err != nil {
+			logInfo("No title for link %s on task %d: %v", link.URL, job.taskID, err)
+			continue
+		}
+		titles[link.URL] = title
+	}
+	if len(titles) == 0 {
+		return
+	}
+	_, err = job.store.Update(job.taskID, func(t *Task) error {
+		for i, link := range t.Links {
+			if link.Title == "" && titles[link.URL] != "" {
+				t.Links[i].Title = titles[link.URL]
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrTaskNotFound) {
+		logError("Failed to store link titles for task %d: %v", job.taskID, err)
+	}
+}
+
+// fetchLinkTitle returns the Open Graph title of the HTML page at rawURL, or its <title>
+func fetchLinkTitle(rawURL string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := linkTitleClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+		return "", fmt.Errorf("content type %q is not HTML", contentType)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxLinkPageBytes))
+	if err != nil {
+		return "", err
+	}
+	title := pageTitle(string(page))
+	if title == "" {
+		return "", errors.New("page has no title")
+	}
+	return title, nil
+}
+
+var (
+	metaTagPattern     = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	ogTitlePattern     = regexp.MustCompile(`(?is)\sproperty\s*=\s*["']og:title["']`)
+	contentAttrPattern = regexp.MustCompile(`(?is)\scontent\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	titleTagPattern    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+)
+
+// pageTitle extracts an HTML page's og:title, falling back to its <title>, as plain collapsed text
+func pageTitle(page string) string {
+	title := ""
+	for _, tag := range metaTagPattern.FindAllString(page, -1) {
+		if ogTitlePattern.MatchString(tag) {
+			if m := contentAttrPattern.FindStringSubmatch(tag); m != nil {
+				title = m[1] + m[2]
+				break
+			}
+		}
+	}
+	if strings.TrimSpace(title) == "" {
+		if m := titleTagPattern.FindStringSubmatch(page); m != nil {
+			title = m[1]
+		}
+	}
+	title = normalizeText(strings.Join(strings.Fields(html.UnescapeString(title)), " "))
+	if utf8.RuneCountInString(title) > maxLinkTitleLength {
+		title = string([]rune(title)[:maxLinkTitleLength])
+	}
+	return title
+}
+
+// refusePrivateAddresses is a net.Dialer Control hook rejecting loopback, private, and link-local addresses.
+// It runs after name resolution, so a public name pointing at an internal address is refused too.
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to fetch non-public address %s", host)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTaskLinks(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{{ID: 1, Title: "Ship it", Links: []TaskLink{{Title: "Spec", URL: "https://example.com/spec"}}}})
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		payload    string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Create With Links", "POST", "/tasks", `{"title": "Review", "links": [{"title": " PR ", "url": "https://github.com/o/r/pull/1"}, {"url": "http://example.com"}]}`, http.StatusCreated,
+			`{"id":2,"title":"Review","completed":false,"links":[{"title":"PR","url":"https://github.com/o/r/pull/1"},{"url":"http://example.com"}],"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Relative URL", "POST", "/tasks", `{"title": "Review", "links": [{"url": "/docs"}]}`, http.StatusBadRequest, `{"error":"links[0].url must be an absolute http or https URL","code":"validation_failed"}`},
+		{"Script URL", "POST", "/tasks", `{"title": "Review", "links": [{"url": "https://ok.example"}, {"url": "javascript:alert(1)"}]}`, http.StatusBadRequest, `{"error":"links[1].url must be an absolute http or https URL","code":"validation_failed"}`},
+		{"Update Keeps Links", "PUT", "/tasks/1", `{"title": "Ship it now"}`, http.StatusOK,
+			`{"id":1,"title":"Ship it now","completed":false,"links":[{"title":"Spec","url":"https://example.com/spec"}],"updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Update Clears Links", "PUT", "/tasks/1", `{"title": "Ship it now", "links": null}`, http.StatusOK,
+			`{"id":1,"title":"Ship it now","completed":false,"updated_at":"2024-05-01T12:00:00Z","version":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestPageTitle(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"Open Graph", `<head><title>Site</title><meta content="The &amp; Article" property="og:title"></head>`, "The & Article"},
+		{"Title Tag", "<html><head><title>\n  Release   notes\n</title></head>", "Release notes"},
+		{"Empty Open Graph", `<meta property='og:title' content=''><title>Fallback</title>`, "Fallback"},
+		{"No Title", `<p>hello</p>`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageTitle(tt.page); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillLinkTitles(t *testing.T) {
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<title>Design doc</title>`))
+	}))
+	defer page.Close()
+
+	// The default client refuses the test server's loopback address
+	if _, err := fetchLinkTitle(page.URL); err == nil || !strings.Contains(err.Error(), "refusing to fetch non-public address") {
+		t.Fatalf("got error %v, want loopback refused", err)
+	}
+
+	original := linkTitleClient
+	linkTitleClient = page.Client()
+	t.Cleanup(func() { linkTitleClient = original })
+	store := newMemoryStore([]Task{{ID: 1, Title: "Read", Links: []TaskLink{{URL: page.URL}, {Title: "Kept", URL: page.URL + "/other"}}}})
+	fillLinkTitles(linkTitleJob{store: store, taskID: 1})
+
+	task, _ := store.Get(1)
+	want := []TaskLink{{Title: "Design doc", URL: page.URL}, {Title: "Kept", URL: page.URL + "/other"}}
+	if len(task.Links) != 2 || task.Links[0] != want[0] || task.Links[1] != want[1] {
+		t.Errorf("got links %+v, want %+v", task.Links, want)
+	}
+}
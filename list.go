@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/url"
+	"time"
+)
+
+// taskListQuery holds the filtering, ordering, and projection options of GET /tasks
+type taskListQuery struct {
+	Search    string     // ?q= substring match on titles
+	Completed *bool      // ?completed= true or false, nil for both
+	Available *bool      // ?available= true for tasks whose start date has arrived, false for deferred ones
+	Overdue   *bool      // ?overdue= true for open tasks past their due date, false for the rest
+	DueFrom   *time.Time // ?due_from= keeps tasks due at or after it
+	DueBefore *time.Time // ?due_before= keeps tasks due before it
+	Priority  string     // ?priority= keeps tasks of one priority, "" for all
+	Tags      []string   // ?tag= keeps tasks carrying every given tag
+	Sort      string     // ?sort= "title" or "priority", or "" for the stored order
+	Fields    []string   // ?fields= sparse fieldset, nil for all fields
+	Page      *pageQuery // ?limit=, ?offset=, ?after_id= select one page, nil for the whole list
+}
+
+// parseTaskListQuery reads GET /tasks query parameters, rejecting values it doesn't understand
+func parseTaskListQuery(values url.Values) (taskListQuery, error) {
+	params := newQueryParams(values)
+	query := taskListQuery{
+		Search:    params.String("q", ""),
+		Completed: params.Bool("completed"),
+		Available: params.Bool("available"),
+		Overdue:   params.Bool("overdue"),
+		DueFrom:   params.Time("due_from"),
+		DueBefore: params.Time("due_before"),
+		Priority:  params.Enum("priority", "", taskPriorities...),
+		Sort:      params.Enum("sort", "", "title", "priority"),
+		Page:      parsePageQuery(params),
+	}
+	if tags := params.Strings("tag"); tags != nil {
+		normalized, err := normalizeTags(tags)
+		params.Check(err)
+		query.Tags = normalized
+	}
+	if raw := params.String("fields", ""); raw != "" {
+		fields, err := parseFieldsParam(raw)
+		params.Check(err)
+		query.Fields = fields
+	}
+	return query, params.Err()
+}
+
+// selectsSubset reports whether the query filters or reorders tasks, so the cached full list can't be used
+func (q taskListQuery) selectsSubset() bool {
+	return q.filters() || q.Sort != ""
+}
+
+// filters reports whether the query narrows the list, as opposed to only ordering or paging it
+func (q taskListQuery) filters() bool {
+	return q.Search != "" || q.Completed != nil || q.Available != nil || q.Overdue != nil || q.DueFrom != nil || q.DueBefore != nil ||
+		q.Priority != "" || q.Tags != nil
+}
+
+// matcher returns a predicate for the tasks the query's filters keep, evaluated as of now
+func (q taskListQuery) matcher(now time.Time) func(Task) bool {
+	var matchesTitle func(string) bool
+	if q.Search != "" {
+		matchesTitle = titleMatcher(q.Search)
+	}
+	return func(t Task) bool {
+		switch {
+		case matchesTitle != nil && !matchesTitle(t.Title):
+			return false
+		case q.Completed != nil && t.Completed != *q.Completed:
+			return false
+		case q.Available != nil && t.isAvailable(now) != *q.Available:
+			return false
+		case q.Overdue != nil && t.isOverdue(now) != *q.Overdue:
+			return false
+		case q.Priority != "" && t.Priority != q.Priority:
+			return false
+		case (q.DueFrom != nil || q.DueBefore != nil) && !t.isDueBetween(q.DueFrom, q.DueBefore):
+			return false
+		}
+		return hasAllTags(t, q.Tags)
+	}
+}
+
+// selectTasks returns the tasks matching the query in the requested order. The input is never modified.
+func selectTasks(list []Task, q taskListQuery) []Task {
+	selected := make([]Task, 0, len(list))
+	matches := q.matcher(clock.Now())
+	for _, t := range list {
+		if matches(t) {
+			selected = append(selected, t)
+		}
+	}
+	switch q.Sort {
+	case "title":
+		sortTasksByTitle(selected)
+	case "priority":
+		sortTasksByPriority(selected)
+	}
+	return selected
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseListenAddrs splits a comma-separated list of listen addresses such as "127.0.0.1:8000,[::1]:8000".
+// An empty list listens on defaultAddr, which with no host means every interface, IPv4 and IPv6.
+func parseListenAddrs(raw, defaultAddr string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return []string{defaultAddr}, nil
+	}
+	var addrs []string
+	for _, addr := range strings.Split(raw, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("invalid listen address %q: %w", addr, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// serveAll starts every server in the background. The returned channel reports the first listener
+// that fails for a reason other than a graceful shutdown.
+func serveAll(servers []*http.Server) <-chan error {
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			logInfo("Listening on %s", srv.Addr)
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				errs <- fmt.Errorf("listen on %s: %w", srv.Addr, err)
+			}
+		}(srv)
+	}
+	return errs
+}
+
+// shutdownAll gracefully stops every server, returning the first error encountered
+func shutdownAll(ctx context.Context, servers []*http.Server) error {
+	var first error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil && first == nil {
+			first = fmt.Errorf("shutdown %s: %w", srv.Addr, err)
+		}
+	}
+	return first
+}
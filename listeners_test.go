@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseListenAddrs(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{"Default dual-stack", "", []string{":8000"}, false},
+		{"Single IPv4", "127.0.0.1:9000", []string{"127.0.0.1:9000"}, false},
+		{"IPv4 and IPv6", "127.0.0.1:8000, [::1]:8000", []string{"127.0.0.1:8000", "[::1]:8000"}, false},
+		{"Trailing comma ignored", "[::]:8000,", []string{"[::]:8000"}, false},
+		{"Missing port", "127.0.0.1", nil, true},
+		{"Unbracketed IPv6", "::1:8000", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseListenAddrs(tt.raw, ":8000")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseListenAddrs(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseListenAddrs(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
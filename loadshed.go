@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// concurrencyLimiter admits a fixed number of requests at once. Up to maxQueued more wait for a slot,
+// for at most queueTimeout; anything beyond that is shed.
+type concurrencyLimiter struct {
+	slots        chan struct{}
+	queue        chan struct{}
+	queueTimeout time.Duration
+
+	mu   sync.Mutex
+	shed int
+}
+
+// concurrency is the limiter in front of the public API, nil when MAX_IN_FLIGHT is unset
+var concurrency *concurrencyLimiter
+
+func newConcurrencyLimiter(maxInFlight, maxQueued int, queueTimeout time.Duration) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		slots:        make(chan struct{}, maxInFlight),
+		queue:        make(chan struct{}, maxQueued),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// limiterStats is a snapshot of a concurrencyLimiter for /metrics
+type limiterStats struct {
+	InFlight, Queued, Shed int
+}
+
+func (l *concurrencyLimiter) stats() limiterStats {
+	if l == nil {
+		return limiterStats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return limiterStats{InFlight: len(l.slots), Queued: len(l.queue), Shed: l.shed}
+}
+
+// acquire takes a slot, waiting in the queue if there is room there. It reports false if the request
+// should be shed: the queue was full, the wait timed out, or the client went away.
+func (l *concurrencyLimiter) acquire(r *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return false
+	}
+	defer func() { <-l.queue }()
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// LimitConcurrency sheds requests beyond the limiter's capacity with a 503 and Retry-After, so that under
+// overload clients back off instead of every request getting slower. Health checks and event streams are
+// never shed.
+func LimitConcurrency(next http.Handler, limiter *concurrencyLimiter) http.Handler {
+	retryAfter := strconv.Itoa(max(1, int(limiter.queueTimeout.Round(time.Second).Seconds())))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Event streams are long-lived and mostly idle, so they would hold a slot without using it
+		if r.URL.Path == "/tasks/health" || r.URL.Path == "/events" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !limiter.acquire(r) {
+			limiter.mu.Lock()
+			limiter.shed++
+			limiter.mu.Unlock()
+			logErrorContext(r.Context(), "Shedding %s %s: too many requests in flight", r.Method, r.URL.Path)
+			w.Header().Set("Retry-After", retryAfter)
+			writeJsonError(w, http.StatusServiceUnavailable, "The server is overloaded, retry shortly")
+			return
+		}
+		defer limiter.release()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimitConcurrency(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	limiter := newConcurrencyLimiter(2, 1, 50*time.Millisecond)
+	handler := LimitConcurrency(blocking, limiter)
+
+	// Two requests take the slots and a third waits in the queue
+	var wg sync.WaitGroup
+	codes := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/tasks", nil))
+			codes <- rec.Code
+		}()
+	}
+	<-started
+	<-started
+	for limiter.stats().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// With the queue full a fourth request is shed at once
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/tasks", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+
+	// Health checks are never shed, even at capacity
+	health := LimitConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), limiter)
+	rec = httptest.NewRecorder()
+	health.ServeHTTP(rec, httptest.NewRequest("GET", "/tasks/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("health check got status %d", rec.Code)
+	}
+
+	// The queued request times out while the slots stay busy
+	deadline := time.Now().Add(time.Second)
+	for limiter.stats().Shed < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	close(codes)
+	counts := map[int]int{}
+	for code := range codes {
+		counts[code]++
+	}
+	if counts[http.StatusOK] != 2 || counts[http.StatusServiceUnavailable] != 1 {
+		t.Errorf("got status counts %v, want two 200s and one 503", counts)
+	}
+	if stats := limiter.stats(); stats != (limiterStats{Shed: 2}) {
+		t.Errorf("got stats %+v after all requests finished", stats)
+	}
+}
+
+func TestLimitConcurrencyQueueAdmits(t *testing.T) {
+	limiter := newConcurrencyLimiter(1, 1, time.Second)
+	handler := LimitConcurrency(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}), limiter)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("POST", "/tasks", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("got status %d, want the queued request served", rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+}
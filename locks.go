@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+)
+
+// TaskLock marks a task as being edited so collaborative UIs can warn other users.
+// Locks are advisory: they don't block PUT or DELETE, and they lapse at ExpiresAt.
+type TaskLock struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Lock TTL bounds, in seconds
+const (
+	defaultLockTTL = 300
+	maxLockTTL     = 3600
+)
+
+// lockRequest is the body of POST /tasks/{id}/lock and /unlock
+type lockRequest struct {
+	Owner      string `json:"owner"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+// LockTask handles taking and releasing edit locks.
+//
+//	POST /tasks/{id}/lock     take or renew a lock {"owner": "Alice", "ttl_seconds": 300}
+//	POST /tasks/{id}/unlock   release a lock {"owner": "Alice"}
+//
+// Taking a lock held by someone else, or releasing one you don't hold, fails with 409 Conflict.
+// With authentication on, the lock's owner is the signed-in user and the body's owner is ignored.
+func LockTask(w http.ResponseWriter, r *http.Request) {
+	action := path.Base(r.URL.Path)
+	ID, err := ParseTaskID(r)
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to read request body in lock")
+		writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	var req lockRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		logErrorContext(r.Context(), "Invalid JSON format in lock")
+		writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	req.Owner = normalizeText(req.Owner)
+	if user := userFor(r); user != "" {
+		req.Owner = user
+	}
+	if req.Owner == "" {
+		writeJsonError(w, http.StatusBadRequest, "Lock owner cannot be empty")
+		return
+	}
+	if req.TTLSeconds == 0 {
+		req.TTLSeconds = defaultLockTTL
+	}
+	if req.TTLSeconds < 1 || req.TTLSeconds > maxLockTTL {
+		writeJsonError(w, http.StatusBadRequest, fmt.Sprintf("ttl_seconds must be 1..%d", maxLockTTL))
+		return
+	}
+
+	now := clock.Now()
+	updated, err := storeFor(r).Update(ID, func(task *Task) error {
+		held := task.Lock
+		if held != nil && !now.Before(held.ExpiresAt) {
+			held = nil
+		}
+		if held != nil && held.Owner != req.Owner {
+			return fmt.Errorf("Task %d is locked by %s until %s", ID, held.Owner, held.ExpiresAt.Format(time.RFC3339))
+		}
+		if action == "unlock" && held == nil {
+			return fmt.Errorf("Task %d is not locked", ID)
+		}
+		task.Lock = nil
+		if action == "lock" {
+			task.Lock = &TaskLock{Owner: req.Owner, ExpiresAt: now.Add(time.Duration(req.TTLSeconds) * time.Second)}
+		}
+		return nil
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		logErrorContext(r.Context(), "Task not found with ID %d in lock", ID)
+		writeJsonErrorCode(w, http.StatusNotFound, codeTaskNotFound, fmt.Sprintf("No task found with ID %d", ID))
+		return
+	}
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusConflict, err.Error())
+		return
+	}
+	logInfoContext(r.Context(), "Task %d %sed by %s", ID, action, req.Owner)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// withoutExpiredLock returns t without its lock if the lock lapsed at or before now. Expired locks
+// are hidden as tasks are read rather than removed, so reading never changes the store; the next
+// update of the task drops them for good.
+func withoutExpiredLock(t Task, now time.Time) Task {
+	if t.Lock != nil && !now.Before(t.Lock.ExpiresAt) {
+		t.Lock = nil
+	}
+	return t
+}
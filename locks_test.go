@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type lockTestCase struct {
+	name       string        // Test case name
+	path       string        // Lock or unlock URL
+	payload    string        // The JSON payload sent in the request
+	advance    time.Duration // How far to move the clock before the request
+	wantStatus int           // Expected HTTP status code
+	wantBody   string        // Expected response body
+}
+
+// lockTests run in order against the same task
+var lockTests = []lockTestCase{
+	{
+		name:       "Take Lock",
+		path:       "/tasks/1/lock",
+		payload:    `{"owner": "Alice", "ttl_seconds": 60}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"id":1,"title":"Draft","completed":false,"lock":{"owner":"Alice","expires_at":"2024-05-01T12:01:00Z"}}`,
+	},
+	{
+		name:       "Held By Someone Else",
+		path:       "/tasks/1/lock",
+		payload:    `{"owner": "Bob"}`,
+		wantStatus: http.StatusConflict,
+		wantBody:   `{"error":"Task 1 is locked by Alice until 2024-05-01T12:01:00Z","code":"conflict"}`,
+	},
+	{
+		name:       "Unlock By Someone Else",
+		path:       "/tasks/1/unlock",
+		payload:    `{"owner": "Bob"}`,
+		wantStatus: http.StatusConflict,
+		wantBody:   `{"error":"Task 1 is locked by Alice until 2024-05-01T12:01:00Z","code":"conflict"}`,
+	},
+	{
+		name:       "Expired Lock Can Be Taken",
+		path:       "/tasks/1/lock",
+		payload:    `{"owner": "Bob", "ttl_seconds": 30}`,
+		advance:    time.Minute,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"id":1,"title":"Draft","completed":false,"lock":{"owner":"Bob","expires_at":"2024-05-01T12:01:30Z"}}`,
+	},
+	{
+		name:       "Release Lock",
+		path:       "/tasks/1/unlock",
+		payload:    `{"owner": "Bob"}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"id":1,"title":"Draft","completed":false}`,
+	},
+	{
+		name:       "Unlock When Not Locked",
+		path:       "/tasks/1/unlock",
+		payload:    `{"owner": "Bob"}`,
+		wantStatus: http.StatusConflict,
+		wantBody:   `{"error":"Task 1 is not locked","code":"conflict"}`,
+	},
+	{
+		name:       "Missing Owner",
+		path:       "/tasks/1/lock",
+		payload:    `{}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Lock owner cannot be empty","code":"invalid_request"}`,
+	},
+	{
+		name:       "TTL Too Long",
+		path:       "/tasks/1/lock",
+		payload:    `{"owner": "Alice", "ttl_seconds": 86400}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"ttl_seconds must be 1..3600","code":"invalid_request"}`,
+	},
+	{
+		name:       "Unknown Task",
+		path:       "/tasks/9/lock",
+		payload:    `{"owner": "Alice"}`,
+		wantStatus: http.StatusNotFound,
+		wantBody:   `{"error":"No task found with ID 9","code":"task_not_found"}`,
+	},
+}
+
+func TestTaskLocks(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	useTasks(t, []Task{{ID: 1, Title: "Draft"}})
+
+	for _, tt := range lockTests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.Advance(tt.advance)
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestTaskLocksTakeOwnerFromUser(t *testing.T) {
+	useFakeClock(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	useUsers(t, "alice", "carol")
+	useTasks(t, []Task{
+		{ID: 1, Title: "Draft", Owner: "alice"},
+		{ID: 2, Title: "Review", Owner: "alice", Lock: &TaskLock{Owner: "carol", ExpiresAt: time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)}},
+	})
+	post := func(path, payload string) (int, string) {
+		rec := httptest.NewRecorder()
+		Tasks(rec, withUser(httptest.NewRequest(http.MethodPost, path, strings.NewReader(payload)), "alice"))
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	// The body can't name someone else as the owner, and can leave it out
+	status, body := post("/tasks/1/lock", `{"owner": "carol", "ttl_seconds": 60}`)
+	if want := `{"id":1,"title":"Draft","completed":false,"lock":{"owner":"alice","expires_at":"2024-05-01T12:01:00Z"},"owner":"alice"}`; status != http.StatusOK || body != want {
+		t.Errorf("got %d %s, want 200 %s", status, body, want)
+	}
+	if status, body := post("/tasks/1/unlock", `{}`); status != http.StatusOK {
+		t.Errorf("got %d %s releasing own lock without an owner in the body", status, body)
+	}
+
+	// Nor release someone else's lock by claiming their name
+	if status, body := post("/tasks/2/unlock", `{"owner": "carol"}`); status != http.StatusConflict {
+		t.Errorf("got %d %s releasing carol's lock as alice, want 409", status, body)
+	}
+}
+
+func TestExpiredLocksHiddenFromList(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	store := useTasks(t, []Task{{ID: 1, Title: "Draft", Version: 1, Lock: &TaskLock{Owner: "Alice", ExpiresAt: fake.Now().Add(time.Minute)}}})
+
+	list := func() string {
+		rec := httptest.NewRecorder()
+		Tasks(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+		return strings.TrimSpace(rec.Body.String())
+	}
+	if got := list(); !strings.Contains(got, `"owner":"Alice"`) {
+		t.Fatalf("active lock missing from list: %s", got)
+	}
+	fake.Advance(time.Minute)
+	generation := store.Generation()
+	if got, want := list(), `[{"id":1,"title":"Draft","completed":false,"version":1}]`; got != want {
+		t.Errorf("got %s after expiry, want %s", got, want)
+	}
+	// Hiding the lock is not a change: reading leaves the store, and so a client's If-Match, alone
+	if store.Generation() != generation {
+		t.Error("listing tasks changed the store")
+	}
+	if got, _ := store.Get(1); got.Lock != nil || got.Version != 1 {
+		t.Errorf("Get = %+v, want version 1 without the expired lock", got)
+	}
+}
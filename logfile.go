@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Log file rotation defaults: rotate at 10 MiB and keep five old files
+const (
+	defaultLogMaxSize    = 10 << 20
+	defaultLogMaxBackups = 5
+)
+
+// rotatingFile is an append-only log file that is renamed to path.1 once a write would take it past
+// maxSize, shifting older files up to path.<maxBackups> and deleting the oldest
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile opens path for appending, creating it if needed
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if p would overflow a file that already has something in it, so a
+// single line longer than maxSize still gets written
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			// Keep logging to the current file rather than losing lines
+			fmt.Fprintf(os.Stderr, "Failed to rotate log file %s: %v\n", f.path, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts path.N to path.N+1, path to path.1, and starts an empty path
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if f.maxBackups > 0 {
+		os.Remove(rotatedLogName(f.path, f.maxBackups))
+		for i := f.maxBackups - 1; i >= 1; i-- {
+			os.Rename(rotatedLogName(f.path, i), rotatedLogName(f.path, i+1))
+		}
+		if err := os.Rename(f.path, rotatedLogName(f.path, 1)); err != nil {
+			f.open()
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		f.open()
+		return err
+	}
+	return f.open()
+}
+
+// Close closes the current file
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// rotatedLogName is the name of the nth most recent rotated log: path.1, path.2, ...
+func rotatedLogName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
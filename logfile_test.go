@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	os.WriteFile(path, []byte("old\n"), 0644)
+	f, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// "old\n" plus "line1\n" fits in 10 bytes; each later line starts a new file
+	for _, line := range []string{"line1\n", "line2\n", "line3\n", "line4\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A line longer than the limit is still written whole
+	f.Write([]byte(strings.Repeat("x", 20) + "\n"))
+
+	want := map[string]string{
+		path:                    strings.Repeat("x", 20) + "\n",
+		rotatedLogName(path, 1): "line4\n",
+		rotatedLogName(path, 2): "line3\n",
+	}
+	for name, content := range want {
+		if got, _ := os.ReadFile(name); string(got) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), got, content)
+		}
+	}
+	if _, err := os.Stat(rotatedLogName(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("kept more than 2 rotated files")
+	}
+}
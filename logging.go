@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Log levels, on slog's scale. Trace sits below debug for lines such as request headers.
+const (
+	levelError = slog.LevelError
+	levelWarn  = slog.LevelWarn
+	levelInfo  = slog.LevelInfo
+	levelDebug = slog.LevelDebug // shown with -v
+	levelTrace = slog.Level(-8)  // shown with -vv
+)
+
+// levelNames are the names --log-level and PUT /admin/loglevel accept
+var levelNames = map[string]slog.Level{
+	"trace": levelTrace,
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+// parseLogLevel returns the level called name, with "" meaning info
+func parseLogLevel(name string) (slog.Level, error) {
+	if name == "" {
+		return levelInfo, nil
+	}
+	level, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown log level %q, must be trace, debug, info, warn, or error", name)
+	}
+	return level, nil
+}
+
+// levelName is the name of level, as parseLogLevel accepts it
+func levelName(level slog.Level) string {
+	for name, l := range levelNames {
+		if l == level {
+			return name
+		}
+	}
+	return strings.ToLower(level.String())
+}
+
+var (
+	// logVerbosity is the most detailed level that is written
+	logVerbosity slog.LevelVar
+	// logColor adds ANSI colors to level prefixes of text logs, for interactive terminals only
+	logColor = false
+	// logger writes every log line. Tests and the text format see the same lines, since both handlers
+	// write through the standard logger's writer.
+	logger = slog.New(contextHandler{textHandler{}})
+)
+
+var levelPrefixes = map[slog.Level]string{
+	levelError: "[Error] ",
+	levelWarn:  "[WARN] ",
+	levelInfo:  "[INFO] ",
+	levelDebug: "[DEBUG] ",
+	levelTrace: "[TRACE] ",
+}
+
+var levelColors = map[slog.Level]string{
+	levelError: "\033[31m", // red
+	levelWarn:  "\033[33m", // yellow
+	levelInfo:  "\033[32m", // green
+	levelDebug: "\033[36m", // cyan
+	levelTrace: "\033[90m", // gray
+}
+
+func logWarn(msg string, args ...interface{}) {
+	logAt(context.Background(), levelWarn, msg, args...)
+}
+
+func logInfo(msg string, args ...interface{}) {
+	logAt(context.Background(), levelInfo, msg, args...)
+}
+
+func logError(msg string, args ...interface{}) {
+	logAt(context.Background(), levelError, msg, args...)
+}
+
+func logDebug(msg string, args ...interface{}) {
+	logAt(context.Background(), levelDebug, msg, args...)
+}
+
+func logTrace(msg string, args ...interface{}) {
+	logAt(context.Background(), levelTrace, msg, args...)
+}
+
+// The Context variants tag the line with the request ID ctx carries, if any. Handlers use them so every
+// line a request causes can be found by its ID.
+
+func logWarnContext(ctx context.Context, msg string, args ...interface{}) {
+	logAt(ctx, levelWarn, msg, args...)
+}
+
+func logInfoContext(ctx context.Context, msg string, args ...interface{}) {
+	logAt(ctx, levelInfo, msg, args...)
+}
+
+func logErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	logAt(ctx, levelError, msg, args...)
+}
+
+func logDebugContext(ctx context.Context, msg string, args ...interface{}) {
+	logAt(ctx, levelDebug, msg, args...)
+}
+
+func logTraceContext(ctx context.Context, msg string, args ...interface{}) {
+	logAt(ctx, levelTrace, msg, args...)
+}
+
+// logFatal logs msg as an error and exits, for startup failures
+func logFatal(msg string, args ...interface{}) {
+	logAt(context.Background(), levelError, msg, args...)
+	os.Exit(1)
+}
+
+// logAt formats msg and writes it when level is enabled. The recorded source is the caller of the
+// logInfo/logError helper rather than the helper itself.
+func logAt(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	record := slog.NewRecord(clock.Now(), level, msg, pcs[0])
+	logger.Handler().Handle(ctx, record)
+}
+
+// logWriter writes to wherever the standard logger currently writes, so redirecting it redirects slog too.
+// Writes are serialized so lines from concurrent requests never interleave.
+type logWriter struct{}
+
+var logWriteMutex sync.Mutex
+
+func (logWriter) Write(p []byte) (int, error) {
+	logWriteMutex.Lock()
+	defer logWriteMutex.Unlock()
+	return log.Writer().Write(p)
+}
+
+// verbosityLevel is the level -v or -vv asks for, or level without them
+func verbosityLevel(verbose, veryVerbose bool, level slog.Level) slog.Level {
+	switch {
+	case veryVerbose:
+		return levelTrace
+	case verbose:
+		return levelDebug
+	}
+	return level
+}
+
+// configureLogging sets the level, the format, and color. The format is "json", "text", or "auto",
+// which writes text to a terminal and JSON elsewhere, such as to a file or a log collector. Color is
+// "always", "never", or "auto", which colors only on a terminal and when NO_COLOR is unset so files
+// and pipes stay plain; it only affects text. Call it again after redirecting the log output so
+// "auto" sees the new destination.
+func configureLogging(level slog.Level, format, color string) error {
+	logVerbosity.Set(level)
+	output, _ := log.Writer().(*os.File)
+	terminal := output != nil && isTerminal(output)
+	switch color {
+	case "always":
+		logColor = true
+	case "never":
+		logColor = false
+	case "auto":
+		logColor = os.Getenv("NO_COLOR") == "" && terminal
+	default:
+		return fmt.Errorf("Unknown --color %q, must be auto, always, or never", color)
+	}
+	if format == "auto" {
+		format = "json"
+		if terminal {
+			format = "text"
+		}
+	}
+	switch format {
+	case "json":
+		logger = slog.New(contextHandler{slog.NewJSONHandler(logWriter{}, &slog.HandlerOptions{
+			AddSource:   true,
+			Level:       &logVerbosity,
+			ReplaceAttr: replaceJSONAttr,
+		})})
+	case "text":
+		logger = slog.New(contextHandler{textHandler{}})
+	default:
+		return fmt.Errorf("Unknown --log-format %q, must be auto, json, or text", format)
+	}
+	return nil
+}
+
+// replaceJSONAttr names the trace level and shortens source paths to the file name
+func replaceJSONAttr(groups []string, a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok && level == levelTrace {
+			return slog.String(slog.LevelKey, "TRACE")
+		}
+	case slog.SourceKey:
+		if source, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.String(slog.SourceKey, filepath.Base(source.File)+":"+strconv.Itoa(source.Line))
+		}
+	}
+	return a
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// requestIDKey is the context key of the request ID RequestID assigns
+type requestIDKey struct{}
+
+// requestIDFrom returns the request ID in ctx, or "" outside a request
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID in the context to every line
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// textHandler writes the human-readable format: the standard logger's date and file:line prefix as
+// its flags ask, the level, the message, then any attributes as key=value
+type textHandler struct {
+	attrs []slog.Attr
+}
+
+func (h textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= logVerbosity.Level()
+}
+
+func (h textHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	flags := log.Flags()
+	if flags&(log.Ldate|log.Ltime) != 0 {
+		t := r.Time
+		if flags&log.LUTC != 0 {
+			t = t.UTC()
+		}
+		if flags&log.Ldate != 0 {
+			buf.WriteString(t.Format("2006/01/02 "))
+		}
+		if flags&log.Ltime != 0 {
+			buf.WriteString(t.Format("15:04:05 "))
+		}
+	}
+	if flags&(log.Lshortfile|log.Llongfile) != 0 && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		file := frame.File
+		if flags&log.Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		fmt.Fprintf(&buf, "%s:%d: ", file, frame.Line)
+	}
+	prefix, ok := levelPrefixes[r.Level]
+	if !ok {
+		prefix = "[" + r.Level.String() + "] "
+	}
+	if logColor {
+		if color, ok := levelColors[r.Level]; ok {
+			prefix = color + prefix[:len(prefix)-1] + "\033[0m "
+		}
+	}
+	buf.WriteString(prefix)
+	buf.WriteString(r.Message)
+	writeAttr := func(a slog.Attr) bool {
+		value := a.Value.Resolve().String()
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&buf, " %s=%s", a.Key, value)
+		return true
+	}
+	for _, a := range h.attrs {
+		writeAttr(a)
+	}
+	r.Attrs(writeAttr)
+	buf.WriteByte('\n')
+	_, err := logWriter{}.Write(buf.Bytes())
+	return err
+}
+
+func (h textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return textHandler{attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+// WithGroup is unsupported; the text format has no nesting, so grouped attributes are written flat
+func (h textHandler) WithGroup(string) slog.Handler {
+	return h
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// captureLog redirects the standard logger, which every log line goes through, for the duration of a test
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	originalWriter, originalFlags := log.Writer(), log.Flags()
+	originalVerbosity, originalColor, originalLogger := logVerbosity.Level(), logColor, logger
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(originalWriter)
+		log.SetFlags(originalFlags)
+		logVerbosity.Set(originalVerbosity)
+		logColor, logger = originalColor, originalLogger
+	})
+	return &buf
+}
+
+func TestLogVerbosity(t *testing.T) {
+	tests := []struct {
+		name        string
+		level       string
+		verbose     bool
+		veryVerbose bool
+		want        string
+	}{
+		{"Default", "", false, false, "[Error] e\n[WARN] w\n[INFO] i\n"},
+		{"Verbose", "", true, false, "[Error] e\n[WARN] w\n[INFO] i\n[DEBUG] d\n"},
+		{"Very Verbose", "", false, true, "[Error] e\n[WARN] w\n[INFO] i\n[DEBUG] d\n[TRACE] t\n"},
+		{"Warn Level", "warn", false, false, "[Error] e\n[WARN] w\n"},
+		{"Error Level", "ERROR", false, false, "[Error] e\n"},
+		{"Debug Level", "debug", false, false, "[Error] e\n[WARN] w\n[INFO] i\n[DEBUG] d\n"},
+		{"-vv Beats Level", "error", false, true, "[Error] e\n[WARN] w\n[INFO] i\n[DEBUG] d\n[TRACE] t\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLog(t)
+			level, err := parseLogLevel(tt.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := configureLogging(verbosityLevel(tt.verbose, tt.veryVerbose, level), "text", "never"); err != nil {
+				t.Fatal(err)
+			}
+			logError("e")
+			logWarn("w")
+			logInfo("i")
+			logDebug("d")
+			logTrace("t")
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogColor(t *testing.T) {
+	buf := captureLog(t)
+	if err := configureLogging(levelInfo, "text", "always"); err != nil {
+		t.Fatal(err)
+	}
+	logError("disk full")
+	if got, want := buf.String(), "\033[31m[Error]\033[0m disk full\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if err := configureLogging(levelInfo, "text", "auto"); err != nil {
+		t.Fatal(err)
+	}
+	if logColor {
+		t.Error("auto color enabled although the log output is not a terminal")
+	}
+	if err := configureLogging(levelInfo, "text", "rainbow"); err == nil || !strings.Contains(err.Error(), "rainbow") {
+		t.Errorf("got %v, want an error naming the bad value", err)
+	}
+}
+
+func TestJSONLogs(t *testing.T) {
+	useFakeClock(t, testNow)
+	buf := captureLog(t)
+	if err := configureLogging(levelDebug, "json", "never"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "abc123")
+	logErrorContext(ctx, "Failed to save %q", "tasks.json")
+	logDebug("d")
+	logTrace("t")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want the error and debug lines: %s", len(lines), buf)
+	}
+	var line map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &line); err != nil {
+		t.Fatalf("not JSON: %s", lines[0])
+	}
+	if line["level"] != "ERROR" || line["msg"] != `Failed to save "tasks.json"` || line["request_id"] != "abc123" || line["time"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("got %v", line)
+	}
+	// The source is the line that logged, not the logging helper
+	if source, _ := line["source"].(string); !strings.HasPrefix(source, "logging_test.go:") {
+		t.Errorf("source = %q, want logging_test.go:<line>", line["source"])
+	}
+
+	if err := configureLogging(levelInfo, "yaml", "never"); err == nil || !strings.Contains(err.Error(), "yaml") {
+		t.Errorf("got %v, want an error naming the bad format", err)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	buf := captureLog(t)
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logInfoContext(r.Context(), "Handling %s", r.URL.Path)
+	}))
+
+	tests := []struct {
+		name   string
+		sent   string
+		reused bool
+	}{
+		{"Generated", "", false},
+		{"Client's Reused", "trace-42", true},
+		{"Malformed Replaced", "bad id\nforged line", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest("GET", "/tasks", nil)
+			if tt.sent != "" {
+				req.Header.Set(requestIDHeader, tt.sent)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			id := rec.Header().Get(requestIDHeader)
+			if tt.reused && id != tt.sent || !tt.reused && !regexp.MustCompile(`^[0-9a-f]{16}$`).MatchString(id) {
+				t.Errorf("X-Request-ID = %q", id)
+			}
+			if want := "[INFO] Handling /tasks request_id=" + id + "\n"; buf.String() != want {
+				t.Errorf("log = %q, want %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	buf := captureLog(t)
+	logVerbosity.Set(levelInfo)
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Get", "GET", "", http.StatusOK, `{"level":"info"}`},
+		{"Set", "PUT", `{"level": "debug"}`, http.StatusOK, `{"level":"debug"}`},
+		{"Unknown Level", "PUT", `{"level": "verbose"}`, http.StatusBadRequest, `{"error":"unknown log level \"verbose\", must be trace, debug, info, warn, or error","code":"invalid_request"}`},
+		{"Missing Level", "PUT", `{}`, http.StatusBadRequest, `{"error":"Request body must be {\"level\": \"trace|debug|info|warn|error\"}","code":"invalid_request"}`},
+		{"Still Debug", "GET", "", http.StatusOK, `{"level":"debug"}`},
+		{"Wrong Method", "POST", "", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed","code":"method_not_allowed"}`},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		LogLevel(rec, httptest.NewRequest(tt.method, "/admin/loglevel", strings.NewReader(tt.body)))
+		if rec.Code != tt.wantStatus || strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, rec.Code, rec.Body, tt.wantStatus, tt.wantBody)
+		}
+	}
+	if !strings.Contains(buf.String(), "[WARN] Log level changed from info to debug") {
+		t.Errorf("the change wasn't logged: %s", buf)
+	}
+	logDebug("now visible")
+	if !strings.Contains(buf.String(), "[DEBUG] now visible") {
+		t.Errorf("debug lines aren't written after the change: %s", buf)
+	}
+}
@@ -2,319 +2,968 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"path"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
+
+	"golang.org/x/text/language"
 )
 
 type Task struct {
-	ID        int    `json:"id"`
-	Title     string `json:"title"`
-	Completed bool   `json:"completed"`
+	ID        int             `json:"id"`
+	Title     string          `json:"title"`
+	Completed bool            `json:"completed"`
+	Checklist []ChecklistItem `json:"checklist,omitempty"`
+	// ChecklistCompletion is the percentage of checklist items done, nil when the task has no checklist
+	ChecklistCompletion *int `json:"checklist_completion,omitempty"`
+	// Lock is the current edit lock, nil when nobody is editing the task
+	Lock *TaskLock `json:"lock,omitempty"`
+	// StartDate defers the task until a day (YYYY-MM-DD); until then it is left out of ?available=true
+	StartDate string `json:"start_date,omitempty"`
+	// DueDate is when the task should be done, an RFC 3339 timestamp stored in UTC
+	DueDate string `json:"due_date,omitempty"`
+	// Priority is "low", "medium", or "high", empty when the task has none
+	Priority string `json:"priority,omitempty"`
+	// Tags are lowercase labels such as "work", without duplicates
+	Tags []string `json:"tags,omitempty"`
+	// Links reference related documents, pull requests, or tickets
+	Links []TaskLink `json:"links,omitempty"`
+	// Notes is free-form detail, such as the body of the email a task was created from
+	Notes string `json:"notes,omitempty"`
+	// ParentID makes the task a subtask of another task, 0 for a top-level task
+	ParentID int `json:"parent_id,omitempty"`
+	// Owner is the name of the user the task belongs to, empty when authentication is off
+	Owner string `json:"owner,omitempty"`
+	// CreatedAt and UpdatedAt are set by the server; nil for tasks saved before they were recorded
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// Version counts the task's changes, starting at 1; the store sets it. It is 0 for tasks saved
+	// before versions were recorded, until their next change.
+	Version int `json:"version,omitempty"`
 }
 
-var tasks = []Task{}
-
-var (
-	lastID    int
-	taskMutex sync.Mutex
-)
+// touch records that the task's content changed at now
+func (t *Task) touch(now time.Time) {
+	t.UpdatedAt = &now
+}
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	err := LoadTasksFromFile("tasks.json")
+	defineConfigFlags(flag.CommandLine)
+	flag.BoolVar(&requireTasksFile, "require-existing-file", false, "refuse to start if the tasks file doesn't exist, instead of starting with no tasks")
+	verbose := flag.Bool("v", false, "verbose logging: include debug lines")
+	veryVerbose := flag.Bool("vv", false, "very verbose logging: include debug and trace lines")
+	color := flag.String("color", "auto", "color log level prefixes: auto, always, or never")
+	logFormat := flag.String("log-format", cmp.Or(os.Getenv("LOG_FORMAT"), "auto"), "log format: json, text, or auto for text on a terminal and JSON otherwise (env LOG_FORMAT)")
+	flag.Parse()
+	if err := configureLogging(verbosityLevel(*verbose, *veryVerbose, levelInfo), *logFormat, *color); err != nil {
+		logFatal("%v", err)
+	}
+
+	// Any remaining arguments select a one-shot CLI command instead of the server
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), os.Stdout, os.Stderr))
+	}
+	config, err := resolveConfig(flag.CommandLine, os.Getenv)
 	if err != nil {
-		log.Fatalf("Failed to load tasks from tasks.json: %v", err)
-	}
-	http.Handle("/tasks", LogRequestDuration(ValidateJSON(http.HandlerFunc(Tasks), http.MethodPost, http.MethodPut)))
-	http.Handle("/tasks/", LogRequestDuration(ValidateJSON(http.HandlerFunc(Tasks), http.MethodPost, http.MethodPut)))
-	http.Handle("/long/", LogRequestDuration(http.HandlerFunc(longRunningHandler)))
-	http.HandleFunc("/tasks/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+		logFatal("Invalid configuration: %v", err)
+	}
+	if config.LogFile != "" {
+		maxSize, maxBackups := int64(defaultLogMaxSize), defaultLogMaxBackups
+		if raw := os.Getenv("LOG_MAX_SIZE_MB"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 {
+				logFatal("Invalid LOG_MAX_SIZE_MB %q, must be a positive number", raw)
+			}
+			maxSize = int64(n) << 20
+		}
+		if raw := os.Getenv("LOG_MAX_BACKUPS"); raw != "" {
+			if maxBackups, err = strconv.Atoi(raw); err != nil || maxBackups < 0 {
+				logFatal("Invalid LOG_MAX_BACKUPS %q, must be a whole number", raw)
+			}
+		}
+		logFile, err := openRotatingFile(config.LogFile, maxSize, maxBackups)
+		if err != nil {
+			logFatal("Failed to open log file %s: %v", config.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	// resolveConfig has validated the level. Configuring again also lets an auto format see the log file.
+	logLevel, _ := parseLogLevel(config.LogLevel)
+	if err := configureLogging(verbosityLevel(*verbose, *veryVerbose, logLevel), *logFormat, *color); err != nil {
+		logFatal("%v", err)
+	}
+
+	pendingFile := os.Getenv("DRY_RUN_FILE")
+	if pendingFile == "" {
+		pendingFile = "pending-changes.jsonl"
+	}
+
+	// BACKUP_RETENTION is how many previous versions of the tasks file to keep (.bak, .bak.1, ...)
+	if raw := os.Getenv("BACKUP_RETENTION"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			logFatal("Invalid BACKUP_RETENTION %q, must be a whole number", raw)
+		}
+		backupRetention = n
+	}
+
+	// Hold the tasks file's lock until exit: a second server on the same file would overwrite the
+	// first's saves with its own
+	var releaseTasksFile func()
+	if config.Storage != "sqlite" {
+		if releaseTasksFile, err = holdFileLock(config.TasksFile); err != nil {
+			logFatal("Cannot use %s: %v", config.TasksFile, err)
+		}
+	}
+
+	var backend taskBackend
+	switch config.Storage {
+	case "file":
+		backend = fileBackend{filename: config.TasksFile}
+	case "gob":
+		backend = gobBackend{filename: config.TasksFile}
+	case "sqlite":
+		sqlite, err := openSQLiteBackend(config.DBPath)
+		if err != nil {
+			logFatal("Failed to open SQLite database %s: %v", config.DBPath, err)
+		}
+		backend = sqlite
+	}
+	// SQLite is cheap to update incrementally, so every change is written before the response goes out
+	var store *memoryStore
+	err = withStorageRetry("load tasks", func() (err error) {
+		store, err = openTaskStore(backend, config.Storage == "sqlite")
+		return err
 	})
-	doneChan := make(chan struct{})
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8000"
+	if err != nil {
+		logFatal("Failed to load tasks: %v", err)
 	}
-	logInfo("Starting server on http://localhost:%s", port)
-	srv := &http.Server{
-		Addr:    "0.0.0.0:" + port,
-		Handler: http.DefaultServeMux,
+	// JOURNAL=true appends every change to <tasks file>.journal before applying it, so a crash between
+	// saves loses nothing; the journal is replayed on startup and emptied by each save
+	if journal, _ := strconv.ParseBool(os.Getenv("JOURNAL")); journal && config.Storage != "sqlite" {
+		if err := store.attachJournal(config.TasksFile + ".journal"); err != nil {
+			logFatal("Failed to replay journal: %v", err)
+		}
+	}
+	taskStore = store
+	shutdownHooks.Register(phaseSave, "tasks", 10*time.Second, func(context.Context) error {
+		return store.FlushFinal()
+	})
+	// Close the store only once in-flight requests can no longer write through to it
+	shutdownHooks.Register(phaseClose, "task store", 5*time.Second, func(context.Context) error {
+		return store.Close()
+	})
+	if releaseTasksFile != nil {
+		shutdownHooks.Register(phaseClose, "tasks file lock", time.Second, func(context.Context) error {
+			releaseTasksFile()
+			return nil
+		})
+	}
+	// The counters file gets the same lifetime lock as the tasks file
+	countersFile := config.CountersFile
+	releaseCountersFile, err := holdFileLock(countersFile)
+	if err != nil {
+		logFatal("Cannot use %s: %v", countersFile, err)
+	}
+	if err := withStorageRetry("load counters", func() error {
+		return timeStorage("load_counters", countersFile, func() error { return LoadCountersFromFile(countersFile) })
+	}); err != nil {
+		logFatal("Failed to load counters from %s: %v", countersFile, err)
+	}
+	shutdownHooks.Register(phaseSave, "counters", 5*time.Second, func(context.Context) error {
+		return saveCounters(countersFile, storageRetry{final: true})
+	})
+	shutdownHooks.Register(phaseClose, "counters file lock", time.Second, func(context.Context) error {
+		releaseCountersFile()
+		return nil
+	})
+	// TRUSTED_PROXIES lists the CIDRs whose forwarding headers identify the real client
+	if trustedProxies, err = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		logFatal("Invalid TRUSTED_PROXIES: %v", err)
 	}
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
-	go func() {
-		<-sigChan
-		logInfo("Received shutdown signal, shutting down gracefully...")
+	// TASKS_LOCALE picks the collation used for ?sort=title and ?q= matching
+	if raw := os.Getenv("TASKS_LOCALE"); raw != "" {
+		if textLocale, err = language.Parse(raw); err != nil {
+			logFatal("Invalid TASKS_LOCALE %q: %v", raw, err)
+		}
+	}
 
-		// Create a timeout context for the shutdown process
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	// VALIDATION_RULES names a JSON file of cross-field rules checked on create and update
+	if filename := os.Getenv("VALIDATION_RULES"); filename != "" {
+		if validationRules, err = loadValidationRules(filename); err != nil {
+			logFatal("Invalid VALIDATION_RULES: %v", err)
+		}
+		logInfo("Loaded %d validation rules from %s", len(validationRules), filename)
+	}
 
-		// Save tasks before shutdown
-		if err := SaveTasksToFile("tasks.json"); err != nil {
-			logError("Failed to save tasks to tasks.json: %v", err)
-		} else {
-			logInfo("Tasks saved to tasks.json")
+	// USERS_FILE lists the users allowed to call the API; each sees only their own tasks
+	if filename := os.Getenv("USERS_FILE"); filename != "" {
+		if users, err = loadUsers(filename); err != nil {
+			logFatal("Invalid USERS_FILE: %v", err)
+		}
+		logInfo("Loaded %d users from %s, API keys are required", len(users), filename)
+		// TOKENS_FILE keeps the API tokens users issue for themselves
+		if tokensFile = os.Getenv("TOKENS_FILE"); tokensFile == "" {
+			tokensFile = "tokens.json"
 		}
+		if err := LoadTokensFromFile(tokensFile); err != nil {
+			logFatal("Failed to load API tokens from %s: %v", tokensFile, err)
+		}
+	}
 
-		// Attempt graceful shutdown
-		if err := srv.Shutdown(ctx); err != nil {
-			log.Fatalf("Server forced to shutdown: %v", err)
+	// AUTH_PROXY_HEADER trusts an authenticating reverse proxy's identity header, such as
+	// X-Auth-Request-User, on requests from the AUTH_PROXY_CIDRS; other requests still need a key
+	if authProxyHeader = os.Getenv("AUTH_PROXY_HEADER"); authProxyHeader != "" {
+		if authProxies, err = parseTrustedProxies(os.Getenv("AUTH_PROXY_CIDRS")); err != nil {
+			logFatal("Invalid AUTH_PROXY_CIDRS: %v", err)
 		}
-		close(doneChan)
-	}()
+		if len(authProxies) == 0 || len(users) == 0 {
+			logFatal("AUTH_PROXY_HEADER needs AUTH_PROXY_CIDRS and USERS_FILE")
+		}
+		logInfo("Trusting %s from auth proxies %s", authProxyHeader, os.Getenv("AUTH_PROXY_CIDRS"))
+	}
 
-	if err = srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Listen failed: %v", err)
+	// PREFERENCES_FILE keeps each user's default list views
+	if preferencesFile = os.Getenv("PREFERENCES_FILE"); preferencesFile == "" {
+		preferencesFile = "preferences.json"
+	}
+	if err := LoadPreferencesFromFile(preferencesFile); err != nil {
+		logFatal("Failed to load preferences from %s: %v", preferencesFile, err)
 	}
 
-	<-doneChan // Wait for shutdown signal
-	log.Println("Server shutdown complete.")
-}
+	// BOARD_WIP_LIMITS caps board columns, e.g. {"in_progress": 3}; boards warn about columns over their limit
+	if boardWIPLimits, err = parseWIPLimits(os.Getenv("BOARD_WIP_LIMITS")); err != nil {
+		logFatal("Invalid BOARD_WIP_LIMITS: %v", err)
+	}
 
-func longRunningHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("Starting long-running request...")
-	time.Sleep(10 * time.Second) // Simulate processing delay
-	log.Println("Finished long-running request.")
-	w.Write([]byte("Request completed"))
-}
+	// RESPONSE_BUDGET bounds how long GETs may take before a cached response is served instead
+	budget := 2 * time.Second
+	if raw := os.Getenv("RESPONSE_BUDGET"); raw != "" {
+		if budget, err = time.ParseDuration(raw); err != nil {
+			logFatal("Invalid RESPONSE_BUDGET %q: %v", raw, err)
+		}
+	}
 
-// Tasks handles requests to retrieve, create, or delete tasks via HTTP methods.
-func Tasks(w http.ResponseWriter, r *http.Request) {
-	// Prints log to Stdout
-	logInfo("Received %s request for %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	// STORAGE_SLOW_THRESHOLD logs storage operations slower than it ("0" disables the log)
+	if raw := os.Getenv("STORAGE_SLOW_THRESHOLD"); raw != "" {
+		if storageMetrics.slowThreshold, err = time.ParseDuration(raw); err != nil || storageMetrics.slowThreshold < 0 {
+			logFatal("Invalid STORAGE_SLOW_THRESHOLD %q", raw)
+		}
+	}
 
-	// Check that method type is supported
-	if r.Method != "GET" && r.Method != "POST" && r.Method != "PUT" && r.Method != "DELETE" {
-		logError("Unsupported method: %s", r.Method)
-		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
-		return
+	// CONSISTENCY_CHECK_INTERVAL schedules background consistency checks ("0" disables them);
+	// CONSISTENCY_REPAIR=true lets them fix what they safely can
+	checkInterval := time.Hour
+	if raw := os.Getenv("CONSISTENCY_CHECK_INTERVAL"); raw != "" {
+		if checkInterval, err = time.ParseDuration(raw); err != nil || checkInterval < 0 {
+			logFatal("Invalid CONSISTENCY_CHECK_INTERVAL %q", raw)
+		}
 	}
-	switch r.Method {
-	case "GET":
-		// Marshal tasks struct into valid json
-		taskMutex.Lock()
-		defer taskMutex.Unlock()
-		jsonData, err := json.Marshal(tasks)
-		if err != nil {
-			logError("JSON marshalling failed")
-			writeJsonError(w, http.StatusInternalServerError, "Internal server error: JSON marshalling failed")
-			return
+	checkRepair, _ := strconv.ParseBool(os.Getenv("CONSISTENCY_REPAIR"))
+	stopBackground := make(chan struct{})
+	addJob("consistency-check", checkInterval, func() error {
+		logConsistencyReport(store.CheckConsistency(checkRepair))
+		return nil
+	})
+
+	// AUTOSAVE_INTERVAL and AUTOSAVE_CHANGES save the tasks periodically and after that many changes ("0"
+	// disables either), so a crash doesn't lose everything since startup; the interval saves changed counters
+	// too. SQLite already saves every change, so there autosave only catches up on changes that failed to
+	// write through.
+	autosaveInterval, autosaveChanges := 30*time.Second, 100
+	if raw := os.Getenv("AUTOSAVE_INTERVAL"); raw != "" {
+		if autosaveInterval, err = time.ParseDuration(raw); err != nil || autosaveInterval < 0 {
+			logFatal("Invalid AUTOSAVE_INTERVAL %q", raw)
 		}
-		// Specify response format as JSON to ensure correct client parsing
-		w.Header().Set("Content-Type", "application/json")
-		// Writes the json data to the client
-		w.Write(jsonData)
-	case "POST":
-		// Reads the body for valid json to add as new task
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			logError("Failed to read request body")
-			writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
-			return
+	}
+	if raw := os.Getenv("AUTOSAVE_CHANGES"); raw != "" {
+		if autosaveChanges, err = strconv.Atoi(raw); err != nil || autosaveChanges < 0 {
+			logFatal("Invalid AUTOSAVE_CHANGES %q", raw)
 		}
+	}
+	if store.writeThrough {
+		autosaveChanges = 0
+	}
+	// Changed counters are saved on the same schedule as the tasks
+	autosaveJob := addJob("autosave", autosaveInterval, func() error {
+		return errors.Join(store.saveUnsaved(), saveUnsavedCounters(countersFile))
+	})
+	if autosaveChanges > 0 {
+		go store.autosave(autosaveChanges, stopBackground)
+	}
 
-		var newTask Task
-		// Unmarshals json into struct fields
-		err = json.Unmarshal(body, &newTask)
+	// LINK_TITLES=true fetches page titles in the background for links added without one
+	if fetchTitles, _ := strconv.ParseBool(os.Getenv("LINK_TITLES")); fetchTitles {
+		linkTitles = make(chan linkTitleJob, 100)
+		go runLinkTitles(stopBackground)
+	}
+
+	// IMAP_URL turns email into tasks: the mailbox is polled every IMAP_POLL_INTERVAL for unseen messages,
+	// and IMAP_TASK_OWNER names the user who owns the tasks when authentication is on
+	if raw := os.Getenv("IMAP_URL"); raw != "" {
+		mailbox, err := parseIMAPURL(raw)
 		if err != nil {
-			logError("Invalid JSON Format in POST request")
-			writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
-			return
+			logFatal("Invalid IMAP_URL: %v", err)
 		}
-		if newTask.Title == "" {
-			logError("Invalid task title in POST request")
-			writeJsonError(w, http.StatusBadRequest, "Task title cannot be empty")
-			return
+		mailbox.Owner = os.Getenv("IMAP_TASK_OWNER")
+		pollInterval := time.Minute
+		if raw := os.Getenv("IMAP_POLL_INTERVAL"); raw != "" {
+			if pollInterval, err = time.ParseDuration(raw); err != nil || pollInterval <= 0 {
+				logFatal("Invalid IMAP_POLL_INTERVAL %q", raw)
+			}
 		}
-		// lastID tracks the ID of the most recently added task
-		taskMutex.Lock()
-		defer taskMutex.Unlock()
-		lastID++
-		newTask.ID = lastID
-		// Add new task to tasks
-		tasks = append(tasks, newTask)
-		w.Header().Set("Content-Type", "application/json")
-		// Sets status to 201 to acknowledge task creation
-		w.WriteHeader(http.StatusCreated)
-		// Writes new task back to client
-		json.NewEncoder(w).Encode(newTask)
-	case "PUT":
-		ID, err := ParseTaskID(r)
-		if err != nil {
-			logError(err.Error())
-			writeJsonError(w, http.StatusBadRequest, err.Error())
-			return
+		addJob("mail-poll", pollInterval, mailPollJob(mailbox, store)).runAtStart = true
+	}
+
+	// JOBS overrides the schedules above per job, as cron expressions, and sets jitter; see the README
+	jobSettings, err := parseJobSettings(os.Getenv("JOBS"))
+	if err == nil {
+		err = jobScheduler.Configure(jobSettings)
+	}
+	if err != nil {
+		logFatal("Invalid JOBS: %v", err)
+	}
+	if autosaveJob.enabled {
+		// Health reports autosave as lagging once changes wait longer than its period
+		store.autosaveInterval = schedulePeriod(autosaveJob.schedule, clock.Now())
+	}
+	jobScheduler.Start(stopBackground)
+	// Let a job in progress, such as a mail poll, finish before the final save
+	shutdownHooks.Register(phaseStopBackground, "background jobs", 10*time.Second, func(context.Context) error {
+		close(stopBackground)
+		jobScheduler.Wait()
+		return nil
+	})
+
+	// SECURITY_HEADERS overrides the default security headers, e.g. {"Content-Security-Policy": "default-src 'self'"}
+	securityHeaders, err := parseSecurityHeaders(os.Getenv("SECURITY_HEADERS"))
+	if err != nil {
+		logFatal("Invalid SECURITY_HEADERS: %v", err)
+	}
+
+	mux := newPublicMux(budget)
+
+	// ADMIN_ADDR is the management port for /admin, /metrics, and /debug; it is localhost-only by default
+	adminAddr := os.Getenv("ADMIN_ADDR")
+	if adminAddr == "" {
+		adminAddr = defaultAdminAddr
+	}
+	adminMux := newAdminMux(mux, pendingFile, config.TasksFile, config.Storage)
+
+	doneChan := make(chan struct{})
+	// LISTEN_ADDRS serves the public API on several addresses, e.g. "127.0.0.1:8000,[::1]:8000"
+	addrs, err := parseListenAddrs(os.Getenv("LISTEN_ADDRS"), config.Addr)
+	if err != nil {
+		logFatal("Invalid LISTEN_ADDRS: %v", err)
+	}
+	// REQUIRE_TASK_VERSION rejects task updates that don't say which version they replace
+	requireTaskVersion, _ = strconv.ParseBool(os.Getenv("REQUIRE_TASK_VERSION"))
+	var handler http.Handler = mux
+	// DRY_RUN=true captures mutations to the pending-changes file for review instead of applying them
+	if dryRun, _ := strconv.ParseBool(os.Getenv("DRY_RUN")); dryRun {
+		logInfo("Dry-run mode enabled, mutations are written to %s", pendingFile)
+		handler = DryRun(handler, pendingFile)
+	}
+	// CHAOS injects latency and failures for resilience testing; only test builds (-tags chaos) accept it
+	if raw := os.Getenv("CHAOS"); raw != "" {
+		if !chaosBuild {
+			logFatal("CHAOS is set but this binary was built without -tags chaos")
 		}
-		// Reads the body for valid json to add as new task
-		body, err := io.ReadAll(r.Body)
+		config, err := parseChaosConfig(raw)
 		if err != nil {
-			logError("Failed to read request body in PUT")
-			writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
-			return
+			logFatal("Invalid CHAOS: %v", err)
 		}
-		var newTask Task
-		// Unmarshals json into struct fields
-		err = json.Unmarshal(body, &newTask)
-		if err != nil {
-			logError("Invalid JSON format in PUT")
-			writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
-			return
+		logInfo("Chaos enabled: %+v", config)
+		handler = Chaos(handler, config)
+	}
+	// Authentication runs ahead of dry-run so staged changes are validated and applied as their author
+	handler = Authenticate(handler)
+	// MAX_IN_FLIGHT caps concurrent requests; up to MAX_QUEUED more wait as long as QUEUE_TIMEOUT for a
+	// slot, and the rest get a 503
+	if raw := os.Getenv("MAX_IN_FLIGHT"); raw != "" {
+		maxInFlight, err := strconv.Atoi(raw)
+		if err != nil || maxInFlight < 1 {
+			logFatal("Invalid MAX_IN_FLIGHT %q, must be a positive number", raw)
 		}
-		if newTask.Title == "" {
-			logError("Empty task title in PUT")
-			writeJsonError(w, http.StatusBadRequest, "Task title cannot be empty")
-			return
+		maxQueued := 100
+		if raw := os.Getenv("MAX_QUEUED"); raw != "" {
+			if maxQueued, err = strconv.Atoi(raw); err != nil || maxQueued < 0 {
+				logFatal("Invalid MAX_QUEUED %q", raw)
+			}
 		}
-		// taskFound tracks whether specified task number exists
-		taskFound := false
-		// Removes specified task if found
-		taskMutex.Lock()
-		defer taskMutex.Unlock()
-		var updatedIndex int
-		for i, t := range tasks {
-			if t.ID == ID {
-				tasks[i].Title = newTask.Title
-				tasks[i].Completed = newTask.Completed
-				updatedIndex = i
-				taskFound = true
-				break
+		queueTimeout := time.Second
+		if raw := os.Getenv("QUEUE_TIMEOUT"); raw != "" {
+			if queueTimeout, err = time.ParseDuration(raw); err != nil || queueTimeout < 0 {
+				logFatal("Invalid QUEUE_TIMEOUT %q", raw)
 			}
 		}
-		if !taskFound {
-			logError("Task not found with ID %d in PUT", ID)
-			writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No task found with ID %d", ID))
-			return
+		concurrency = newConcurrencyLimiter(maxInFlight, maxQueued, queueTimeout)
+		handler = LimitConcurrency(handler, concurrency)
+	}
+	handler = RequestID(SecurityHeaders(handler, securityHeaders))
+	var servers []*http.Server
+	for _, addr := range addrs {
+		servers = append(servers, &http.Server{Addr: addr, Handler: handler})
+	}
+	servers = append(servers, &http.Server{Addr: adminAddr, Handler: RequestID(adminMux)})
+	logInfo("Starting server on %s (management on %s)", strings.Join(addrs, ", "), adminAddr)
+	shutdownHooks.Register(phaseStopAccepting, "health", time.Second, func(context.Context) error {
+		shuttingDown.Store(true)
+		for _, srv := range servers {
+			srv.SetKeepAlivesEnabled(false)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		// Outputs success message in json format
-		json.NewEncoder(w).Encode(tasks[updatedIndex])
+		return nil
+	})
+	// Open event streams would otherwise hold the drain until its timeout
+	shutdownHooks.Register(phaseStopAccepting, "event streams", time.Second, func(context.Context) error {
+		taskChanges.close()
+		return nil
+	})
+	shutdownHooks.Register(phaseDrain, "http servers", 10*time.Second, func(ctx context.Context) error {
+		return shutdownAll(ctx, servers)
+	})
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 
-	case "DELETE":
-		ID, err := ParseTaskID(r)
-		if err != nil {
-			logError(err.Error())
-			writeJsonError(w, http.StatusBadRequest, err.Error())
-			return
-		}
-		// taskFound tracks whether specified task number exists
-		taskFound := false
-		// Removes specified task if found
-		taskMutex.Lock()
-		defer taskMutex.Unlock()
-		for i, t := range tasks {
-			if t.ID == ID {
-				tasks = append(tasks[:i], tasks[i+1:]...)
-				taskFound = true
-				break
-			}
-		}
-		if !taskFound {
-			logError("Task not found with ID %d in DELETE", ID)
-			writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No task found with ID %d", ID))
-			return
+	go func() {
+		<-sigChan
+		logInfo("Received shutdown signal, shutting down gracefully...")
+		if failed := shutdownHooks.Run(context.Background()); failed > 0 {
+			logError("Shutdown finished with %d failed steps", failed)
 		}
-		w.Header().Set("Content-Type", "application/json")
-		// Outputs success message in json format
-		json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Task deleted"})
+		close(doneChan)
+	}()
+
+	if err := <-serveAll(servers); err != nil {
+		logFatal("Listen failed: %v", err)
 	}
+
+	<-doneChan // Wait for shutdown signal
+	logInfo("Server shutdown complete.")
 }
 
-func writeJsonError(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(map[string]string{"error": message})
+// newPublicMux builds the public routes with their middleware. They get their own mux so nothing
+// registered on http.DefaultServeMux by imported packages is exposed.
+func newPublicMux(budget time.Duration) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/tasks", LogRequestDuration(ResponseBudget(ValidateJSON(http.HandlerFunc(Tasks), http.MethodPost, http.MethodPut), budget)))
+	mux.Handle("/tasks/", LogRequestDuration(ResponseBudget(ValidateJSON(http.HandlerFunc(Tasks), http.MethodPost, http.MethodPut), budget)))
+	mux.Handle("/counters", LogRequestDuration(ValidateJSON(http.HandlerFunc(Counters), http.MethodPost)))
+	mux.Handle("/counters/", LogRequestDuration(http.HandlerFunc(Counters)))
+	mux.Handle("/feed.json", LogRequestDuration(ResponseBudget(http.HandlerFunc(JSONFeedHandler), budget)))
+	mux.Handle("/feed.atom", LogRequestDuration(ResponseBudget(http.HandlerFunc(AtomFeedHandler), budget)))
+	mux.Handle("/.well-known/tasktracker", LogRequestDuration(http.HandlerFunc(WellKnown)))
+	mux.Handle("/openapi.json", LogRequestDuration(http.HandlerFunc(OpenAPI)))
+	mux.Handle("/docs", LogRequestDuration(Docs()))
+	mux.Handle("/docs/", LogRequestDuration(Docs()))
+	mux.Handle("/boards/", LogRequestDuration(ResponseBudget(http.HandlerFunc(Boards), budget)))
+	mux.Handle("/tags", LogRequestDuration(ResponseBudget(http.HandlerFunc(Tags), budget)))
+	mux.Handle("/me/tokens", LogRequestDuration(http.HandlerFunc(Tokens)))
+	mux.Handle("/me/tokens/", LogRequestDuration(http.HandlerFunc(Tokens)))
+	mux.Handle("/me/preferences", LogRequestDuration(http.HandlerFunc(UserPreferences)))
+	mux.Handle("/imports", LogRequestDuration(http.HandlerFunc(Imports)))
+	mux.Handle("/jobs/", LogRequestDuration(http.HandlerFunc(Jobs)))
+	mux.Handle("/export", LogRequestDuration(http.HandlerFunc(ExportTasks)))
+	mux.Handle("/import", LogRequestDuration(http.HandlerFunc(ImportTasks)))
+	mux.Handle("/long/", LogRequestDuration(http.HandlerFunc(longRunningHandler)))
+	// Streams stay open for as long as clients listen, so they get no response budget
+	mux.Handle("/events", LogRequestDuration(http.HandlerFunc(Events)))
+	mux.HandleFunc("/tasks/health", Health)
+	return mux
 }
 
-func logInfo(msg string, args ...interface{}) {
-	log.Printf("[INFO] "+msg, args...)
+// taskRoutes dispatches /tasks requests to a handler per method and path. Each path also has a handler
+// without a method, so other methods get a JSON 405 rather than the mux's plain-text one.
+var taskRoutes = newTaskRoutes()
+
+func newTaskRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tasks", ListTasks)
+	mux.HandleFunc("POST /tasks", CreateTask)
+	mux.HandleFunc("DELETE /tasks", DeleteTasks)
+	mux.HandleFunc("PUT /tasks/order", ReorderTasks)
+	mux.HandleFunc("GET /tasks/print", PrintTasks)
+	mux.HandleFunc("PUT /tasks/{id}", UpdateTask)
+	mux.HandleFunc("DELETE /tasks/{id}", DeleteTask)
+	mux.HandleFunc("GET /tasks/{id}/subtasks", Subtasks)
+	mux.HandleFunc("POST /tasks/{id}/lock", LockTask)
+	mux.HandleFunc("POST /tasks/{id}/unlock", LockTask)
+	mux.HandleFunc("GET /tasks/{id}/checklist", Checklist)
+	mux.HandleFunc("POST /tasks/{id}/checklist", Checklist)
+	mux.HandleFunc("PUT /tasks/{id}/checklist", Checklist)
+	mux.HandleFunc("PUT /tasks/{id}/checklist/{item}", Checklist)
+	mux.HandleFunc("DELETE /tasks/{id}/checklist/{item}", Checklist)
+	for _, pattern := range []string{"/tasks", "/tasks/{id}", "/tasks/{id}/subtasks", "/tasks/{id}/lock", "/tasks/{id}/unlock", "/tasks/{id}/checklist", "/tasks/{id}/checklist/{item}"} {
+		mux.HandleFunc(pattern, methodNotAllowed)
+	}
+	mux.HandleFunc("/tasks/", func(w http.ResponseWriter, r *http.Request) {
+		logErrorContext(r.Context(), "No route for %s %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusNotFound, "Not Found")
+	})
+	return mux
 }
 
-func logError(msg string, args ...interface{}) {
-	log.Printf("[Error] "+msg, args...)
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+	writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
 }
 
-func ParseTaskID(r *http.Request) (int, error) {
-	// Cleans path to allow trailing slashes
+// taskRoute returns the taskRoutes pattern that would serve r, such as "PUT /tasks/{id}", for
+// middleware that runs before routing
+func taskRoute(r *http.Request) string {
+	routed := *r
+	routed.URL = &url.URL{Path: path.Clean(r.URL.Path)}
+	_, pattern := taskRoutes.Handler(&routed)
+	return pattern
+}
+
+// Health reports readiness: 200 OK normally, 503 while the storage circuit breaker is open.
+// With ?verbose=true it returns a JSON HealthReport of each subsystem instead.
+func Health(w http.ResponseWriter, r *http.Request) {
+	if verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose")); verbose {
+		writeHealthReport(w, storeFor(r))
+		return
+	}
+	if storageBreaker.open() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("Storage unavailable"))
+		return
+	}
+	if shuttingDown.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("Shutting down"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+func longRunningHandler(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Starting long-running request...")
+	time.Sleep(10 * time.Second) // Simulate processing delay
+	logInfoContext(r.Context(), "Finished long-running request.")
+	w.Write([]byte("Request completed"))
+}
+
+// Tasks serves everything under /tasks through taskRoutes, after cleaning the path so a trailing slash is ignored
+func Tasks(w http.ResponseWriter, r *http.Request) {
+	// Prints log to Stdout
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
 	r.URL.Path = path.Clean(r.URL.Path)
-	// Splits URL based on /
-	parts := strings.Split(r.URL.Path, "/")
-	// Checks that URL is properly formed "host/tasks/{id}"
-	if len(parts) != 3 {
-		return 0, fmt.Errorf("Invalid URL")
+	taskRoutes.ServeHTTP(w, r)
+}
+
+// ListTasks serves GET /tasks
+func ListTasks(w http.ResponseWriter, r *http.Request) {
+	store := storeFor(r)
+	// ?q=, ?completed=, ?available=, ?sort=, and ?fields= narrow, order, and project the list;
+	// the user's preferences fill in any the request leaves out
+	query, err := parseTaskListQuery(preferencesFor(userFor(r)).apply(r.URL.Query()))
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Taken before the list is read, so the token is never newer than the tasks returned
+	snapshot := store.Generation()
+	// Marshal tasks struct into valid json
+	var jsonData []byte
+	switch {
+	case query.Page != nil:
+		jsonData, err = marshalTaskPage(r.URL, store.List(), query)
+		var unknown *cursorError
+		if errors.As(err, &unknown) {
+			logErrorContext(r.Context(), err.Error())
+			writeJsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	case query.Fields != nil:
+		jsonData, err = marshalTasksProjected(selectTasks(store.List(), query), query.Fields)
+	case query.selectsSubset():
+		jsonData, err = json.Marshal(selectTasks(store.List(), query))
+	default:
+		jsonData, err = encodeTaskList(store)
 	}
-	// Converts task number to integer
-	ID, err := strconv.Atoi(parts[len(parts)-1])
 	if err != nil {
-		return 0, fmt.Errorf("Invalid Task ID")
+		logErrorContext(r.Context(), "JSON marshalling failed")
+		writeJsonError(w, http.StatusInternalServerError, "Internal server error: JSON marshalling failed")
+		return
 	}
-	return ID, nil
+	// The ETag hashes the body, so any change to what this caller would see, a mutation or a task
+	// becoming overdue, gives a new one. Polling clients send it back in If-None-Match and get a 304.
+	etag := bodyETag(jsonData)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set(snapshotHeader, formatSnapshot(snapshot))
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	// Specify response format as JSON to ensure correct client parsing
+	w.Header().Set("Content-Type", "application/json")
+	// Writes the json data to the client
+	w.Write(jsonData)
 }
 
-func LoadTasksFromFile(filename string) error {
-	file, err := os.Open(filename)
+// CreateTask serves POST /tasks
+func CreateTask(w http.ResponseWriter, r *http.Request) {
+	store := storeFor(r)
+	// Reads the body for valid json to add as new task
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		return err
+		logErrorContext(r.Context(), "Failed to read request body")
+		writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+		return
 	}
-	defer file.Close()
 
-	if err := json.NewDecoder(file).Decode(&tasks); err != nil {
-		return err
+	var newTask Task
+	// Unmarshals json into struct fields
+	err = json.Unmarshal(body, &newTask)
+	if err != nil {
+		logErrorContext(r.Context(), "Invalid JSON Format in POST request")
+		writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	newTask.Title = normalizeText(newTask.Title)
+	if newTask.Title == "" {
+		logErrorContext(r.Context(), "Invalid task title in POST request")
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, "Task title cannot be empty")
+		return
 	}
-	logInfo("Tasks loaded successfully from %s", filename)
+	if err := validateTaskDates(&newTask); err != nil {
+		logErrorContext(r.Context(), "Invalid dates in POST request: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if err := validatePriority(newTask.Priority); err != nil {
+		logErrorContext(r.Context(), "Invalid priority in POST request: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if newTask.Tags, err = normalizeTags(newTask.Tags); err != nil {
+		logErrorContext(r.Context(), "Invalid tags in POST request: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if newTask.Links, err = normalizeLinks(newTask.Links); err != nil {
+		logErrorContext(r.Context(), "Invalid links in POST request: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if newTask.Notes, err = normalizeNotes(newTask.Notes); err != nil {
+		logErrorContext(r.Context(), "Invalid notes in POST request: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if newTask.Checklist, err = normalizeChecklist(newTask.Checklist); err != nil {
+		logErrorContext(r.Context(), "Invalid checklist in POST request: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	// The completion percentage is derived from the items, never taken from the client
+	updateChecklistCompletion(&newTask)
+	if err := validateParent(store.List(), 0, newTask.ParentID); err != nil {
+		logErrorContext(r.Context(), "Invalid parent in POST request: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	// Locks are only taken through /tasks/{id}/lock, and the owner comes from authentication
+	newTask.Lock = nil
+	newTask.Owner = ""
+	// Timestamps are the server's; whatever the client sent is replaced
+	now := clock.Now()
+	newTask.CreatedAt = &now
+	newTask.touch(now)
+	var violated *ruleError
+	if err := checkRules(newTask, validationRules); errors.As(err, &violated) {
+		logErrorContext(r.Context(), "Task rejected in POST: %v", err)
+		writeRuleViolations(w, violated)
+		return
+	}
+	// Add new task to tasks; the store assigns its ID
+	newTask, err = store.Create(newTask)
+	if errors.Is(err, ErrTaskIDExhausted) {
+		logErrorContext(r.Context(), "Task ID space exhausted")
+		writeJsonError(w, http.StatusInsufficientStorage, "Task ID space exhausted")
+		return
+	}
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to create task: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to create task")
+		return
+	}
+	queueLinkTitles(store, newTask)
+	w.Header().Set("ETag", versionETag(newTask.Version))
+	w.Header().Set("Content-Type", "application/json")
+	// Sets status to 201 to acknowledge task creation
+	w.WriteHeader(http.StatusCreated)
+	// Writes new task back to client
+	json.NewEncoder(w).Encode(newTask)
+}
 
-	// caluclate lastID
-	lastID = 0
-	for _, task := range tasks {
-		if task.ID > lastID {
-			lastID = task.ID
+// UpdateTask serves PUT /tasks/{id}. With ?include=diff the response adds a diff object holding the
+// old and new value of every field the update changed.
+func UpdateTask(w http.ResponseWriter, r *http.Request) {
+	store := storeFor(r)
+	ID, err := ParseTaskID(r)
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Reads the body for valid json to add as new task
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to read request body in PUT")
+		writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	var newTask Task
+	// Unmarshals json into struct fields
+	err = json.Unmarshal(body, &newTask)
+	if err != nil {
+		logErrorContext(r.Context(), "Invalid JSON format in PUT")
+		writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	newTask.Title = normalizeText(newTask.Title)
+	if newTask.Title == "" {
+		logErrorContext(r.Context(), "Empty task title in PUT")
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, "Task title cannot be empty")
+		return
+	}
+	if err := validateTaskDates(&newTask); err != nil {
+		logErrorContext(r.Context(), "Invalid dates in PUT: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if err := validatePriority(newTask.Priority); err != nil {
+		logErrorContext(r.Context(), "Invalid priority in PUT: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if newTask.Tags, err = normalizeTags(newTask.Tags); err != nil {
+		logErrorContext(r.Context(), "Invalid tags in PUT: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if newTask.Links, err = normalizeLinks(newTask.Links); err != nil {
+		logErrorContext(r.Context(), "Invalid links in PUT: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	if newTask.Notes, err = normalizeNotes(newTask.Notes); err != nil {
+		logErrorContext(r.Context(), "Invalid notes in PUT: %v", err)
+		writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+		return
+	}
+	// ?subtasks=cascade completes open subtasks along with the task; ?subtasks=block refuses while any are open
+	params := newQueryParams(r.URL.Query())
+	subtaskMode := params.Enum("subtasks", "", "cascade", "block")
+	include := params.Enum("include", "", "diff")
+	if err := params.Err(); err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// If-Match or a version in the body makes the update apply only to the version the client read
+	expected, stated, err := expectedVersion(r, body)
+	if err != nil {
+		logErrorContext(r.Context(), "Invalid version in PUT: %v", err)
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !stated && requireTaskVersion {
+		logErrorContext(r.Context(), "PUT of task %d without a version", ID)
+		writeJsonError(w, http.StatusPreconditionRequired, "Updates must send If-Match or version with the task version they replace")
+		return
+	}
+	list := store.List()
+	if hasJSONField(body, "parent_id") {
+		if err := validateParent(list, ID, newTask.ParentID); err != nil {
+			logErrorContext(r.Context(), "Invalid parent in PUT: %v", err)
+			writeJsonErrorCode(w, http.StatusBadRequest, codeValidationFailed, err.Error())
+			return
 		}
 	}
-
-	return nil
+	if open := incompleteDescendants(list, ID); newTask.Completed && subtaskMode == "block" && len(open) > 0 {
+		logErrorContext(r.Context(), "Task %d not completed, subtasks %s are open", ID, formatIDs(open))
+		writeJsonError(w, http.StatusConflict, fmt.Sprintf("Task %d has incomplete subtasks: %s", ID, formatIDs(open)))
+		return
+	}
+	// The task and, with ?subtasks=cascade, its subtasks change together or not at all
+	var before, updated Task
+	err = store.WithTx(func(tx TaskStore) error {
+		var err error
+		updated, err = tx.Update(ID, func(t *Task) error {
+			if expected != nil && t.Version != *expected {
+				return &versionConflict{id: ID, current: t.Version, expected: *expected}
+			}
+			before = t.clone()
+			t.Title = newTask.Title
+			t.Completed = newTask.Completed
+			// Optional fields keep their value unless the body mentions them; null clears them
+			if hasJSONField(body, "start_date") {
+				t.StartDate = newTask.StartDate
+			}
+			if hasJSONField(body, "due_date") {
+				t.DueDate = newTask.DueDate
+			}
+			if hasJSONField(body, "priority") {
+				t.Priority = newTask.Priority
+			}
+			if hasJSONField(body, "tags") {
+				t.Tags = newTask.Tags
+			}
+			if hasJSONField(body, "links") {
+				t.Links = newTask.Links
+			}
+			if hasJSONField(body, "notes") {
+				t.Notes = newTask.Notes
+			}
+			if hasJSONField(body, "parent_id") {
+				t.ParentID = newTask.ParentID
+			}
+			t.touch(clock.Now())
+			// Rules see the task as it would be stored, including fields this PUT left alone
+			return checkRules(*t, validationRules)
+		})
+		if err != nil || subtaskMode != "cascade" || !updated.Completed {
+			return err
+		}
+		return completeSubtasks(tx, ID)
+	})
+	var conflict *versionConflict
+	if errors.As(err, &conflict) {
+		logErrorContext(r.Context(), "Stale PUT of task %d: %v", ID, err)
+		w.Header().Set("ETag", versionETag(conflict.current))
+		writeJsonErrorCode(w, http.StatusConflict, codeVersionConflict, conflict.Error())
+		return
+	}
+	var violated *ruleError
+	if errors.As(err, &violated) {
+		logErrorContext(r.Context(), "Task %d rejected in PUT: %v", ID, err)
+		writeRuleViolations(w, violated)
+		return
+	}
+	if errors.Is(err, ErrTaskNotFound) {
+		logErrorContext(r.Context(), "Task not found with ID %d in PUT", ID)
+		writeJsonErrorCode(w, http.StatusNotFound, codeTaskNotFound, fmt.Sprintf("No task found with ID %d", ID))
+		return
+	}
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to update task %d: %v", ID, err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to update task")
+		return
+	}
+	queueLinkTitles(store, updated)
+	w.Header().Set("ETag", versionETag(updated.Version))
+	if include == "diff" {
+		diff, err := diffTasks(before, updated)
+		if err != nil {
+			logErrorContext(r.Context(), "Failed to diff task %d: %v", ID, err)
+			writeJsonError(w, http.StatusInternalServerError, "Failed to encode task")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(taskWithDiff{updated, diff})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// Outputs success message in json format
+	json.NewEncoder(w).Encode(updated)
 }
 
-func SaveTasksToFile(filename string) error {
-	// Create backup of old tasks.json
-	backupFilename := filename + ".bak"
-	if _, err := os.Stat(filename); err == nil { // Check if file exists
-		if err := os.Rename(filename, backupFilename); err != nil {
-			logError("Warning: Failed to create backup %s: %v", backupFilename, err)
-		} else {
-			logInfo("Backup created: %s", backupFilename)
+// DeleteTask serves DELETE /tasks/{id}
+func DeleteTask(w http.ResponseWriter, r *http.Request) {
+	store := storeFor(r)
+	ID, err := ParseTaskID(r)
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Removes specified task if found, promoting its subtasks in the same transaction
+	err = store.WithTx(func(tx TaskStore) error {
+		if err := tx.Delete(ID); err != nil {
+			return err
 		}
+		return detachSubtasks(tx, map[int]bool{ID: true})
+	})
+	if errors.Is(err, ErrTaskNotFound) {
+		logErrorContext(r.Context(), "Task not found with ID %d in DELETE", ID)
+		writeJsonErrorCode(w, http.StatusNotFound, codeTaskNotFound, fmt.Sprintf("No task found with ID %d", ID))
+		return
 	}
-
-	// Overwrite original file
-	file, err := os.Create(filename)
 	if err != nil {
-		return err
+		logErrorContext(r.Context(), "Failed to delete task %d: %v", ID, err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to delete task")
+		return
 	}
-	defer file.Close()
+	w.Header().Set("Content-Type", "application/json")
+	// Outputs success message in json format
+	json.NewEncoder(w).Encode(map[string]string{"status": "success", "message": "Task deleted"})
+}
 
-	// Write JSON to file
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err = encoder.Encode(tasks); err != nil {
-		return err
+// ParseTaskID returns the {id} of a request taskRoutes matched
+func ParseTaskID(r *http.Request) (int, error) {
+	return parseTaskIDString(r.PathValue("id"))
+}
+
+// maxTaskID is the largest task ID the server will issue or accept, kept within int32 so IDs
+// stay exact in clients that store them as 32-bit integers or JavaScript numbers
+const maxTaskID = math.MaxInt32
+
+// parseTaskIDString converts a path segment to a task ID, accepting only canonical positive decimals
+// within maxTaskID. Swap this out if the ID generator ever moves to a non-numeric scheme.
+func parseTaskIDString(raw string) (int, error) {
+	// Converts task number to integer
+	ID, err := strconv.Atoi(raw)
+	if errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("Task ID out of range")
+	}
+	// Reject forms like "+5" or "007" so each task has exactly one URL
+	if err != nil || strconv.Itoa(ID) != raw {
+		return 0, fmt.Errorf("Invalid Task ID")
+	}
+	if ID <= 0 {
+		return 0, fmt.Errorf("Task ID must be a positive integer")
+	}
+	if ID > maxTaskID {
+		return 0, fmt.Errorf("Task ID out of range")
 	}
+	return ID, nil
+}
 
-	logInfo("Tasks successfully saved to %s", filename)
+// LoadTasksFromFile replaces the task store with the contents of a JSON tasks file
+func LoadTasksFromFile(filename string) error {
+	store, err := openTaskStore(fileBackend{filename: filename}, false)
+	if err != nil {
+		return err
+	}
+	taskStore = store
 	return nil
 }
+
+// SaveTasksToFile writes the task list to a JSON tasks file, keeping the previous version as a .bak
+func SaveTasksToFile(filename string) error {
+	return fileBackend{filename: filename}.Save(taskStore.List())
+}
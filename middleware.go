@@ -1,26 +1,85 @@
 package main
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
-// LogRequestDuration logs the method, path, and duration of each request
+// LogRequestDuration logs the method, route template, path, and duration of each request
 func LogRequestDuration(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// capture current time for logging duration
-		start := time.Now()
+		// start measuring for logging duration
+		elapsed := clock.Stopwatch()
+		logTraceContext(r.Context(), "%s %s headers: %v", r.Method, r.URL.Path, r.Header)
 
 		// Call the next handler in the chain
 		next.ServeHTTP(w, r)
 
-		duration := time.Since(start)
+		duration := elapsed()
 
-		log.Printf("Handled %s %s in %v", r.Method, r.URL.Path, duration)
+		logger.LogAttrs(r.Context(), levelInfo, "Handled request",
+			slog.String("method", r.Method),
+			slog.String("route", routeTemplate(r)),
+			slog.String("path", r.URL.Path),
+			slog.String("client", clientIP(r)),
+			slog.Float64("duration_seconds", duration.Seconds()))
+	})
+}
+
+// requestIDHeader carries the request ID: clients and proxies may send one to correlate their logs with
+// ours, and every response returns the ID the request was logged under
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID limits client-chosen IDs to something safe to echo and to log
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestID gives every request an ID, reusing a well-formed X-Request-ID from the client, puts it in the
+// request context so every log line about the request carries it, and returns it in X-Request-ID
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// newRequestID returns 16 random hex digits
+func newRequestID() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// taskStoreKey is the request context key WithTaskStore uses
+type taskStoreKey struct{}
 
+// WithTaskStore serves next against store instead of the package-wide taskStore,
+// so several servers in one process can each keep their own tasks
+func WithTaskStore(next http.Handler, store TaskStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), taskStoreKey{}, store)))
 	})
+}
 
+// storeFor returns the store r was routed to by WithTaskStore, or taskStore if none
+func storeFor(r *http.Request) TaskStore {
+	if store, ok := r.Context().Value(taskStoreKey{}).(TaskStore); ok {
+		return store
+	}
+	return taskStore
 }
 
 // ValidateJSON ensures the request Content-Type is application/json
@@ -31,7 +90,7 @@ func ValidateJSON(next http.Handler, methods ...string) http.Handler {
 			if r.Method == method {
 				// Check if the Content-Type header is not application/json
 				if r.Header.Get("Content-Type") != "application/json" {
-					http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+					writeJsonError(w, http.StatusUnsupportedMediaType, "Unsupported Media Type")
 					return
 				}
 				break
@@ -41,3 +100,133 @@ func ValidateJSON(next http.Handler, methods ...string) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// routeTemplate returns a low-cardinality label for the route that served r, such as /tasks/{id},
+// so per-route logs and metrics don't grow a new series for every task ID.
+// The router's pattern is used when it carries wildcards; otherwise numeric path segments are replaced.
+func routeTemplate(r *http.Request) string {
+	if strings.Contains(r.Pattern, "{") {
+		pattern := r.Pattern
+		// Drop the "METHOD " prefix of method-qualified patterns
+		if i := strings.Index(pattern, " "); i != -1 {
+			pattern = pattern[i+1:]
+		}
+		return pattern
+	}
+
+	parts := strings.Split(path.Clean("/"+r.URL.Path), "/")
+	for i, part := range parts {
+		if _, err := strconv.Atoi(part); err != nil {
+			continue
+		}
+		if i > 0 && parts[i-1] == "checklist" {
+			parts[i] = "{item}"
+		} else {
+			parts[i] = "{id}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// bufferedResponse is an http.ResponseWriter that holds the status, headers, and body in memory
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+// degradedCacheLimit bounds how many distinct GET URLs ResponseBudget keeps a fallback response for
+const degradedCacheLimit = 256
+
+// cachedResponse is the last successful response for a GET URL, replayed when the handler runs over budget
+type cachedResponse struct {
+	header http.Header
+	status int
+	body   []byte
+}
+
+// ResponseBudget gives GET requests a soft time budget. If the handler overruns it, the last successful
+// response for the same URL is served with an X-Degraded header; with nothing cached the client gets a 504.
+// The slow handler keeps running in the background and refreshes the cache when it finishes.
+// Other methods pass straight through, since a timed-out mutation may still have been applied.
+func ResponseBudget(next http.Handler, budget time.Duration) http.Handler {
+	var (
+		cacheMu sync.Mutex
+		cache   = make(map[string]cachedResponse)
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Users see different tasks at the same URL, so one user's response is never served to another
+		key := userFor(r) + " " + r.URL.String()
+		buffered := newBufferedResponse()
+		done := make(chan struct{})
+		// The handler may outlive the budget, so it gets its own copy of the request: r is still
+		// read here and by the middleware outside after ServeHTTP returns
+		inner := r.Clone(r.Context())
+		go func() {
+			defer close(done)
+			next.ServeHTTP(buffered, inner)
+			if buffered.status < 200 || buffered.status >= 300 {
+				return
+			}
+			cacheMu.Lock()
+			defer cacheMu.Unlock()
+			if _, ok := cache[key]; !ok && len(cache) >= degradedCacheLimit {
+				// Evict an arbitrary entry to stay bounded
+				for k := range cache {
+					delete(cache, k)
+					break
+				}
+			}
+			cache[key] = cachedResponse{header: buffered.header.Clone(), status: buffered.status, body: buffered.body.Bytes()}
+		}()
+
+		timer := time.NewTimer(budget)
+		defer timer.Stop()
+		select {
+		case <-done:
+			writeBufferedResponse(w, buffered.header, buffered.status, buffered.body.Bytes())
+		case <-timer.C:
+			cacheMu.Lock()
+			cached, ok := cache[key]
+			cacheMu.Unlock()
+			if !ok {
+				logErrorContext(r.Context(), "%s %s exceeded its %v budget with no cached response", r.Method, r.URL.Path, budget)
+				writeJsonError(w, http.StatusGatewayTimeout, "Request exceeded its time budget")
+				return
+			}
+			logErrorContext(r.Context(), "%s %s exceeded its %v budget, serving cached response", r.Method, r.URL.Path, budget)
+			w.Header().Set("X-Degraded", "true")
+			writeBufferedResponse(w, cached.header, cached.status, cached.body)
+		}
+	})
+}
+
+// writeBufferedResponse copies a captured response onto w
+func writeBufferedResponse(w http.ResponseWriter, header http.Header, status int, body []byte) {
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type routeTemplateTestCase struct {
+	name    string // Test case name
+	pattern string // Pattern the router matched, if any
+	url     string // Requested URL
+	want    string // Expected route template
+}
+
+var routeTemplateTests = []routeTemplateTestCase{
+	{
+		name: "Collection",
+		url:  "/tasks",
+		want: "/tasks",
+	},
+	{
+		name: "Task ID",
+		url:  "/tasks/12345",
+		want: "/tasks/{id}",
+	},
+	{
+		name: "Trailing Slash",
+		url:  "/tasks/12345/",
+		want: "/tasks/{id}",
+	},
+	{
+		name: "Checklist Item",
+		url:  "/tasks/7/checklist/3",
+		want: "/tasks/{id}/checklist/{item}",
+	},
+	{
+		name:    "Router Pattern",
+		pattern: "GET /tasks/{id}",
+		url:     "/tasks/42",
+		want:    "/tasks/{id}",
+	},
+}
+
+func TestRouteTemplate(t *testing.T) {
+	for _, tt := range routeTemplateTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			req.Pattern = tt.pattern
+
+			if got := routeTemplate(req); got != tt.want {
+				t.Errorf("Test %s: got %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseBudget(t *testing.T) {
+	var slow atomic.Bool
+	release := make(chan struct{})
+	handler := ResponseBudget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if slow.Load() {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}), 20*time.Millisecond)
+	defer close(release)
+
+	// A fast response passes through and primes the cache
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Degraded") != "" {
+		t.Fatalf("got status %d, X-Degraded %q on fast path", rec.Code, rec.Header().Get("X-Degraded"))
+	}
+
+	// Over budget with a cached copy serves the stale response
+	slow.Store(true)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Degraded") != "true" || rec.Body.String() != `[]` {
+		t.Errorf("got status %d, X-Degraded %q, body %s; want cached degraded response", rec.Code, rec.Header().Get("X-Degraded"), rec.Body.String())
+	}
+
+	// Over budget with nothing cached times out
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tasks?fields=id", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("got status %d for uncached slow request, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+}
+
+func TestResponseBudgetKeepsOuterRequest(t *testing.T) {
+	release := make(chan struct{})
+	handler := ResponseBudget(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Keep running past the budget, changing the request the way a rewriting handler would
+		r.URL.Path = "/rewritten"
+		<-release
+	}), 20*time.Millisecond)
+	defer close(release)
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if req.URL.Path != "/tasks" {
+		t.Errorf("got outer path %q after the budget ran out, want /tasks", req.URL.Path)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+)
+
+// openAPISpec is the hand-maintained OpenAPI 3 description of the public API. TestOpenAPICoversRoutes
+// keeps it in step with the README's endpoint table and the Task type.
+//
+//go:embed api/openapi.json
+var openAPISpec []byte
+
+// docsUIFiles is the page at /docs that renders the spec
+//
+//go:embed ui/docs
+var docsUIFiles embed.FS
+
+// docsCSP loosens the default policy just enough for the docs page to load its own script and
+// stylesheet and fetch the spec
+const docsCSP = "default-src 'none'; script-src 'self'; style-src 'self'; connect-src 'self'; frame-ancestors 'none'"
+
+// OpenAPI serves GET /openapi.json, with the server URL of the host the client used
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		logErrorContext(r.Context(), "Invalid embedded OpenAPI spec: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to load API description")
+		return
+	}
+	spec["servers"] = []map[string]string{{"url": requestBaseURL(r)}}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(spec)
+}
+
+// Docs serves the API reference page under /docs/, which renders /openapi.json in the browser
+func Docs() http.Handler {
+	assets, err := fs.Sub(docsUIFiles, "ui/docs")
+	if err != nil {
+		panic(err)
+	}
+	site, err := newStaticSite(assets)
+	if err != nil {
+		panic(err)
+	}
+	files := http.StripPrefix("/docs/", site)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/docs" {
+			http.Redirect(w, r, "/docs/", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Security-Policy", docsCSP)
+		files.ServeHTTP(w, r)
+	})
+}
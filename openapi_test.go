@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readmeEndpoint matches a row of the README's endpoint table, e.g. "| GET    | `/tasks/{id}/subtasks` | ..."
+var readmeEndpoint = regexp.MustCompile("^\\| (GET|POST|PUT|DELETE) +\\| `([^`?]+)")
+
+type openAPIDocument struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+	server, client := StartTestServer(t)
+
+	status, body := client.Get("/openapi.json")
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want %d", status, http.StatusOK)
+	}
+	var doc openAPIDocument
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	if len(doc.Servers) != 1 || doc.Servers[0].URL != server.URL {
+		t.Errorf("servers = %+v, want %s", doc.Servers, server.URL)
+	}
+
+	mux := SecurityHeaders(newPublicMux(0), defaultSecurityHeaders)
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		contains     string
+	}{
+		{"Page", "/docs/", http.StatusOK, "<title>Task Tracker API</title>"},
+		{"Script", "/docs/docs.js", http.StatusOK, `fetch("/openapi.json")`},
+		{"Without slash", "/docs", http.StatusMovedPermanently, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.expectedCode {
+				t.Errorf("expected status %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.contains) {
+				t.Errorf("expected body to contain %q, got %q", tt.contains, rec.Body.String())
+			}
+			if tt.expectedCode == http.StatusOK && rec.Header().Get("Content-Security-Policy") != docsCSP {
+				t.Errorf("CSP = %q, want %q", rec.Header().Get("Content-Security-Policy"), docsCSP)
+			}
+		})
+	}
+}
+
+func TestOpenAPIIsPublic(t *testing.T) {
+	useUsers(t, "alice")
+	_, client := StartTestServer(t)
+	for _, path := range []string{"/openapi.json", "/docs/"} {
+		if status, body := client.Get(path); status != http.StatusOK {
+			t.Errorf("GET %s without a key = %d %s, want 200", path, status, body)
+		}
+	}
+}
+
+// TestOpenAPICoversRoutes keeps the hand-maintained spec in step with the API: every endpoint in the README
+// is described, every described /tasks path is routed, and every reference resolves
+func TestOpenAPICoversRoutes(t *testing.T) {
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+
+	readme, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, line := range strings.Split(string(readme), "\n") {
+		m := readmeEndpoint.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(m[2], "/admin/") {
+			continue
+		}
+		found++
+		if _, ok := doc.Paths[m[2]][strings.ToLower(m[1])]; !ok {
+			t.Errorf("%s %s is in the README but not in api/openapi.json", m[1], m[2])
+		}
+	}
+	if found < 30 {
+		t.Fatalf("found only %d endpoints in the README; has the table changed format?", found)
+	}
+
+	placeholders := strings.NewReplacer("{id}", "1", "{item}", "1")
+	for path, item := range doc.Paths {
+		if !strings.HasPrefix(path, "/tasks") || path == "/tasks/health" {
+			continue
+		}
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			r := httptest.NewRequest(strings.ToUpper(method), placeholders.Replace(path), nil)
+			if pattern := taskRoute(r); pattern != strings.ToUpper(method)+" "+path {
+				t.Errorf("%s %s is routed to %q", strings.ToUpper(method), path, pattern)
+			}
+		}
+	}
+
+	var spec struct {
+		Components map[string]map[string]json.RawMessage `json:"components"`
+	}
+	json.Unmarshal(openAPISpec, &spec)
+	for _, ref := range regexp.MustCompile(`"\$ref": "#/components/(\w+)/(\w+)"`).FindAllStringSubmatch(string(openAPISpec), -1) {
+		if _, ok := spec.Components[ref[1]][ref[2]]; !ok {
+			t.Errorf("unresolved reference #/components/%s/%s", ref[1], ref[2])
+		}
+	}
+}
+
+func TestOpenAPITaskSchema(t *testing.T) {
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+	var documented []string
+	for name := range doc.Components.Schemas["Task"].Properties {
+		documented = append(documented, name)
+	}
+	var fields []string
+	taskType := reflect.TypeOf(Task{})
+	for i := 0; i < taskType.NumField(); i++ {
+		fields = append(fields, strings.Split(taskType.Field(i).Tag.Get("json"), ",")[0])
+	}
+	sort.Strings(documented)
+	sort.Strings(fields)
+	if !reflect.DeepEqual(documented, fields) {
+		t.Errorf("Task schema properties = %v, want the Task fields %v", documented, fields)
+	}
+}
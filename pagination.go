@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit is the page size when ?offset= or ?after_id= is given without ?limit=
+	defaultPageLimit = 100
+	// maxPageLimit bounds ?limit= so a single page stays a reasonable size
+	maxPageLimit = 1000
+)
+
+// pageQuery selects one page of GET /tasks. Offset and AfterID are mutually exclusive;
+// AfterID is a cursor naming the last task of the previous page.
+type pageQuery struct {
+	Limit   int
+	Offset  int
+	AfterID int
+}
+
+// taskPage is the GET /tasks response body when pagination was requested
+type taskPage struct {
+	Tasks json.RawMessage `json:"tasks"`
+	pageMeta
+}
+
+// pageMeta says where a page sits in its list. Paginated responses embed it next to their items, so
+// one piece of client code can page through any list.
+type pageMeta struct {
+	// Total counts every item matching the filters, not just this page
+	Total int `json:"total"`
+	// TotalPages is how many pages of this size the list fills
+	TotalPages int  `json:"total_pages"`
+	HasMore    bool `json:"has_more"`
+	// NextCursor is the ?after_id= of the following page and Next its URL, both omitted on the last page
+	NextCursor int    `json:"next_cursor,omitempty"`
+	Next       string `json:"next,omitempty"`
+}
+
+// newPageMeta describes a page of a list of total items that ends at index end, where lastID is the
+// ID of the page's last item
+func newPageMeta(u *url.URL, p pageQuery, total, end, lastID int) pageMeta {
+	meta := pageMeta{Total: total, TotalPages: (total + p.Limit - 1) / p.Limit, HasMore: end < total}
+	if meta.HasMore {
+		meta.NextCursor = lastID
+		meta.Next = nextPageURL(u, p, lastID, end)
+	}
+	return meta
+}
+
+// cursorError reports an ?after_id= naming a task that isn't in the filtered list, usually because it was deleted
+type cursorError struct {
+	id int
+}
+
+func (e *cursorError) Error() string {
+	return fmt.Sprintf("after_id %d is not in the list", e.id)
+}
+
+// parsePageQuery reads ?limit=, ?offset=, and ?after_id=, returning nil when none is present
+// so unpaginated clients keep getting a plain array
+func parsePageQuery(params *queryParams) *pageQuery {
+	if params.String("limit", "") == "" && params.String("offset", "") == "" && params.String("after_id", "") == "" {
+		return nil
+	}
+	page := &pageQuery{
+		Limit:   params.Int("limit", defaultPageLimit, 1, maxPageLimit),
+		Offset:  params.Int("offset", 0, 0, maxTaskID),
+		AfterID: params.Int("after_id", 0, 1, maxTaskID),
+	}
+	if page.Offset > 0 && page.AfterID > 0 {
+		params.Check(fmt.Errorf("offset and after_id cannot be combined"))
+	}
+	return page
+}
+
+// paginate returns the requested page of list and the index just past it
+func paginate(list []Task, p pageQuery) ([]Task, int, error) {
+	start := p.Offset
+	if p.AfterID > 0 {
+		start = -1
+		for i, t := range list {
+			if t.ID == p.AfterID {
+				start = i + 1
+				break
+			}
+		}
+		if start == -1 {
+			return nil, 0, &cursorError{id: p.AfterID}
+		}
+	}
+	if start > len(list) {
+		start = len(list)
+	}
+	end := start + p.Limit
+	if end > len(list) {
+		end = len(list)
+	}
+	return list[start:end], end, nil
+}
+
+// nextPageURL links to the page after one ending at index end, keeping the request's other parameters.
+// Offset pages continue by offset; everything else continues from the last task's ID.
+func nextPageURL(u *url.URL, p pageQuery, lastID, end int) string {
+	values := u.Query()
+	values.Set("limit", strconv.Itoa(p.Limit))
+	values.Del("offset")
+	values.Del("after_id")
+	if p.Offset > 0 {
+		values.Set("offset", strconv.Itoa(end))
+	} else {
+		values.Set("after_id", strconv.Itoa(lastID))
+	}
+	return u.Path + "?" + values.Encode()
+}
+
+// marshalTaskPage encodes one page of the tasks selected by q with its pageMeta
+func marshalTaskPage(u *url.URL, list []Task, q taskListQuery) ([]byte, error) {
+	selected := selectTasks(list, q)
+	page, end, err := paginate(selected, *q.Page)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []byte
+	if q.Fields != nil {
+		tasks, err = marshalTasksProjected(page, q.Fields)
+	} else {
+		tasks, err = json.Marshal(page)
+	}
+	if err != nil {
+		return nil, err
+	}
+	lastID := 0
+	if len(page) > 0 {
+		lastID = page[len(page)-1].ID
+	}
+	body := taskPage{Tasks: tasks, pageMeta: newPageMeta(u, *q.Page, len(selected), end, lastID)}
+	// Keep the & in next links readable rather than \u0026
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(body); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	useTasks(t, []Task{
+		{ID: 1, Title: "One"},
+		{ID: 2, Title: "Two", Completed: true},
+		{ID: 3, Title: "Three"},
+		{ID: 4, Title: "Four"},
+		{ID: 5, Title: "Five", Completed: true},
+	})
+
+	tests := []struct {
+		name       string // Test case name
+		query      string // Query string of the GET /tasks request
+		wantStatus int    // Expected HTTP status code
+		wantBody   string // Expected response body
+	}{
+		{"First Page", "limit=2&fields=id", http.StatusOK, `{"tasks":[{"id":1},{"id":2}],"total":5,"total_pages":3,"has_more":true,"next_cursor":2,"next":"/tasks?after_id=2&fields=id&limit=2"}`},
+		{"Cursor Page", "limit=2&after_id=2&fields=id", http.StatusOK, `{"tasks":[{"id":3},{"id":4}],"total":5,"total_pages":3,"has_more":true,"next_cursor":4,"next":"/tasks?after_id=4&fields=id&limit=2"}`},
+		{"Last Page Has No Next", "limit=2&after_id=4&fields=id", http.StatusOK, `{"tasks":[{"id":5}],"total":5,"total_pages":3,"has_more":false}`},
+		{"Offset Page", "limit=2&offset=2&fields=id", http.StatusOK, `{"tasks":[{"id":3},{"id":4}],"total":5,"total_pages":3,"has_more":true,"next_cursor":4,"next":"/tasks?fields=id&limit=2&offset=4"}`},
+		{"Offset Past End", "offset=10", http.StatusOK, `{"tasks":[],"total":5,"total_pages":1,"has_more":false}`},
+		{"Total Counts Filtered Tasks", "completed=true&limit=1", http.StatusOK, `{"tasks":[{"id":2,"title":"Two","completed":true}],"total":2,"total_pages":2,"has_more":true,"next_cursor":2,"next":"/tasks?after_id=2&completed=true&limit=1"}`},
+		{"Cursor Follows Sort", "sort=title&limit=2&after_id=4&fields=id", http.StatusOK, `{"tasks":[{"id":1},{"id":3}],"total":5,"total_pages":3,"has_more":true,"next_cursor":3,"next":"/tasks?after_id=3&fields=id&limit=2&sort=title"}`},
+		{"Empty List", "completed=true&q=nothing&limit=2", http.StatusOK, `{"tasks":[],"total":0,"total_pages":0,"has_more":false}`},
+		{"Unknown Cursor", "after_id=9", http.StatusBadRequest, `{"error":"after_id 9 is not in the list","code":"invalid_request"}`},
+		{"Bad Limit", "limit=0&offset=1&after_id=1", http.StatusBadRequest, `{"error":"limit must be 1..1000; offset and after_id cannot be combined","code":"invalid_request"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
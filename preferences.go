@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Preferences are a user's defaults for the task list. GET /tasks applies each one unless the request
+// sets the same query parameter itself; an empty parameter such as ?completed= overrides a default filter.
+type Preferences struct {
+	// Sort is the default ?sort=
+	Sort string `json:"sort,omitempty"`
+	// Filters are default list filters keyed by query parameter: q, completed, available, overdue,
+	// priority, and tag, whose value may list several tags separated by commas
+	Filters map[string]string `json:"filters,omitempty"`
+	// PageSize is the default ?limit=; setting it makes GET /tasks return pages
+	PageSize int `json:"page_size,omitempty"`
+	// Timezone is the IANA name clients should display dates in, e.g. "Europe/Paris"
+	Timezone string `json:"timezone,omitempty"`
+	// Notifications are kept for clients that notify the user; the server itself sends none
+	Notifications NotificationPreferences `json:"notifications"`
+}
+
+// NotificationPreferences say which reminders a user wants
+type NotificationPreferences struct {
+	DueSoon     bool `json:"due_soon"`
+	Overdue     bool `json:"overdue"`
+	DailyDigest bool `json:"daily_digest"`
+}
+
+// preferenceFilters are the GET /tasks parameters a preference may default
+var preferenceFilters = []string{"q", "completed", "available", "overdue", "priority", "tag"}
+
+var (
+	// userPreferences is keyed by user name, "" when authentication is off
+	userPreferences = map[string]Preferences{}
+	preferenceMutex sync.Mutex
+	// preferencesFile is where preferences are saved after every change; empty keeps them in memory only
+	preferencesFile string
+)
+
+// LoadPreferencesFromFile reads saved preferences; a missing file means nobody has set any
+func LoadPreferencesFromFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	preferenceMutex.Lock()
+	defer preferenceMutex.Unlock()
+	if err := json.Unmarshal(data, &userPreferences); err != nil {
+		return err
+	}
+	logInfo("Preferences loaded successfully from %s", filename)
+	return nil
+}
+
+// savePreferencesLocked writes every user's preferences to preferencesFile. The caller holds preferenceMutex.
+func savePreferencesLocked() error {
+	if preferencesFile == "" {
+		return nil
+	}
+	data, err := marshalCanonical(userPreferences)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(preferencesFile, 0644, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// preferencesFor returns the user's preferences, the zero value if they have set none
+func preferencesFor(user string) Preferences {
+	preferenceMutex.Lock()
+	defer preferenceMutex.Unlock()
+	return userPreferences[user]
+}
+
+// validate checks the preferences the same way GET /tasks would check the parameters they stand for
+func (p Preferences) validate() error {
+	for name := range p.Filters {
+		known := false
+		for _, filter := range preferenceFilters {
+			known = known || name == filter
+		}
+		if !known {
+			return fmt.Errorf("Unknown filter %q, must be one of %s", name, strings.Join(preferenceFilters, ", "))
+		}
+	}
+	if p.PageSize < 0 || p.PageSize > maxPageLimit {
+		return fmt.Errorf("page_size must be 0..%d", maxPageLimit)
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("Unknown timezone %q", p.Timezone)
+		}
+	}
+	_, err := parseTaskListQuery(p.apply(url.Values{}))
+	return err
+}
+
+// apply returns values with the preferences filled in wherever the request left a parameter out
+func (p Preferences) apply(values url.Values) url.Values {
+	merged := url.Values{}
+	for name, v := range values {
+		merged[name] = v
+	}
+	setDefault := func(name string, value ...string) {
+		if _, set := merged[name]; !set && len(value) > 0 && value[0] != "" {
+			merged[name] = value
+		}
+	}
+	setDefault("sort", p.Sort)
+	for name, value := range p.Filters {
+		if name == "tag" {
+			setDefault(name, strings.Split(value, ",")...)
+		} else {
+			setDefault(name, value)
+		}
+	}
+	if p.PageSize > 0 {
+		setDefault("limit", strconv.Itoa(p.PageSize))
+	}
+	return merged
+}
+
+// UserPreferences serves /me/preferences for the authenticated user, or for everyone when authentication is off.
+//
+//	GET    /me/preferences  the current preferences
+//	PUT    /me/preferences  replace them
+//	DELETE /me/preferences  reset them to the defaults
+func UserPreferences(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	user := userFor(r)
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+			return
+		}
+		var prefs Preferences
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&prefs); err != nil {
+			writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+		if err := prefs.validate(); err != nil {
+			logErrorContext(r.Context(), "Invalid preferences: %v", err)
+			writeJsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !setPreferences(w, user, &prefs) {
+			return
+		}
+	case "DELETE":
+		if !setPreferences(w, user, nil) {
+			return
+		}
+	default:
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preferencesFor(user))
+}
+
+// setPreferences stores prefs for user, or removes them when nil, restoring the old ones if the save fails
+func setPreferences(w http.ResponseWriter, user string, prefs *Preferences) bool {
+	preferenceMutex.Lock()
+	defer preferenceMutex.Unlock()
+	original, existed := userPreferences[user]
+	if prefs == nil {
+		delete(userPreferences, user)
+	} else {
+		userPreferences[user] = *prefs
+	}
+	if err := savePreferencesLocked(); err != nil {
+		if existed {
+			userPreferences[user] = original
+		} else {
+			delete(userPreferences, user)
+		}
+		logError("Failed to save preferences: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to save preferences")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// usePreferences starts the test with no preferences, saved to a temp file
+func usePreferences(t *testing.T) string {
+	t.Helper()
+	original, originalFile := userPreferences, preferencesFile
+	userPreferences, preferencesFile = map[string]Preferences{}, filepath.Join(t.TempDir(), "preferences.json")
+	t.Cleanup(func() { userPreferences, preferencesFile = original, originalFile })
+	return preferencesFile
+}
+
+func titlesOf(t *testing.T, body string) []string {
+	t.Helper()
+	var list []Task
+	if err := json.Unmarshal([]byte(body), &list); err != nil {
+		var page struct{ Tasks []Task }
+		if err := json.Unmarshal([]byte(body), &page); err != nil {
+			t.Fatalf("invalid task list %s: %v", body, err)
+		}
+		list = page.Tasks
+	}
+	titles := []string{}
+	for _, task := range list {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
+
+func TestPreferences(t *testing.T) {
+	filename := usePreferences(t)
+	_, client := StartTestServer(t)
+	for _, task := range []string{
+		`{"title": "Banana", "tags": ["work"]}`,
+		`{"title": "Apple", "tags": ["work"]}`,
+		`{"title": "Cherry", "completed": true, "tags": ["work"]}`,
+		`{"title": "Date"}`,
+	} {
+		client.Post("/tasks", task)
+	}
+
+	status, body := client.Put("/me/preferences", `{"sort": "title", "filters": {"completed": "false", "tag": "work"}, "timezone": "Europe/Paris", "notifications": {"due_soon": true}}`)
+	if status != http.StatusOK {
+		t.Fatalf("PUT got %d %s", status, body)
+	}
+	if data, err := os.ReadFile(filename); err != nil || !strings.Contains(string(data), "Europe/Paris") {
+		t.Errorf("preferences were not saved: %s %v", data, err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"Defaults applied", "/tasks", "Apple,Banana"},
+		{"Sort overridden", "/tasks?sort=", "Banana,Apple"},
+		{"Filter overridden", "/tasks?completed=true", "Cherry"},
+		{"Filter cleared", "/tasks?completed=&tag=", "Apple,Banana,Cherry,Date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := client.Get(tt.path)
+			if status != http.StatusOK {
+				t.Fatalf("got %d %s", status, body)
+			}
+			if got := strings.Join(titlesOf(t, body), ","); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// A page size makes the list paginated
+	client.Put("/me/preferences", `{"page_size": 2}`)
+	status, body = client.Get("/tasks")
+	var page taskPage
+	if err := json.Unmarshal([]byte(body), &page); status != http.StatusOK || err != nil || page.Total != 4 || page.Next == "" {
+		t.Errorf("got %d %s, want the first page of 4 tasks", status, body)
+	}
+
+	// Resetting restores the defaults
+	if status, body := client.Delete("/me/preferences"); status != http.StatusOK || strings.Contains(body, "page_size") {
+		t.Errorf("DELETE got %d %s", status, body)
+	}
+	if _, body := client.Get("/tasks"); len(titlesOf(t, body)) != 4 {
+		t.Errorf("after reset got %s", body)
+	}
+}
+
+func TestPreferencesValidation(t *testing.T) {
+	usePreferences(t)
+	_, client := StartTestServer(t)
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Unknown sort", `{"sort": "color"}`},
+		{"Unknown filter", `{"filters": {"colour": "red"}}`},
+		{"Bad filter value", `{"filters": {"completed": "maybe"}}`},
+		{"Page size too big", `{"page_size": 100000}`},
+		{"Unknown timezone", `{"timezone": "Mars/Olympus"}`},
+		{"Unknown field", `{"theme": "dark"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status, body := client.Put("/me/preferences", tt.body); status != http.StatusBadRequest {
+				t.Errorf("got %d %s, want 400", status, body)
+			}
+		})
+	}
+}
+
+func TestPreferencesPerUser(t *testing.T) {
+	usePreferences(t)
+	useUsers(t, "alice", "bob")
+	server, alice := StartTestServer(t)
+	alice.Key = "alice-key"
+	bob := &Client{t: t, baseURL: server.URL, http: server.Client(), Key: "bob-key"}
+	alice.Post("/tasks", `{"title": "Open"}`)
+	alice.Post("/tasks", `{"title": "Done", "completed": true}`)
+	bob.Post("/tasks", `{"title": "Open"}`)
+	bob.Post("/tasks", `{"title": "Done", "completed": true}`)
+
+	alice.Put("/me/preferences", `{"filters": {"completed": "true"}}`)
+	if _, body := alice.Get("/tasks"); strings.Join(titlesOf(t, body), ",") != "Done" {
+		t.Errorf("alice got %s", body)
+	}
+	if _, body := bob.Get("/tasks"); len(titlesOf(t, body)) != 2 {
+		t.Errorf("bob got %s, want alice's preferences not to apply", body)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// printStyle is the print page's stylesheet. It is inlined so the page is a single file that can be
+// saved or printed offline, and allowed by its hash in printCSP.
+const printStyle = `
+body { font: 11pt/1.4 Georgia, serif; margin: 2em auto; max-width: 42em; color: #000; }
+header { border-bottom: 1px solid #000; margin-bottom: 1em; }
+h1 { font-size: 16pt; margin: 0; }
+header p { margin: 0.2em 0 0.6em; font-size: 9pt; color: #444; }
+h2 { font-size: 12pt; margin: 1.2em 0 0.3em; break-after: avoid; }
+ul { list-style: none; margin: 0; padding: 0; }
+li { margin: 0.25em 0; break-inside: avoid; }
+li li { margin-left: 1.6em; font-size: 10pt; }
+.box { display: inline-block; width: 1.2em; font-family: sans-serif; }
+.done { text-decoration: line-through; color: #555; }
+.meta { font-size: 9pt; color: #444; margin-left: 0.5em; }
+@page { margin: 1.5cm; }
+@media print { body { margin: 0; max-width: none; } }
+`
+
+var printCSP = "default-src 'none'; style-src '" + styleHash(printStyle) + "'; frame-ancestors 'none'"
+
+var printTemplate = template.Must(template.New("print").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Tasks</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+<header>
+<h1>Tasks</h1>
+<p>{{.Count}} task{{if ne .Count 1}}s{{end}}{{with .Filter}} matching {{.}}{{end}}, printed {{.Printed}}</p>
+</header>
+{{range .Groups}}
+<h2>{{.Project}}</h2>
+<ul>
+{{range .Tasks}}<li{{if .Completed}} class="done"{{end}}><span class="box">{{if .Completed}}&#9745;{{else}}&#9744;{{end}}</span>{{.Title}}
+{{- if .DueDate}}<span class="meta">due {{slice .DueDate 0 10}}</span>{{end}}
+{{- if .Priority}}<span class="meta">{{.Priority}} priority</span>{{end}}
+{{- if .Checklist}}
+<ul>{{range .Checklist}}<li{{if .Done}} class="done"{{end}}><span class="box">{{if .Done}}&#9745;{{else}}&#9744;{{end}}</span>{{.Text}}</li>{{end}}</ul>
+{{- end}}</li>
+{{end}}</ul>
+{{else}}
+<p>No tasks.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// printGroup is one project's tasks on the print page
+type printGroup struct {
+	Project string
+	Tasks   []Task
+}
+
+// noProject heads the group of tasks without tags
+const noProject = "No project"
+
+// PrintTasks serves GET /tasks/print, a plain HTML page of the caller's tasks for printing or saving
+// as a PDF from the browser. It takes the GET /tasks filters and ?sort=, and groups tasks by project,
+// a task's first tag, with untagged tasks last.
+func PrintTasks(w http.ResponseWriter, r *http.Request) {
+	query, err := parseTaskListQuery(r.URL.Query())
+	if err == nil && (query.Fields != nil || query.Page != nil) {
+		err = errors.New("print doesn't support fields, limit, offset, or after_id")
+	}
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tasks := selectTasks(storeFor(r).List(), query)
+	var buf bytes.Buffer
+	err = printTemplate.Execute(&buf, map[string]interface{}{
+		"Style":   template.CSS(printStyle),
+		"Count":   len(tasks),
+		"Filter":  describeFilters(r),
+		"Printed": clock.Now().Format("2 Jan 2006 15:04 MST"),
+		"Groups":  groupByProject(tasks),
+	})
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to render print page: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to render tasks")
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", printCSP)
+	w.Write(buf.Bytes())
+}
+
+// groupByProject groups tasks by their first tag, keeping their order within each group. Groups are
+// in name order with untagged tasks last.
+func groupByProject(tasks []Task) []printGroup {
+	index := map[string]int{}
+	var groups []printGroup
+	for _, t := range tasks {
+		project := noProject
+		if len(t.Tags) > 0 {
+			project = t.Tags[0]
+		}
+		i, ok := index[project]
+		if !ok {
+			i = len(groups)
+			index[project] = i
+			groups = append(groups, printGroup{Project: project})
+		}
+		groups[i].Tasks = append(groups[i].Tasks, t)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Project == noProject) != (groups[j].Project == noProject) {
+			return groups[j].Project == noProject
+		}
+		return groups[i].Project < groups[j].Project
+	})
+	return groups
+}
+
+// describeFilters lists the request's query parameters for the page header, such as "tag=work, completed=false"
+func describeFilters(r *http.Request) string {
+	values := r.URL.Query()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range values[name] {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// styleHash is the CSP source allowing an inline style element holding style
+func styleHash(style string) string {
+	sum := sha256.Sum256([]byte(style))
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum[:])
+}
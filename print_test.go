@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPrintTasks(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Buy <milk>", Tags: []string{"home"}},
+		{ID: 2, Title: "Report", Tags: []string{"work", "home"}, DueDate: "2024-05-20T09:00:00Z", Priority: "high",
+			Checklist: []ChecklistItem{{ID: 1, Text: "Draft", Done: true}, {ID: 2, Text: "Review"}}},
+		{ID: 3, Title: "Call Sam"},
+		{ID: 4, Title: "Filed", Tags: []string{"work"}, Completed: true},
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       []string // in order
+		wantAbsent []string
+	}{
+		{
+			name:       "Grouped",
+			wantStatus: http.StatusOK,
+			want: []string{
+				"<p>4 tasks, printed 1 May 2024 12:00 UTC</p>",
+				"<h2>home</h2>", "Buy &lt;milk&gt;",
+				"<h2>work</h2>", "Report", "due 2024-05-20", "high priority", `class="done"><span class="box">&#9745;</span>Draft`, "Review",
+				`<li class="done"><span class="box">&#9745;</span>Filed`,
+				"<h2>No project</h2>", "Call Sam",
+			},
+		},
+		{
+			name:       "Filtered",
+			query:      "?completed=false&tag=work",
+			wantStatus: http.StatusOK,
+			want:       []string{"<p>1 task matching completed=false, tag=work, printed", "<h2>work</h2>", "Report"},
+			wantAbsent: []string{"Filed", "Call Sam", "<h2>home</h2>"},
+		},
+		{
+			name:       "Nothing Matches",
+			query:      "?q=nothing",
+			wantStatus: http.StatusOK,
+			want:       []string{"<p>0 tasks matching q=nothing", "<p>No tasks.</p>"},
+		},
+		{"Page", "?limit=2", http.StatusBadRequest, []string{"print doesn't support fields, limit, offset, or after_id"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Tasks(rec, httptest.NewRequest("GET", "/tasks/print"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			body := rec.Body.String()
+			rest := body
+			for _, want := range tt.want {
+				i := strings.Index(rest, want)
+				if i == -1 {
+					t.Fatalf("body lacks %q after the earlier parts:\n%s", want, body)
+				}
+				rest = rest[i+len(want):]
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(body, absent) {
+					t.Errorf("body contains %q", absent)
+				}
+			}
+			if tt.wantStatus == http.StatusOK && rec.Header().Get("Content-Security-Policy") != printCSP {
+				t.Errorf("CSP = %q", rec.Header().Get("Content-Security-Policy"))
+			}
+		})
+	}
+}
+
+// The page's own style has to be the one its CSP allows, or browsers print it unstyled
+func TestPrintStyleHash(t *testing.T) {
+	rec := httptest.NewRecorder()
+	useTasks(t, nil)
+	Tasks(rec, httptest.NewRequest("GET", "/tasks/print", nil))
+	style := regexp.MustCompile(`(?s)<style>(.*?)</style>`).FindStringSubmatch(rec.Body.String())
+	if style == nil || !strings.Contains(printCSP, styleHash(style[1])) {
+		t.Errorf("inline style doesn't match the CSP hash %s", printCSP)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"sort"
+)
+
+// taskPriorities are the accepted Task.Priority values, lowest first
+var taskPriorities = []string{"low", "medium", "high"}
+
+// validatePriority checks that a set priority is one of taskPriorities
+func validatePriority(priority string) error {
+	if priority != "" && priorityRank(priority) == 0 {
+		return errors.New("priority must be low, medium, or high")
+	}
+	return nil
+}
+
+// priorityRank orders priorities from 0 for none up to len(taskPriorities) for high
+func priorityRank(priority string) int {
+	for i, p := range taskPriorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// sortTasksByPriority puts high priority tasks first and tasks without one last, keeping the stored order within a level
+func sortTasksByPriority(list []Task) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return priorityRank(list[i].Priority) > priorityRank(list[j].Priority)
+	})
+}
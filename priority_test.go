@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPriority(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Someday"},
+		{ID: 2, Title: "Soon", Priority: "medium"},
+		{ID: 3, Title: "Whenever", Priority: "low"},
+	})
+
+	tests := []struct {
+		name       string // Test case name
+		method     string // HTTP method
+		url        string // Request URL
+		payload    string // The JSON payload sent in the request
+		wantStatus int    // Expected HTTP status code
+		wantBody   string // Expected response body
+	}{
+		{"Create High Priority", http.MethodPost, "/tasks", `{"title": "Now", "priority": "high"}`, http.StatusCreated,
+			`{"id":4,"title":"Now","completed":false,"priority":"high","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Invalid Priority", http.MethodPost, "/tasks", `{"title": "Urgent", "priority": "urgent"}`, http.StatusBadRequest,
+			`{"error":"priority must be low, medium, or high","code":"validation_failed"}`},
+		{"Sort By Priority", http.MethodGet, "/tasks?sort=priority&fields=id", "", http.StatusOK, `[{"id":4},{"id":2},{"id":3},{"id":1}]`},
+		{"Filter By Priority", http.MethodGet, "/tasks?priority=medium&fields=id", "", http.StatusOK, `[{"id":2}]`},
+		{"Unknown Priority Filter", http.MethodGet, "/tasks?priority=urgent", "", http.StatusBadRequest,
+			`{"error":"Unknown priority \"urgent\", must be low or medium or high","code":"invalid_request"}`},
+		{"PUT Without Priority Keeps It", http.MethodPut, "/tasks/2", `{"title": "Soon-ish"}`, http.StatusOK,
+			`{"id":2,"title":"Soon-ish","completed":false,"priority":"medium","updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"PUT Null Clears It", http.MethodPut, "/tasks/2", `{"title": "Soon-ish", "priority": null}`, http.StatusOK,
+			`{"id":2,"title":"Soon-ish","completed":false,"updated_at":"2024-05-01T12:00:00Z","version":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// taskFieldNames lists Task's JSON field names in declaration order, the order projected objects use
+var taskFieldNames = jsonFieldNames(reflect.TypeOf(Task{}))
+
+// jsonFieldNames returns the JSON keys of a struct type's exported fields in declaration order
+func jsonFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// parseFieldsParam splits a ?fields=a,b,c value and rejects names that aren't Task fields
+func parseFieldsParam(raw string) ([]string, error) {
+	known := make(map[string]bool, len(taskFieldNames))
+	for _, name := range taskFieldNames {
+		known[name] = true
+	}
+	requested := make(map[string]bool)
+	var unknown []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		requested[name] = true
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("Unknown field(s): %s", strings.Join(unknown, ", "))
+	}
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("fields must name at least one field")
+	}
+	// Emit fields in declaration order regardless of the order they were requested in
+	fields := make([]string, 0, len(requested))
+	for _, name := range taskFieldNames {
+		if requested[name] {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// marshalTasksProjected encodes tasks as a JSON array keeping only the given fields.
+// Fields omitted by omitempty stay omitted.
+func marshalTasksProjected(list []Task, fields []string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, task := range list {
+		full, err := json.Marshal(task)
+		if err != nil {
+			return nil, err
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(full, &values); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		first := true
+		for _, name := range fields {
+			value, ok := values[name]
+			if !ok {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			key, _ := json.Marshal(name)
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
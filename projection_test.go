@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type projectionTestCase struct {
+	name       string // Test case name
+	query      string // Value of the fields query parameter
+	wantStatus int    // Expected HTTP status code
+	wantBody   string // Expected response body
+}
+
+var projectionTests = []projectionTestCase{
+	{
+		name:       "ID And Title",
+		query:      "id,title",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":1,"title":"Clean the carpet"},{"id":2,"title":"Pick up the groceries"}]`,
+	},
+	{
+		name:       "Declaration Order",
+		query:      "completed,id",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":1,"completed":false},{"id":2,"completed":true}]`,
+	},
+	{
+		name:       "Omitted Empty Field",
+		query:      "id,checklist_completion",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":1},{"id":2,"checklist_completion":100}]`,
+	},
+	{
+		name:       "Unknown Field",
+		query:      "id,comments,history",
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Unknown field(s): comments, history","code":"invalid_request"}`,
+	},
+	{
+		name:       "Only Separators",
+		query:      ",,",
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"fields must name at least one field","code":"invalid_request"}`,
+	},
+}
+
+func TestFieldProjection(t *testing.T) {
+	completion := 100
+	useTasks(t, []Task{
+		{ID: 1, Title: "Clean the carpet", Completed: false},
+		{ID: 2, Title: "Pick up the groceries", Completed: true, Checklist: []ChecklistItem{{ID: 1, Text: "Milk", Done: true}}, ChecklistCompletion: &completion},
+	})
+
+	for _, tt := range projectionTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?fields="+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Test %s: got status %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			}
+			gotBody := strings.TrimSpace(rec.Body.String())
+			if gotBody != tt.wantBody {
+				t.Errorf("Test %s: got body %s, want %s", tt.name, gotBody, tt.wantBody)
+			}
+		})
+	}
+}
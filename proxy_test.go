@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type clientIPTestCase struct {
+	name         string // Test case name
+	remoteAddr   string // Address of the connected peer
+	forwardedFor string // X-Forwarded-For header value
+	realIP       string // X-Real-IP header value
+	want         string // Expected client IP
+}
+
+var clientIPTests = []clientIPTestCase{
+	{
+		name:       "Direct Client",
+		remoteAddr: "203.0.113.7:51000",
+		want:       "203.0.113.7",
+	},
+	{
+		name:         "Untrusted Peer Ignores Headers",
+		remoteAddr:   "203.0.113.7:51000",
+		forwardedFor: "198.51.100.1",
+		want:         "203.0.113.7",
+	},
+	{
+		name:         "Trusted Proxy",
+		remoteAddr:   "10.0.0.5:443",
+		forwardedFor: "198.51.100.1",
+		want:         "198.51.100.1",
+	},
+	{
+		name:         "Spoofed Leftmost Entry",
+		remoteAddr:   "10.0.0.5:443",
+		forwardedFor: "1.2.3.4, 198.51.100.1",
+		want:         "198.51.100.1",
+	},
+	{
+		name:         "Chain Of Trusted Proxies",
+		remoteAddr:   "10.0.0.5:443",
+		forwardedFor: "198.51.100.1, 10.0.0.9",
+		want:         "198.51.100.1",
+	},
+	{
+		name:       "X-Real-IP Fallback",
+		remoteAddr: "10.0.0.5:443",
+		realIP:     "198.51.100.2",
+		want:       "198.51.100.2",
+	},
+	{
+		name:       "IPv6 Peer",
+		remoteAddr: "[2001:db8::1]:51000",
+		want:       "2001:db8::1",
+	},
+}
+
+func TestClientIP(t *testing.T) {
+	var err error
+	trustedProxies, err = parseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+	defer func() {
+		trustedProxies = nil
+	}()
+
+	for _, tt := range clientIPTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := clientIP(req); got != tt.want {
+				t.Errorf("Test %s: got %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsGarbage(t *testing.T) {
+	if _, err := parseTrustedProxies("10.0.0.0/8, not-an-ip"); err == nil {
+		t.Errorf("Expected an error for an invalid entry, got nil")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+var (
+	// authProxyHeader names the header an authenticating reverse proxy, such as oauth2-proxy's
+	// X-Auth-Request-User, sets to the signed-in user. Empty turns proxy authentication off.
+	authProxyHeader string
+	// authProxies are the peers whose authProxyHeader is believed. They are kept apart from
+	// trustedProxies: forwarding a client's address is a smaller grant than vouching for who it is.
+	authProxies []netip.Prefix
+)
+
+// fromAuthProxy reports whether r came straight from an authenticating proxy
+func fromAuthProxy(r *http.Request) bool {
+	if authProxyHeader == "" {
+		return false
+	}
+	peer, ok := peerAddr(r)
+	if !ok {
+		return false
+	}
+	for _, prefix := range authProxies {
+		if prefix.Contains(peer.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// findUserByIdentity returns the user an authenticating proxy's identity names: a user's name, or
+// one of their identities compared case-insensitively
+func findUserByIdentity(identity string) (User, bool) {
+	for _, u := range users {
+		if u.Name == identity {
+			return u, true
+		}
+		for _, alias := range u.Identities {
+			if strings.EqualFold(alias, identity) {
+				return u, true
+			}
+		}
+	}
+	return User{}, false
+}
+
+// authenticateProxied identifies a request from an authenticating proxy by its identity header,
+// responding 401 when the header is missing and 403 when it names nobody in the users file
+func authenticateProxied(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	identity := strings.TrimSpace(r.Header.Get(authProxyHeader))
+	if identity == "" {
+		logErrorContext(r.Context(), "Request for %s from auth proxy %s has no %s header", r.URL.Path, r.RemoteAddr, authProxyHeader)
+		writeJsonError(w, http.StatusUnauthorized, "Authentication required")
+		return r, false
+	}
+	user, ok := findUserByIdentity(identity)
+	if !ok {
+		logErrorContext(r.Context(), "No user for identity %q from auth proxy %s", identity, r.RemoteAddr)
+		writeJsonError(w, http.StatusForbidden, "No account for this identity")
+		return r, false
+	}
+	logDebugContext(r.Context(), "Auth proxy identified %q as user %s", identity, user.Name)
+	return withRole(withUser(r, user.Name), user.Role), true
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// useAuthProxy trusts header from the proxies for the test
+func useAuthProxy(t *testing.T, header string, proxies ...string) {
+	t.Helper()
+	originalHeader, originalProxies := authProxyHeader, authProxies
+	authProxyHeader, authProxies = header, nil
+	for _, proxy := range proxies {
+		authProxies = append(authProxies, netip.MustParsePrefix(proxy))
+	}
+	t.Cleanup(func() { authProxyHeader, authProxies = originalHeader, originalProxies })
+}
+
+func TestAuthProxy(t *testing.T) {
+	useUsers(t, "alice")
+	users = append(users,
+		User{Name: "bob", Role: roleReadOnly, Identities: []string{"Bob@Example.com"}},
+		User{Name: "carol", KeySHA256: hashAPIKey("carol-key"), Role: roleReadWrite})
+	useAuthProxy(t, "X-Auth-Request-User", "10.0.0.0/8")
+	useTasks(t, []Task{{ID: 1, Title: "Alice's", Owner: "alice"}, {ID: 2, Title: "Bob's", Owner: "bob"}})
+	handler := Authenticate(http.HandlerFunc(Tasks))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		method     string
+		identity   string
+		key        string
+		wantStatus int
+		wantBody   string
+	}{
+		{"By Name", "10.1.2.3:5000", "GET", "alice", "", http.StatusOK, `"title":"Alice's"`},
+		{"By Identity", "10.1.2.3:5000", "GET", "bob@example.com", "", http.StatusOK, `"title":"Bob's"`},
+		{"Role Still Applies", "10.1.2.3:5000", "POST", "bob@example.com", "", http.StatusForbidden, "needs the read-write role"},
+		{"Unknown Identity", "10.1.2.3:5000", "GET", "mallory", "", http.StatusForbidden, "No account for this identity"},
+		{"Missing Header", "10.1.2.3:5000", "GET", "", "alice-key", http.StatusUnauthorized, "Authentication required"},
+		{"Untrusted Peer Ignored", "192.0.2.1:5000", "GET", "alice", "", http.StatusUnauthorized, "Authentication required"},
+		{"Untrusted Peer With Key", "192.0.2.1:5000", "GET", "alice", "carol-key", http.StatusOK, `[]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/tasks", strings.NewReader(`{"title": "New"}`))
+			req.RemoteAddr = tt.remoteAddr
+			if tt.identity != "" {
+				req.Header.Set("X-Auth-Request-User", tt.identity)
+			}
+			if tt.key != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got %d %s, want %d containing %s", rec.Code, rec.Body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// queryParams binds URL query parameters to typed values, collecting every problem
+// so a client sees all of its mistakes in one 400 instead of fixing them one at a time
+type queryParams struct {
+	values   url.Values
+	problems []string
+}
+
+func newQueryParams(values url.Values) *queryParams {
+	return &queryParams{values: values}
+}
+
+// String returns the raw value of name, or def when it is absent
+func (q *queryParams) String(name, def string) string {
+	if raw := q.values.Get(name); raw != "" {
+		return raw
+	}
+	return def
+}
+
+// Strings returns every non-empty value of a repeated parameter such as ?tag=a&tag=b, or nil when absent
+func (q *queryParams) Strings(name string) []string {
+	var values []string
+	for _, raw := range q.values[name] {
+		if raw != "" {
+			values = append(values, raw)
+		}
+	}
+	return values
+}
+
+// Enum returns the value of name if it is one of allowed, or def when it is absent
+func (q *queryParams) Enum(name, def string, allowed ...string) string {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return def
+	}
+	for _, value := range allowed {
+		if raw == value {
+			return raw
+		}
+	}
+	q.fail("Unknown %s %q, must be %s", name, raw, strings.Join(allowed, " or "))
+	return def
+}
+
+// Bool returns the value of name as a boolean, or nil when it is absent
+func (q *queryParams) Bool(name string) *bool {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		q.fail("%s must be true or false", name)
+		return nil
+	}
+	return &value
+}
+
+// Int returns the value of name if it is an integer within min..max, or def when it is absent
+func (q *queryParams) Int(name string, def, min, max int) int {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min || value > max {
+		q.fail("%s must be %d..%d", name, min, max)
+		return def
+	}
+	return value
+}
+
+// Time returns the value of name as an instant, or nil when it is absent. It accepts an RFC 3339
+// timestamp or a YYYY-MM-DD date, which means the start of that day in UTC.
+func (q *queryParams) Time(name string) *time.Time {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	if value, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &value
+	}
+	if isTaskDate(raw) {
+		value, _ := time.Parse(taskDateFormat, raw)
+		return &value
+	}
+	q.fail("%s must be a YYYY-MM-DD date or an RFC 3339 timestamp", name)
+	return nil
+}
+
+// Check records the error returned by a custom parser for name, if any
+func (q *queryParams) Check(err error) {
+	if err != nil {
+		q.problems = append(q.problems, err.Error())
+	}
+}
+
+// Err joins every recorded problem into a single error, or returns nil when all parameters were valid
+func (q *queryParams) Err() error {
+	if len(q.problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(q.problems, "; "))
+}
+
+func (q *queryParams) fail(format string, args ...interface{}) {
+	q.problems = append(q.problems, fmt.Sprintf(format, args...))
+}
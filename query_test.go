@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestQueryParams(t *testing.T) {
+	values, _ := url.ParseQuery("limit=900&completed=maybe&sort=color&page=2")
+	params := newQueryParams(values)
+
+	if got := params.Int("page", 1, 1, 100); got != 2 {
+		t.Errorf("page = %d, want 2", got)
+	}
+	if got := params.Int("offset", 0, 0, 100); got != 0 {
+		t.Errorf("absent offset = %d, want default 0", got)
+	}
+	params.Int("limit", 50, 1, 500)
+	params.Bool("completed")
+	params.Enum("sort", "", "title", "id")
+
+	want := `limit must be 1..500; completed must be true or false; Unknown sort "color", must be title or id`
+	if err := params.Err(); err == nil || err.Error() != want {
+		t.Errorf("Err() = %v, want %q", err, want)
+	}
+	if err := newQueryParams(url.Values{}).Err(); err != nil {
+		t.Errorf("Err() with no params = %v, want nil", err)
+	}
+}
+
+func TestListQueryErrorsAggregated(t *testing.T) {
+	useTasks(t, []Task{{ID: 1, Title: "Open"}, {ID: 2, Title: "Done", Completed: true}})
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Completed Filter", "completed=true&fields=id", http.StatusOK, `[{"id":2}]`},
+		{"Several Bad Params", "completed=yes&sort=color&fields=nope", http.StatusBadRequest, `{"error":"completed must be true or false; Unknown sort \"color\", must be title or priority; Unknown field(s): nope","code":"invalid_request"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// requireTasksFile makes a missing tasks file an error instead of the first run of an empty store
+var requireTasksFile bool
+
+// fileTaskBackend is a backend kept in a single file, whose backups can be read in the same format
+type fileTaskBackend interface {
+	taskBackend
+	file() string
+	withFile(filename string) taskBackend
+}
+
+func (s fileBackend) file() string                         { return s.filename }
+func (s fileBackend) withFile(filename string) taskBackend { return fileBackend{filename: filename} }
+func (s gobBackend) file() string                          { return s.filename }
+func (s gobBackend) withFile(filename string) taskBackend  { return gobBackend{filename: filename} }
+
+// loadTasks loads the list from backend. For a file backend, a missing file starts an empty list on a
+// first run, and a truncated or corrupt file is moved aside and replaced by the newest backup that loads.
+// Errors reading the file, as opposed to decoding it, are returned as they are so the caller can retry.
+func loadTasks(backend taskBackend) ([]Task, error) {
+	var loaded []Task
+	err := timeStorage("load", backendKey(backend), func() (err error) {
+		loaded, err = backend.Load()
+		return err
+	})
+	file, ok := backend.(fileTaskBackend)
+	var pathErr *fs.PathError
+	if err == nil || !ok || (errors.As(err, &pathErr) && !errors.Is(err, os.ErrNotExist)) {
+		return loaded, err
+	}
+	filename := file.file()
+	missing := errors.Is(err, os.ErrNotExist)
+	if missing && requireTasksFile {
+		return nil, fmt.Errorf("%w (--require-existing-file is set)", err)
+	}
+	if !missing {
+		logError("Failed to load %s: %v", filename, err)
+	}
+
+	for n := 0; ; n++ {
+		backup := backupName(filename, n)
+		if _, statErr := os.Stat(backup); statErr != nil {
+			break
+		}
+		restored, loadErr := file.withFile(backup).Load()
+		if loadErr != nil {
+			logError("Backup %s is unusable too: %v", backup, loadErr)
+			continue
+		}
+		if !missing {
+			// Set the bad file aside, both for inspection and so the next save doesn't rotate it over the good backup
+			aside := filename + ".corrupt-" + clock.Now().UTC().Format("20060102T150405Z")
+			if err := os.Rename(filename, aside); err != nil {
+				return nil, fmt.Errorf("move corrupt %s aside: %w", filename, err)
+			}
+			logError("Moved unreadable %s to %s", filename, aside)
+		}
+		logInfo("Recovered %d tasks from %s", len(restored), backup)
+		return restored, nil
+	}
+	if missing {
+		logInfo("%s does not exist, starting with no tasks", filename)
+		return []Task{}, nil
+	}
+	return nil, fmt.Errorf("%w, and no backup could be loaded", err)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTasksRecovery(t *testing.T) {
+	valid := `[{"id": 1, "title": "From backup"}]`
+	tests := []struct {
+		name      string
+		file      *string // nil leaves the tasks file missing
+		backups   []string
+		require   bool
+		wantTitle string // "" expects no tasks
+		wantErr   bool
+		wantAside bool
+	}{
+		{name: "First run", wantTitle: ""},
+		{name: "First run required", require: true, wantErr: true},
+		{name: "Truncated file", file: stringPtr(`[{"id": 1, "ti`), backups: []string{valid}, wantTitle: "From backup", wantAside: true},
+		{name: "Empty file", file: stringPtr(""), backups: []string{valid}, wantTitle: "From backup", wantAside: true},
+		{name: "Newest backup corrupt too", file: stringPtr("{"), backups: []string{"[", valid}, wantTitle: "From backup", wantAside: true},
+		{name: "No usable backup", file: stringPtr("garbage"), backups: []string{"["}, wantErr: true},
+		{name: "Missing file with backup", backups: []string{valid}, wantTitle: "From backup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "tasks.json")
+			if tt.file != nil {
+				os.WriteFile(filename, []byte(*tt.file), 0644)
+			}
+			for n, backup := range tt.backups {
+				os.WriteFile(backupName(filename, n), []byte(backup), 0644)
+			}
+			requireTasksFile = tt.require
+			defer func() { requireTasksFile = false }()
+
+			list, err := loadTasks(fileBackend{filename: filename})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantTitle == "" && len(list) != 0 || tt.wantTitle != "" && (len(list) != 1 || list[0].Title != tt.wantTitle) {
+				t.Errorf("got %+v, want title %q", list, tt.wantTitle)
+			}
+			aside, _ := filepath.Glob(filename + ".corrupt-*")
+			if (len(aside) == 1) != tt.wantAside {
+				t.Errorf("corrupt copies %v, want one: %v", aside, tt.wantAside)
+			}
+		})
+	}
+}
+
+func TestRecoveredStoreKeepsGoodBackup(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "tasks.gob")
+	backend := gobBackend{filename: filename}
+	if err := backend.Save([]Task{{ID: 1, Title: "Good"}}); err != nil {
+		t.Fatal(err)
+	}
+	os.Rename(filename, backupName(filename, 0))
+	os.WriteFile(filename, []byte("not gob"), 0644)
+
+	store, err := openTaskStore(backend, false)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if list := store.List(); len(list) != 1 || list[0].Title != "Good" {
+		t.Fatalf("got %+v", list)
+	}
+	// Saving the recovered store must not rotate the corrupt file over the good backup
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	backup, err := gobBackend{filename: backupName(filename, 0)}.Load()
+	if err != nil || len(backup) != 1 || !strings.Contains(backup[0].Title, "Good") {
+		t.Errorf("backup after save = %+v, %v", backup, err)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
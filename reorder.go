@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// reorderRequest is the body of PUT /tasks/order
+type reorderRequest struct {
+	IDs []int `json:"ids"`
+}
+
+// ReorderTasks handles PUT /tasks/order, replacing the list order in one step. The body must name
+// every existing task exactly once, so a client can't silently drop tasks created since it last read.
+func ReorderTasks(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to read request body in reorder")
+		writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	var req reorderRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		logErrorContext(r.Context(), "Invalid JSON format in reorder")
+		writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	reordered, err := storeFor(r).Reorder(req.IDs)
+	if err != nil {
+		logErrorContext(r.Context(), "Invalid task order: %v", err)
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	jsonData, err := json.Marshal(reordered)
+	if err != nil {
+		logErrorContext(r.Context(), "JSON marshalling failed")
+		writeJsonError(w, http.StatusInternalServerError, "Internal server error: JSON marshalling failed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// orderTasks returns list rearranged to follow ids, which must be a permutation of the task IDs in list
+func orderTasks(list []Task, ids []int) ([]Task, error) {
+	if len(ids) != len(list) {
+		return nil, fmt.Errorf("Order must list all %d tasks, got %d IDs", len(list), len(ids))
+	}
+	byID := make(map[int]Task, len(list))
+	for _, t := range list {
+		byID[t.ID] = t
+	}
+	reordered := make([]Task, 0, len(ids))
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return nil, fmt.Errorf("Duplicate task ID %d in order", id)
+		}
+		seen[id] = true
+		task, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("No task found with ID %d", id)
+		}
+		reordered = append(reordered, task)
+	}
+	return reordered, nil
+}
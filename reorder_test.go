@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type reorderTestCase struct {
+	name       string // Test case name
+	payload    string // The JSON payload sent in the request
+	wantStatus int    // Expected HTTP status code
+	wantBody   string // Expected response body
+}
+
+var reorderTests = []reorderTestCase{
+	{
+		name:       "Valid Order",
+		payload:    `{"ids": [3, 1, 2]}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":3,"title":"Third","completed":false},{"id":1,"title":"First","completed":false},{"id":2,"title":"Second","completed":false}]`,
+	},
+	{
+		name:       "Missing Task",
+		payload:    `{"ids": [3, 1]}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Order must list all 3 tasks, got 2 IDs","code":"invalid_request"}`,
+	},
+	{
+		name:       "Duplicate Task",
+		payload:    `{"ids": [3, 3, 1]}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Duplicate task ID 3 in order","code":"invalid_request"}`,
+	},
+	{
+		name:       "Unknown Task",
+		payload:    `{"ids": [3, 1, 99]}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"No task found with ID 99","code":"invalid_request"}`,
+	},
+	{
+		name:       "Invalid JSON",
+		payload:    `{"ids": [3, 1,`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Invalid JSON format","code":"invalid_request"}`,
+	},
+}
+
+func TestReorderTasks(t *testing.T) {
+	for _, tt := range reorderTests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTasks(t, []Task{
+				{ID: 1, Title: "First"},
+				{ID: 2, Title: "Second"},
+				{ID: 3, Title: "Third"},
+			})
+
+			req := httptest.NewRequest(http.MethodPut, "/tasks/order", strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Test %s: got status %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			}
+			gotBody := strings.TrimSpace(rec.Body.String())
+			if gotBody != tt.wantBody {
+				t.Errorf("Test %s: got body %s, want %s", tt.name, gotBody, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrStorageUnavailable is returned without attempting I/O while the storage circuit breaker is open
+var ErrStorageUnavailable = errors.New("storage unavailable: too many consecutive failures")
+
+// retryPolicy controls how persistence operations are retried
+type retryPolicy struct {
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// storageRetryPolicy retries up to 4 times with exponential backoff from 100ms, capped at 2s
+var storageRetryPolicy = retryPolicy{attempts: 4, baseDelay: 100 * time.Millisecond, maxDelay: 2 * time.Second}
+
+// retrySleep pauses between attempts; tests replace it to avoid real waits
+var retrySleep = time.Sleep
+
+// circuitBreaker stops hammering storage after repeated failed operations, letting it recover
+type circuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	cooldown    time.Duration
+	failures    int
+	openedAt    time.Time
+	retries     int
+	lastFailure error
+}
+
+// storageBreaker guards all retried persistence operations: after 3 consecutive failures it opens for 30s
+var storageBreaker = &circuitBreaker{threshold: 3, cooldown: 30 * time.Second}
+
+// open reports whether the breaker is currently rejecting operations
+func (b *circuitBreaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.isOpen()
+}
+
+// isOpen must be called with b.mu held
+func (b *circuitBreaker) isOpen() bool {
+	return b.failures >= b.threshold && clock.Now().Sub(b.openedAt) < b.cooldown
+}
+
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if b.failures >= b.threshold {
+			logInfo("Storage recovered, closing circuit breaker")
+		}
+		b.failures = 0
+		b.lastFailure = nil
+		return
+	}
+	b.failures++
+	b.lastFailure = err
+	if b.failures == b.threshold || (b.failures > b.threshold && !b.isOpen()) {
+		b.openedAt = clock.Now()
+		logError("Storage circuit breaker opened after %d consecutive failures: %v", b.failures, err)
+	}
+}
+
+// StorageStats summarizes persistence health for readiness checks and monitoring
+type StorageStats struct {
+	Degraded            bool   `json:"degraded"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Retries             int    `json:"retries"`
+	LastError           string `json:"last_error,omitempty"`
+}
+
+func (b *circuitBreaker) stats() StorageStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := StorageStats{Degraded: b.isOpen(), ConsecutiveFailures: b.failures, Retries: b.retries}
+	if b.lastFailure != nil {
+		stats.LastError = b.lastFailure.Error()
+	}
+	return stats
+}
+
+// decodeError is returned when stored tasks were read but could not be decoded. Reading them again
+// won't help, so it is neither retried nor counted against the breaker.
+type decodeError struct {
+	err error
+}
+
+func (e decodeError) Error() string { return e.err.Error() }
+func (e decodeError) Unwrap() error { return e.err }
+
+// isAnswer reports whether err says something about the data rather than the storage: a missing
+// file, or one that won't decode
+func isAnswer(err error) bool {
+	var undecodable decodeError
+	return errors.Is(err, os.ErrNotExist) || errors.As(err, &undecodable)
+}
+
+// storageRetry describes how a persistence operation is retried
+type storageRetry struct {
+	// once makes a single attempt, for callers that can't wait out a backoff
+	once bool
+	// final tries even while the breaker is open: it is the last chance to save, on shutdown
+	final bool
+	// release, when set, is a lock the caller holds. It is unlocked while waiting between attempts so
+	// the backoff doesn't block everyone else, and each attempt runs with it held again.
+	release sync.Locker
+}
+
+// withStorageRetry runs a persistence operation, retrying transient failures with exponential backoff
+// and full jitter. While the breaker is open it fails fast.
+func withStorageRetry(operation string, fn func() error) error {
+	return storageRetry{}.run(operation, fn)
+}
+
+// run runs fn as withStorageRetry does. Missing or undecodable files aren't retried, and nor is
+// ErrStorageBusy, as acquireFileLock has already waited storageLockTimeout for the other process.
+func (o storageRetry) run(operation string, fn func() error) error {
+	if !o.final && storageBreaker.open() {
+		logError("Skipping %s: %v", operation, ErrStorageUnavailable)
+		return ErrStorageUnavailable
+	}
+
+	attempts := storageRetryPolicy.attempts
+	if o.once {
+		attempts = 1
+	}
+	var err error
+	attempt := 1
+	delay := storageRetryPolicy.baseDelay
+	for ; ; attempt++ {
+		if err = fn(); err == nil || isAnswer(err) || errors.Is(err, ErrStorageBusy) || attempt == attempts {
+			break
+		}
+		// Full jitter spreads retries from concurrent writers apart
+		wait := time.Duration(rand.Int63n(int64(delay) + 1))
+		logError("%s failed (attempt %d/%d), retrying in %v: %v", operation, attempt, attempts, wait, err)
+		storageBreaker.mu.Lock()
+		storageBreaker.retries++
+		storageBreaker.mu.Unlock()
+		if o.release != nil {
+			o.release.Unlock()
+		}
+		retrySleep(wait)
+		if o.release != nil {
+			o.release.Lock()
+		}
+		delay *= 2
+		if delay > storageRetryPolicy.maxDelay {
+			delay = storageRetryPolicy.maxDelay
+		}
+	}
+
+	if isAnswer(err) {
+		return err
+	}
+	storageBreaker.record(err)
+	if err != nil {
+		return fmt.Errorf("%s failed after %d attempts: %w", operation, attempt, err)
+	}
+	return nil
+}
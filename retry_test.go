@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+// resetStorageBreaker gives each test a fresh breaker and instant retries
+func resetStorageBreaker(t *testing.T) {
+	t.Helper()
+	originalBreaker, originalSleep := storageBreaker, retrySleep
+	storageBreaker = &circuitBreaker{threshold: 3, cooldown: 30 * time.Second}
+	retrySleep = func(time.Duration) {}
+	t.Cleanup(func() {
+		storageBreaker, retrySleep = originalBreaker, originalSleep
+	})
+}
+
+func TestStorageRetryRecoversFromTransientFailure(t *testing.T) {
+	resetStorageBreaker(t)
+
+	calls := 0
+	err := withStorageRetry("flaky save", func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("disk hiccup")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected success after retries, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+	if stats := storageBreaker.stats(); stats.Retries != 2 || stats.Degraded {
+		t.Errorf("Unexpected stats after recovery: %+v", stats)
+	}
+}
+
+func TestStorageRetryDoesNotRetryMissingFile(t *testing.T) {
+	resetStorageBreaker(t)
+
+	calls := 0
+	err := withStorageRetry("load", func() error {
+		calls++
+		return os.ErrNotExist
+	})
+	if !errors.Is(err, os.ErrNotExist) || calls != 1 {
+		t.Errorf("Expected a single attempt returning ErrNotExist, got %d attempts and %v", calls, err)
+	}
+}
+
+func TestStorageCircuitBreakerOpens(t *testing.T) {
+	resetStorageBreaker(t)
+	fake := useFakeClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	failing := func() error { return errors.New("disk gone") }
+	for i := 0; i < storageBreaker.threshold; i++ {
+		if err := withStorageRetry("save", failing); err == nil {
+			t.Fatalf("Expected failure %d", i+1)
+		}
+	}
+
+	// Open breaker fails fast and marks the server not ready
+	calls := 0
+	err := withStorageRetry("save", func() error { calls++; return nil })
+	if !errors.Is(err, ErrStorageUnavailable) || calls != 0 {
+		t.Errorf("Expected fast failure while open, got %v after %d calls", err, calls)
+	}
+	rec := httptest.NewRecorder()
+	Health(rec, httptest.NewRequest(http.MethodGet, "/tasks/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected health to report 503 while degraded, got %d", rec.Code)
+	}
+
+	// After the cooldown a successful operation closes it again
+	fake.Advance(storageBreaker.cooldown)
+	if err := withStorageRetry("save", func() error { return nil }); err != nil {
+		t.Fatalf("Expected success after cooldown, got %v", err)
+	}
+	rec = httptest.NewRecorder()
+	Health(rec, httptest.NewRequest(http.MethodGet, "/tasks/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected health to recover, got %d", rec.Code)
+	}
+}
+
+func TestStorageRetrySkipsLastingFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantFailures int // Failures counted against the breaker
+	}{
+		{"Undecodable", decodeError{&json.SyntaxError{}}, 0},
+		{"Missing", os.ErrNotExist, 0},
+		{"Locked", fmt.Errorf("%w (tasks.json.lock)", ErrStorageBusy), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStorageBreaker(t)
+			calls := 0
+			err := withStorageRetry("load", func() error { calls++; return tt.err })
+			if !errors.Is(err, tt.err) || calls != 1 {
+				t.Errorf("got %v after %d attempts, want %v after 1", err, calls, tt.err)
+			}
+			if stats := storageBreaker.stats(); stats.ConsecutiveFailures != tt.wantFailures || stats.Retries != 0 {
+				t.Errorf("breaker stats %+v, want %d failures and no retries", stats, tt.wantFailures)
+			}
+		})
+	}
+}
+
+func TestFinalSaveIgnoresOpenBreaker(t *testing.T) {
+	resetStorageBreaker(t)
+	useFakeClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	for i := 0; i < storageBreaker.threshold; i++ {
+		withStorageRetry("save", func() error { return errors.New("disk gone") })
+	}
+
+	backend := &countingBackend{}
+	store := newMemoryStore([]Task{{ID: 1, Title: "Unsaved"}})
+	store.backend = backend
+	if err := store.Flush(); !errors.Is(err, ErrStorageUnavailable) {
+		t.Errorf("Flush = %v, want ErrStorageUnavailable while the breaker is open", err)
+	}
+	if err := store.FlushFinal(); err != nil || backend.saves != 1 {
+		t.Errorf("FlushFinal = %v after %d saves, want one save despite the open breaker", err, backend.saves)
+	}
+}
+
+// flakyBackend fails its next failures saves, then saves as countingBackend does
+type flakyBackend struct {
+	countingBackend
+	failures int
+}
+
+func (b *flakyBackend) Save(list []Task) error {
+	if b.failures > 0 {
+		b.failures--
+		return errors.New("disk hiccup")
+	}
+	return b.countingBackend.Save(list)
+}
+
+func TestFlushReleasesStoreWhileBackingOff(t *testing.T) {
+	resetStorageBreaker(t)
+	backend := &flakyBackend{failures: 1}
+	store := newMemoryStore(nil)
+	store.backend = backend
+	store.Create(Task{Title: "First"})
+
+	// A change made while the save backs off is in the save that follows
+	retrySleep = func(time.Duration) {
+		if !store.mu.TryLock() {
+			t.Fatal("store locked while backing off")
+		}
+		store.mu.Unlock()
+		store.Create(Task{Title: "Second"})
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush = %v", err)
+	}
+	if len(backend.last) != 2 || store.unsaved != 0 {
+		t.Errorf("saved %d tasks leaving %d unsaved, want 2 and 0", len(backend.last), store.unsaved)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Roles, from least to most access. Users have one (admin unless USERS_FILE says otherwise), and API
+// tokens are scoped to one.
+const (
+	roleReadOnly  = "read-only"
+	roleReadWrite = "read-write"
+	roleAdmin     = "admin"
+)
+
+// roleRank orders roles by access, 0 for an unknown role
+func roleRank(role string) int {
+	switch role {
+	case roleReadOnly:
+		return 1
+	case roleReadWrite:
+		return 2
+	case roleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// lesserRole returns whichever of a and b grants less
+func lesserRole(a, b string) string {
+	if roleRank(a) < roleRank(b) {
+		return a
+	}
+	return b
+}
+
+// accessPolicy maps a method to the least role allowed to use it; "*" covers methods not listed
+type accessPolicy map[string]string
+
+var (
+	readPolicy  = accessPolicy{"*": roleReadOnly}
+	writePolicy = accessPolicy{http.MethodGet: roleReadOnly, http.MethodHead: roleReadOnly, "*": roleReadWrite}
+	adminPolicy = accessPolicy{"*": roleAdmin}
+)
+
+// routePolicies assigns a policy to each route prefix; the first match wins
+var routePolicies = []struct {
+	prefix string
+	policy accessPolicy
+}{
+	// Any role manages its own tokens, though only with its own key: Tokens turns tokens away
+	{"/me/tokens", readPolicy},
+	// Only the admin area's own endpoints on the management port are authenticated
+	{"/admin", adminPolicy},
+	// Preferences only change the user's own view, so any role may set them
+	{"/me/preferences", readPolicy},
+	{"/tasks", writePolicy},
+	{"/counters", writePolicy},
+	{"/imports", writePolicy},
+	{"/import", writePolicy},
+	// Feeds, tags, exports, and job reports only read
+	{"/", readPolicy},
+}
+
+// requiredRole returns the least role allowed to make r
+func requiredRole(r *http.Request) string {
+	for _, route := range routePolicies {
+		if route.prefix == "/" || r.URL.Path == route.prefix || strings.HasPrefix(r.URL.Path, route.prefix+"/") {
+			if role, ok := route.policy[r.Method]; ok {
+				return role
+			}
+			return route.policy["*"]
+		}
+	}
+	return roleAdmin
+}
+
+// roleKey is the request context key for the authenticated credential's role
+type roleKey struct{}
+
+// roleFor returns the role r was authenticated with, roleAdmin when authentication is off
+func roleFor(r *http.Request) string {
+	if role, ok := r.Context().Value(roleKey{}).(string); ok {
+		return role
+	}
+	return roleAdmin
+}
+
+// withRole returns r acting with role
+func withRole(r *http.Request, role string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), roleKey{}, role))
+}
+
+// viaTokenKey is the request context key marking a request authenticated by an API token
+type viaTokenKey struct{}
+
+// viaToken reports whether r was authenticated by an API token rather than its user's own key
+func viaToken(r *http.Request) bool {
+	via, _ := r.Context().Value(viaTokenKey{}).(bool)
+	return via
+}
+
+// authorize checks r's role against its route's policy, responding 403 when it falls short
+func authorize(w http.ResponseWriter, r *http.Request) bool {
+	role, required := roleFor(r), requiredRole(r)
+	if roleRank(role) >= roleRank(required) {
+		return true
+	}
+	logErrorContext(r.Context(), "%s %s needs role %s, %s has %s", r.Method, r.URL.Path, required, userFor(r), role)
+	writeJsonError(w, http.StatusForbidden, fmt.Sprintf("This request needs the %s role, you have %s", required, role))
+	return false
+}
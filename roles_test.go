@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequiredRole(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{"GET", "/tasks", roleReadOnly},
+		{"HEAD", "/tasks/1/checklist", roleReadOnly},
+		{"POST", "/tasks", roleReadWrite},
+		{"DELETE", "/tasks/1", roleReadWrite},
+		{"POST", "/counters/visits/increment", roleReadWrite},
+		{"POST", "/imports", roleReadWrite},
+		{"GET", "/tags", roleReadOnly},
+		{"GET", "/feed.json", roleReadOnly},
+		{"GET", "/me/tokens", roleReadOnly},
+		{"POST", "/me/tokens/abc/rotate", roleReadOnly},
+		// A prefix only matches whole path segments
+		{"POST", "/tasksx", roleReadOnly},
+	}
+	for _, tt := range tests {
+		if got := requiredRole(httptest.NewRequest(tt.method, tt.path, nil)); got != tt.want {
+			t.Errorf("%s %s: got %s, want %s", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRoles(t *testing.T) {
+	useFakeClock(t, testNow)
+	useUsers(t)
+	users = []User{
+		{Name: "dash", KeySHA256: hashAPIKey("dash-key"), Role: roleReadOnly},
+		{Name: "editor", KeySHA256: hashAPIKey("editor-key"), Role: roleReadWrite},
+	}
+	useTokens(t)
+	// Issued while dash was an admin; it is capped at dash's current role
+	apiTokens = []storedToken{{APIToken: APIToken{ID: "t1", Owner: "dash", Scope: roleAdmin, CreatedAt: testNow}, Hash: hashAPIKey("dash-token")}}
+	server, _ := StartTestServer(t)
+	as := func(key string) *Client { return &Client{t: t, baseURL: server.URL, http: server.Client(), Key: key} }
+
+	steps := []struct {
+		name       string
+		key        string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"Read-Only Lists", "dash-key", "GET", "/tasks", "", http.StatusOK, `[]`},
+		{"Read-Only Reads Tags", "dash-key", "GET", "/tags", "", http.StatusOK, `[]`},
+		{"Read-Only Can't Create", "dash-key", "POST", "/tasks", `{"title": "No"}`, http.StatusForbidden,
+			`{"error":"This request needs the read-write role, you have read-only","code":"forbidden"}`},
+		{"Read-Only Can't Count", "dash-key", "POST", "/counters", `{"name": "visits", "step": 1}`, http.StatusForbidden,
+			`{"error":"This request needs the read-write role, you have read-only","code":"forbidden"}`},
+		{"Admin Token Capped By Role", "dash-token", "DELETE", "/tasks/1", "", http.StatusForbidden,
+			`{"error":"This request needs the read-write role, you have read-only","code":"forbidden"}`},
+		{"Read-Write Creates", "editor-key", "POST", "/tasks", `{"title": "Yes"}`, http.StatusCreated,
+			`{"id":1,"title":"Yes","completed":false,"owner":"editor","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Read-Only Lists Tokens", "dash-key", "GET", "/me/tokens", "", http.StatusOK,
+			`[{"id":"t1","name":"","owner":"dash","scope":"admin","created_at":"2024-05-01T12:00:00Z"}]`},
+		{"Token Can't Manage Tokens", "dash-token", "DELETE", "/me/tokens/t1", "", http.StatusForbidden,
+			`{"error":"API tokens can only be managed with your own key","code":"forbidden"}`},
+	}
+	for _, tt := range steps {
+		status, body := as(tt.key).Do(tt.method, tt.path, tt.body)
+		if status != tt.wantStatus || body != tt.wantBody {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, status, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestTokensCappedAtIssue(t *testing.T) {
+	useUsers(t)
+	users = []User{{Name: "editor", KeySHA256: hashAPIKey("editor-key"), Role: roleReadWrite}}
+	useTokens(t)
+	_, editor := StartTestServer(t)
+	editor.Key = "editor-key"
+
+	status, body := editor.Post("/me/tokens", `{"name": "deploy", "scope": "admin"}`)
+	var issued issuedToken
+	if err := json.Unmarshal([]byte(body), &issued); status != http.StatusCreated || err != nil {
+		t.Fatalf("issue token: got %d %s", status, body)
+	}
+	if issued.Scope != roleReadWrite {
+		t.Errorf("read-write user was issued a %s token, want read-write", issued.Scope)
+	}
+}
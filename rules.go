@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ValidationRule is a cross-field check applied when a task is created or updated. Exactly one of
+// Require or Field/After is set, optionally guarded by When. Fields are named by their JSON keys.
+//
+//	{"id": "high-needs-due", "when": {"field": "priority", "equals": "high"}, "require": "due_date"}
+//	{"id": "due-after-start", "field": "due_date", "after": "start_date"}
+type ValidationRule struct {
+	ID      string         `json:"id"`
+	Message string         `json:"message,omitempty"`
+	When    *RuleCondition `json:"when,omitempty"`
+	// Require names a field that must be set
+	Require string `json:"require,omitempty"`
+	// Field must be strictly after After whenever both are set; dates and numbers both compare naturally
+	Field string `json:"field,omitempty"`
+	After string `json:"after,omitempty"`
+}
+
+// RuleCondition limits a rule to tasks whose field equals a value
+type RuleCondition struct {
+	Field  string      `json:"field"`
+	Equals interface{} `json:"equals"`
+}
+
+// RuleViolation names a broken rule in an error response
+type RuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ruleError carries the violations of a rejected create or update
+type ruleError struct {
+	violations []RuleViolation
+}
+
+func (e *ruleError) Error() string {
+	return fmt.Sprintf("Task violates %d validation rule(s)", len(e.violations))
+}
+
+// validationRules are the rules in force, loaded from VALIDATION_RULES at startup
+var validationRules []ValidationRule
+
+// loadValidationRules reads a JSON array of rules, rejecting rules that are malformed or name unknown fields
+func loadValidationRules(filename string) ([]ValidationRule, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var rules []ValidationRule
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	known := make(map[string]bool, len(taskFieldNames))
+	for _, name := range taskFieldNames {
+		known[name] = true
+	}
+	ids := make(map[string]bool, len(rules))
+	var problems []string
+	for i, rule := range rules {
+		label := fmt.Sprintf("rule %d", i)
+		if rule.ID != "" {
+			label = fmt.Sprintf("rule %q", rule.ID)
+		}
+		switch {
+		case rule.ID == "":
+			problems = append(problems, label+": id is required")
+		case ids[rule.ID]:
+			problems = append(problems, label+": duplicate id")
+		}
+		ids[rule.ID] = true
+		if (rule.Require == "") == (rule.Field == "" && rule.After == "") {
+			problems = append(problems, label+": set either require or field and after")
+		} else if rule.Require == "" && (rule.Field == "" || rule.After == "") {
+			problems = append(problems, label+": field and after must be set together")
+		}
+		fields := []string{rule.Require, rule.Field, rule.After}
+		if rule.When != nil {
+			fields = append(fields, rule.When.Field)
+		}
+		for _, field := range fields {
+			if field != "" && !known[field] {
+				problems = append(problems, fmt.Sprintf("%s: unknown field %q", label, field))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+	return rules, nil
+}
+
+// checkRules returns the rules task breaks, or nil if it satisfies all of them
+func checkRules(task Task, rules []ValidationRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	// Work on the JSON form so rules see exactly the fields clients send, with omitted fields unset
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var violations []RuleViolation
+	for _, rule := range rules {
+		if rule.When != nil && !reflect.DeepEqual(fields[rule.When.Field], rule.When.Equals) {
+			continue
+		}
+		message := rule.Message
+		if rule.Require != "" {
+			if isSet(fields[rule.Require]) {
+				continue
+			}
+			if message == "" {
+				message = rule.Require + " is required"
+				if rule.When != nil {
+					message += fmt.Sprintf(" when %s is %v", rule.When.Field, rule.When.Equals)
+				}
+			}
+		} else {
+			value, after := fields[rule.Field], fields[rule.After]
+			if !isSet(value) || !isSet(after) || isAfter(value, after) {
+				continue
+			}
+			if message == "" {
+				message = fmt.Sprintf("%s must be after %s", rule.Field, rule.After)
+			}
+		}
+		violations = append(violations, RuleViolation{Rule: rule.ID, Message: message})
+	}
+	if len(violations) > 0 {
+		return &ruleError{violations: violations}
+	}
+	return nil
+}
+
+// isSet reports whether a decoded JSON value counts as present
+func isSet(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+// isAfter compares two decoded JSON values of the same kind; YYYY-MM-DD dates order as strings
+func isAfter(a, b interface{}) bool {
+	switch av := a.(type) {
+	case float64:
+		bv, ok := b.(float64)
+		return ok && av > bv
+	case string:
+		bv, ok := b.(string)
+		return ok && av > bv
+	}
+	return false
+}
+
+// writeRuleViolations responds 400 with every rule the task broke
+func writeRuleViolations(w http.ResponseWriter, err *ruleError) {
+	writeJsonErrorBody(w, http.StatusBadRequest, struct {
+		errorResponse
+		Violations []RuleViolation `json:"violations"`
+	}{errorResponse{err.Error(), codeValidationFailed}, err.violations})
+}
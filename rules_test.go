@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useValidationRules installs rules for the duration of a test
+func useValidationRules(t *testing.T, rules []ValidationRule) {
+	t.Helper()
+	original := validationRules
+	validationRules = rules
+	t.Cleanup(func() { validationRules = original })
+}
+
+type ruleTestCase struct {
+	name       string // Test case name
+	method     string // HTTP method
+	url        string // Request URL
+	payload    string // The JSON payload sent in the request
+	wantStatus int    // Expected HTTP status code
+	wantBody   string // Expected response body
+}
+
+// ruleTests run in order against the same store
+var ruleTests = []ruleTestCase{
+	{
+		name:       "Create Breaking Rule",
+		method:     http.MethodPost,
+		url:        "/tasks",
+		payload:    `{"title": "Done already", "completed": true}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Task violates 1 validation rule(s)","code":"validation_failed","violations":[{"rule":"done-needs-start","message":"start_date is required when completed is true"}]}`,
+	},
+	{
+		name:       "Create Satisfying Rule",
+		method:     http.MethodPost,
+		url:        "/tasks",
+		payload:    `{"title": "Done already", "completed": true, "start_date": "2024-05-01"}`,
+		wantStatus: http.StatusCreated,
+		wantBody:   `{"id":2,"title":"Done already","completed":true,"start_date":"2024-05-01","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`,
+	},
+	{
+		name:       "Update Breaking Rule Is Not Applied",
+		method:     http.MethodPut,
+		url:        "/tasks/1",
+		payload:    `{"title": "Open", "completed": true}`,
+		wantStatus: http.StatusBadRequest,
+		wantBody:   `{"error":"Task violates 2 validation rule(s)","code":"validation_failed","violations":[{"rule":"done-needs-start","message":"start_date is required when completed is true"},{"rule":"no-silent-finish","message":"Say what finished it"}]}`,
+	},
+	{
+		name:       "Task Unchanged After Rejected Update",
+		method:     http.MethodGet,
+		url:        "/tasks?fields=id,completed",
+		wantStatus: http.StatusOK,
+		wantBody:   `[{"id":1,"completed":false},{"id":2,"completed":true}]`,
+	},
+	{
+		name:       "Update Checks Stored Fields",
+		method:     http.MethodPut,
+		url:        "/tasks/2",
+		payload:    `{"title": "Done already (renamed)", "completed": true}`,
+		wantStatus: http.StatusOK,
+		wantBody:   `{"id":2,"title":"Done already (renamed)","completed":true,"start_date":"2024-05-01","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":2}`,
+	},
+}
+
+func TestValidationRules(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{{ID: 1, Title: "Open"}})
+	useValidationRules(t, []ValidationRule{
+		{ID: "done-needs-start", When: &RuleCondition{Field: "completed", Equals: true}, Require: "start_date"},
+		{ID: "no-silent-finish", Message: "Say what finished it", When: &RuleCondition{Field: "title", Equals: "Open"}, Require: "start_date"},
+	})
+
+	for _, tt := range ruleTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestIsAfter(t *testing.T) {
+	tests := []struct {
+		a, b interface{}
+		want bool
+	}{
+		{"2024-05-02", "2024-05-01", true},
+		{"2024-05-01", "2024-05-01", false},
+		{3.0, 2.0, true},
+		{2.0, 3.0, false},
+		{"2024-05-02", 1.0, false},
+	}
+	for _, tt := range tests {
+		if got := isAfter(tt.a, tt.b); got != tt.want {
+			t.Errorf("isAfter(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLoadValidationRules(t *testing.T) {
+	tests := []struct {
+		name    string // Test case name
+		content string // Rules file contents
+		wantErr string // Expected error substring, empty for success
+	}{
+		{"Valid", `[{"id": "a", "require": "start_date"}, {"id": "b", "field": "start_date", "after": "start_date"}]`, ""},
+		{"Missing ID", `[{"require": "start_date"}]`, `rule 0: id is required`},
+		{"Duplicate ID", `[{"id": "a", "require": "title"}, {"id": "a", "require": "title"}]`, `rule "a": duplicate id`},
+		{"No Check", `[{"id": "a"}]`, `rule "a": set either require or field and after`},
+		{"Both Checks", `[{"id": "a", "require": "title", "field": "title", "after": "title"}]`, `set either require or field and after`},
+		{"Half Comparison", `[{"id": "a", "field": "start_date"}]`, `field and after must be set together`},
+		{"Unknown Field", `[{"id": "a", "when": {"field": "colour", "equals": "red"}, "require": "title"}]`, `unknown field "colour"`},
+		{"Unknown Key", `[{"id": "a", "requires": "title"}]`, `unknown field "requires"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(filename, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadValidationRules(filename)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrJobNotFound is returned by scheduler methods when no job has the requested name
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStatus is a scheduled job's configuration and recent history, as GET /admin/jobs reports it
+type JobStatus struct {
+	Name                string     `json:"name"`
+	Schedule            string     `json:"schedule,omitempty"`
+	Enabled             bool       `json:"enabled"`
+	JitterSeconds       float64    `json:"jitter_seconds,omitempty"`
+	Running             bool       `json:"running"`
+	Runs                int        `json:"runs"`
+	Failures            int        `json:"failures"`
+	NextRun             *time.Time `json:"next_run,omitempty"`
+	LastRun             *time.Time `json:"last_run,omitempty"`
+	LastDurationSeconds *float64   `json:"last_duration_seconds,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+// scheduledJob is a background task the scheduler runs on its schedule or on demand
+type scheduledJob struct {
+	name string
+	spec string
+	// schedule is nil for a job that only runs on demand
+	schedule schedule
+	enabled  bool
+	// jitter delays each scheduled run by up to this much, so instances sharing a schedule spread out
+	jitter time.Duration
+	// runAtStart runs the job as soon as the scheduler starts, before its first scheduled time
+	runAtStart bool
+	run        func() error
+	// trigger asks for a run now; it holds one request, so repeated requests while one waits coalesce
+	trigger chan struct{}
+	// status is guarded by the scheduler's mutex
+	status JobStatus
+}
+
+// scheduler runs the server's background jobs: autosave, consistency checks, and mail polling. Each
+// job runs in its own goroutine, so a slow job never delays another, and a job never overlaps itself.
+type scheduler struct {
+	mu   sync.Mutex
+	jobs []*scheduledJob
+	// running counts the job goroutines Start began that haven't returned
+	running sync.WaitGroup
+}
+
+// jobScheduler is the scheduler the admin endpoints report on
+var jobScheduler = &scheduler{}
+
+// jobSettings overrides a job's defaults; see parseJobSettings
+type jobSettings struct {
+	Schedule string `json:"schedule"`
+	Enabled  *bool  `json:"enabled"`
+	Jitter   string `json:"jitter"`
+}
+
+// parseJobSettings parses the JOBS variable, a JSON object of per-job settings, e.g.
+// {"consistency-check": {"schedule": "0 3 * * *", "jitter": "10m"}, "mail-poll": {"enabled": false}}
+func parseJobSettings(raw string) (map[string]jobSettings, error) {
+	settings := map[string]jobSettings{}
+	if raw == "" {
+		return settings, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("must be a JSON object of job names to {\"schedule\", \"enabled\", \"jitter\"}: %v", err)
+	}
+	return settings, nil
+}
+
+// Add registers a job that runs fn on spec. A disabled job, or one with an empty spec, only runs when
+// asked to through RunNow.
+func (s *scheduler) Add(name, spec string, enabled bool, fn func() error) (*scheduledJob, error) {
+	var sched schedule
+	if spec != "" {
+		var err error
+		if sched, err = parseSchedule(spec); err != nil {
+			return nil, err
+		}
+	}
+	job := &scheduledJob{name: name, spec: spec, schedule: sched, enabled: enabled && sched != nil, run: fn, trigger: make(chan struct{}, 1)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+	return job, nil
+}
+
+// Configure applies settings to the jobs they name, rejecting names of jobs that don't exist
+func (s *scheduler) Configure(settings map[string]jobSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, setting := range settings {
+		job := s.find(name)
+		if job == nil {
+			return fmt.Errorf("unknown job %q, must be one of %s", name, strings.Join(s.names(), ", "))
+		}
+		if setting.Schedule != "" {
+			sched, err := parseSchedule(setting.Schedule)
+			if err != nil {
+				return fmt.Errorf("job %s: %v", name, err)
+			}
+			job.spec, job.schedule = setting.Schedule, sched
+		}
+		if setting.Enabled != nil {
+			job.enabled = *setting.Enabled
+		}
+		if setting.Jitter != "" {
+			jitter, err := time.ParseDuration(setting.Jitter)
+			if err != nil || jitter < 0 {
+				return fmt.Errorf("job %s: invalid jitter %q", name, setting.Jitter)
+			}
+			job.jitter = jitter
+		}
+		if job.enabled && job.schedule == nil {
+			return fmt.Errorf("job %s needs a schedule to be enabled", name)
+		}
+	}
+	return nil
+}
+
+// addJob registers a job with jobScheduler that runs every interval, or only on demand when interval
+// is zero
+func addJob(name string, interval time.Duration, fn func() error) *scheduledJob {
+	spec := ""
+	if interval > 0 {
+		spec = "@every " + interval.String()
+	}
+	job, err := jobScheduler.Add(name, spec, true, fn)
+	if err != nil {
+		logFatal("Invalid schedule for job %s: %v", name, err)
+	}
+	return job
+}
+
+// Start runs every job in the background until stop is closed
+func (s *scheduler) Start(stop <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		if job.enabled {
+			logInfo("Scheduled job %s: %s", job.name, job.spec)
+		} else {
+			logInfo("Job %s runs on demand only", job.name)
+		}
+		s.running.Add(1)
+		go s.loop(job, stop)
+	}
+}
+
+// Wait returns once every job has stopped after the stop channel given to Start was closed, letting
+// runs in progress finish
+func (s *scheduler) Wait() {
+	s.running.Wait()
+}
+
+func (s *scheduler) loop(job *scheduledJob, stop <-chan struct{}) {
+	defer s.running.Done()
+	if job.runAtStart && job.enabled {
+		s.runJob(job)
+	}
+	for {
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next := s.nextRun(job); !next.IsZero() {
+			timer = time.NewTimer(next.Sub(clock.Now()))
+			due = timer.C
+		}
+		select {
+		case <-due:
+		case <-job.trigger:
+		case <-stop:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		s.runJob(job)
+	}
+}
+
+// nextRun picks and records the job's next scheduled run, the zero time if it has none
+func (s *scheduler) nextRun(job *scheduledJob) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.status.NextRun = nil
+	if !job.enabled || job.schedule == nil {
+		return time.Time{}
+	}
+	next := job.schedule.Next(clock.Now())
+	if next.IsZero() {
+		return next
+	}
+	if job.jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(job.jitter))))
+	}
+	job.status.NextRun = &next
+	return next
+}
+
+// runJob runs the job once and records how it went. A panic counts as a failure rather than taking
+// the server down.
+func (s *scheduler) runJob(job *scheduledJob) {
+	s.mu.Lock()
+	job.status.Running = true
+	s.mu.Unlock()
+
+	started := clock.Now()
+	elapsed := clock.Stopwatch()
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return job.run()
+	}()
+	duration := elapsed().Seconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.status.Running = false
+	job.status.Runs++
+	job.status.LastRun, job.status.LastDurationSeconds = &started, &duration
+	job.status.LastError = ""
+	if err != nil {
+		job.status.Failures++
+		job.status.LastError = err.Error()
+		logError("Job %s failed after %.3fs: %v", job.name, duration, err)
+		return
+	}
+	logDebug("Job %s finished in %.3fs", job.name, duration)
+}
+
+// RunNow asks for the named job to run as soon as it isn't already running
+func (s *scheduler) RunNow(name string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.find(name)
+	if job == nil {
+		return JobStatus{}, ErrJobNotFound
+	}
+	select {
+	case job.trigger <- struct{}{}:
+	default:
+	}
+	return job.statusLocked(), nil
+}
+
+// Jobs reports every job in the order they were added
+func (s *scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]JobStatus, len(s.jobs))
+	for i, job := range s.jobs {
+		statuses[i] = job.statusLocked()
+	}
+	return statuses
+}
+
+// Job returns the named job's status
+func (s *scheduler) Job(name string) (JobStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.find(name)
+	if job == nil {
+		return JobStatus{}, ErrJobNotFound
+	}
+	return job.statusLocked(), nil
+}
+
+func (s *scheduler) find(name string) *scheduledJob {
+	for _, job := range s.jobs {
+		if job.name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+func (s *scheduler) names() []string {
+	names := make([]string, len(s.jobs))
+	for i, job := range s.jobs {
+		names[i] = job.name
+	}
+	return names
+}
+
+// statusLocked copies the job's status; the caller holds the scheduler's mutex
+func (job *scheduledJob) statusLocked() JobStatus {
+	status := job.status
+	status.Name, status.Schedule, status.Enabled = job.name, job.spec, job.enabled
+	status.JitterSeconds = job.jitter.Seconds()
+	return status
+}
+
+// schedulePeriod estimates how far apart runs of sched are, from the two runs after now
+func schedulePeriod(sched schedule, now time.Time) time.Duration {
+	first := sched.Next(now)
+	if first.IsZero() {
+		return 0
+	}
+	return sched.Next(first).Sub(first)
+}
+
+// ScheduledJobs serves the scheduler's admin endpoints:
+//
+//	GET  /admin/jobs              every job's schedule and last run
+//	GET  /admin/jobs/{name}       one job
+//	POST /admin/jobs/{name}/run   run the job now, even if it is disabled; responds 202
+func ScheduledJobs(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/jobs"), "/")
+	name, action, _ := strings.Cut(path, "/")
+	switch {
+	case name == "" && r.Method == "GET":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jobScheduler.Jobs())
+	case name != "" && action == "" && r.Method == "GET":
+		status, err := jobScheduler.Job(name)
+		if err != nil {
+			writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No job named %q", name))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	case name != "" && action == "run" && r.Method == "POST":
+		status, err := jobScheduler.RunNow(name)
+		if err != nil {
+			writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No job named %q", name))
+			return
+		}
+		logInfoContext(r.Context(), "Job %s run on demand", name)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(status)
+	case action != "" && action != "run":
+		writeJsonError(w, http.StatusNotFound, "Not Found")
+	default:
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
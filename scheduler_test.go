@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useScheduler swaps in an empty jobScheduler for the test
+func useScheduler(t *testing.T) *scheduler {
+	t.Helper()
+	previous := jobScheduler
+	jobScheduler = &scheduler{}
+	t.Cleanup(func() { jobScheduler = previous })
+	return jobScheduler
+}
+
+func TestSchedulerConfigure(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobs      string
+		want      JobStatus
+		wantError string
+	}{
+		{"Defaults", "", JobStatus{Name: "report", Schedule: "@every 1h", Enabled: true}, ""},
+		{"Cron With Jitter", `{"report": {"schedule": "0 3 * * *", "jitter": "90s"}}`, JobStatus{Name: "report", Schedule: "0 3 * * *", Enabled: true, JitterSeconds: 90}, ""},
+		{"Disabled", `{"report": {"enabled": false}}`, JobStatus{Name: "report", Schedule: "@every 1h"}, ""},
+		{"Unknown Job", `{"reports": {}}`, JobStatus{}, `unknown job "reports", must be one of report, sweep`},
+		{"Unknown Setting", `{"report": {"cron": "* * * * *"}}`, JobStatus{}, "unknown field"},
+		{"Bad Schedule", `{"report": {"schedule": "daily"}}`, JobStatus{}, "job report: schedule \"daily\" must be"},
+		{"Bad Jitter", `{"report": {"jitter": "-1s"}}`, JobStatus{}, `job report: invalid jitter "-1s"`},
+		{"Enabled Without Schedule", `{"sweep": {"enabled": true}}`, JobStatus{}, "job sweep needs a schedule to be enabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &scheduler{}
+			jobs.Add("report", "@every 1h", true, func() error { return nil })
+			jobs.Add("sweep", "", true, func() error { return nil })
+			settings, err := parseJobSettings(tt.jobs)
+			if err == nil {
+				err = jobs.Configure(settings)
+			}
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Errorf("error = %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, _ := jobs.Job("report"); got != tt.want {
+				t.Errorf("status = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduledJobsAdmin(t *testing.T) {
+	useFakeClock(t, testNow)
+	jobs := useScheduler(t)
+	ran := make(chan struct{}, 1)
+	jobs.Add("sweep", "", true, func() error {
+		ran <- struct{}{}
+		return errors.New("disk full")
+	})
+	jobs.Add("report", "@daily", true, func() error { return nil })
+	stop := make(chan struct{})
+	defer jobs.Wait()
+	defer close(stop)
+	jobs.Start(stop)
+
+	serve := func(method, path string) (int, string) {
+		rec := httptest.NewRecorder()
+		ScheduledJobs(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	status, body := serve("POST", "/admin/jobs/sweep/run")
+	if status != http.StatusAccepted {
+		t.Fatalf("run = %d %s, want 202", status, body)
+	}
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job didn't run")
+	}
+	// Wait for the run to be recorded
+	var sweep JobStatus
+	for deadline := time.Now().Add(time.Second); sweep.Runs == 0 && time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		sweep, _ = jobs.Job("sweep")
+	}
+	if sweep.Runs != 1 || sweep.Failures != 1 || sweep.LastError != "disk full" || sweep.LastRun == nil || !sweep.LastRun.Equal(testNow) {
+		t.Errorf("sweep after a failed run = %+v", sweep)
+	}
+
+	status, body = serve("GET", "/admin/jobs")
+	var listed []JobStatus
+	json.Unmarshal([]byte(body), &listed)
+	if status != http.StatusOK || len(listed) != 2 || listed[1].Name != "report" || listed[1].NextRun == nil || !listed[1].NextRun.Equal(testNow.Add(12*time.Hour)) {
+		t.Errorf("list = %d %s", status, body)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"One Job", "GET", "/admin/jobs/report", http.StatusOK, `"name":"report","schedule":"@daily","enabled":true`},
+		{"Unknown Job", "POST", "/admin/jobs/nightly/run", http.StatusNotFound, `{"error":"No job named \"nightly\"","code":"not_found"}`},
+		{"Unknown Action", "POST", "/admin/jobs/report/pause", http.StatusNotFound, `{"error":"Not Found","code":"not_found"}`},
+		{"Wrong Method", "GET", "/admin/jobs/report/run", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed","code":"method_not_allowed"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := serve(tt.method, tt.path)
+			if status != tt.wantStatus || !strings.Contains(body, tt.wantBody) {
+				t.Errorf("got %d %s, want %d %s", status, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
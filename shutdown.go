@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shutdownPhase orders shutdown hooks: every hook of one phase finishes before the next phase starts
+type shutdownPhase int
+
+const (
+	// phaseStopAccepting turns new work away: health checks fail so load balancers move on, and
+	// long-lived streams are told to end
+	phaseStopAccepting shutdownPhase = iota
+	// phaseDrain waits for requests in progress to finish
+	phaseDrain
+	// phaseStopBackground stops scheduled jobs and workers, letting a run in progress finish
+	phaseStopBackground
+	// phaseFlush hands off anything still queued in memory, such as undelivered events
+	phaseFlush
+	// phaseSave writes state to storage
+	phaseSave
+	// phaseClose releases storage and other resources
+	phaseClose
+)
+
+var shutdownPhaseNames = [...]string{"stop accepting", "drain", "stop background", "flush", "save", "close"}
+
+func (p shutdownPhase) String() string {
+	return shutdownPhaseNames[p]
+}
+
+// shutdownHook is one cleanup step; fn should return by the time ctx is done
+type shutdownHook struct {
+	phase   shutdownPhase
+	name    string
+	timeout time.Duration
+	fn      func(ctx context.Context) error
+}
+
+// shutdownRegistry runs the hooks subsystems register, in phase order and then in the order they were
+// registered. A hook that fails or overruns its timeout is logged and shutdown moves on, so one stuck
+// subsystem can't stop the tasks from being saved.
+type shutdownRegistry struct {
+	mu    sync.Mutex
+	hooks []shutdownHook
+}
+
+// shutdownHooks is the registry main runs on SIGINT, SIGTERM, or SIGHUP
+var shutdownHooks = &shutdownRegistry{}
+
+// shuttingDown is set once shutdown starts; Health reports 503 from then on
+var shuttingDown atomic.Bool
+
+// Register adds a hook that runs fn in phase, giving it up to timeout
+func (s *shutdownRegistry) Register(phase shutdownPhase, name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, shutdownHook{phase: phase, name: name, timeout: timeout, fn: fn})
+}
+
+// Run runs every hook and reports how many failed or timed out
+func (s *shutdownRegistry) Run(ctx context.Context) int {
+	s.mu.Lock()
+	hooks := append([]shutdownHook(nil), s.hooks...)
+	s.mu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].phase < hooks[j].phase })
+
+	failed := 0
+	for _, hook := range hooks {
+		if err := runShutdownHook(ctx, hook); err != nil {
+			logError("Shutdown %s: %s failed: %v", hook.phase, hook.name, err)
+			failed++
+		}
+	}
+	return failed
+}
+
+// runShutdownHook runs hook, abandoning it when its timeout passes
+func runShutdownHook(ctx context.Context, hook shutdownHook) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout)
+	defer cancel()
+	elapsed := clock.Stopwatch()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- hook.fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+		logInfo("Shutdown %s: %s done in %v", hook.phase, hook.name, elapsed().Round(time.Millisecond))
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %v", hook.timeout)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownRegistryOrder(t *testing.T) {
+	captureLog(t)
+	var ran []string
+	hook := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	registry := &shutdownRegistry{}
+	registry.Register(phaseClose, "store", time.Second, hook("store"))
+	registry.Register(phaseSave, "tasks", time.Second, hook("tasks"))
+	registry.Register(phaseStopAccepting, "health", time.Second, hook("health"))
+	registry.Register(phaseSave, "counters", time.Second, hook("counters"))
+	registry.Register(phaseDrain, "servers", time.Second, hook("servers"))
+
+	if failed := registry.Run(context.Background()); failed != 0 {
+		t.Errorf("failed = %d, want 0", failed)
+	}
+	want := []string{"health", "servers", "tasks", "counters", "store"}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+}
+
+func TestShutdownRegistryFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		fn      func(ctx context.Context) error
+		wantLog string
+	}{
+		{"error", time.Second, func(context.Context) error { return errors.New("disk full") }, "Shutdown save: step failed: disk full"},
+		{"timeout", 10 * time.Millisecond, func(context.Context) error { time.Sleep(time.Second); return nil }, "Shutdown save: step failed: timed out after 10ms"},
+		{"panic", time.Second, func(context.Context) error { panic("boom") }, "Shutdown save: step failed: panic: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLog(t)
+			closed := false
+			registry := &shutdownRegistry{}
+			registry.Register(phaseSave, "step", tt.timeout, tt.fn)
+			registry.Register(phaseClose, "store", time.Second, func(context.Context) error {
+				closed = true
+				return nil
+			})
+
+			if failed := registry.Run(context.Background()); failed != 1 {
+				t.Errorf("failed = %d, want 1", failed)
+			}
+			// A failing hook must not keep later phases from running
+			if !closed {
+				t.Error("close phase did not run")
+			}
+			if !strings.Contains(buf.String(), tt.wantLog) {
+				t.Errorf("log %q does not contain %q", buf, tt.wantLog)
+			}
+		})
+	}
+}
+
+func TestHealthWhileShuttingDown(t *testing.T) {
+	resetStorageBreaker(t)
+	shuttingDown.Store(true)
+	t.Cleanup(func() { shuttingDown.Store(false) })
+
+	rec := httptest.NewRecorder()
+	Health(rec, httptest.NewRequest("GET", "/tasks/health", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "Shutting down" {
+		t.Errorf("got %d %q, want 503 Shutting down", rec.Code, rec.Body)
+	}
+
+	if report := buildHealthReport(newMemoryStore(nil), testNow); report.Status != "shutting_down" {
+		t.Errorf("verbose status = %q, want shutting_down", report.Status)
+	}
+}
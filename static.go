@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// Cache lifetimes of UI files. A hashed name changes with its content, so it can be kept for a year;
+// other names are revalidated with their ETag on every visit.
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+)
+
+const (
+	// staticHashLength is how many hex digits of the content hash go into an asset's name
+	staticHashLength = 10
+	// staticGzipMinimumSaving is how many bytes gzip has to save for a file to be served compressed
+	staticGzipMinimumSaving = 64
+)
+
+// staticFile is one UI file, prepared once at startup
+type staticFile struct {
+	content     []byte
+	gzipped     []byte // nil when compressing doesn't pay
+	contentType string
+	etag        string
+	immutable   bool
+}
+
+// staticSite serves an embedded directory of UI files. Every script and stylesheet is also served
+// under a name carrying a hash of its content (admin.3f2a9c1b7d.js), and HTML pages are rewritten to
+// refer to those names, so a browser fetches each version of an asset once. Responses are gzipped
+// when the client accepts it.
+type staticSite struct {
+	files map[string]*staticFile
+}
+
+// newStaticSite reads every file in fsys
+func newStaticSite(fsys fs.FS) (*staticSite, error) {
+	site := &staticSite{files: map[string]*staticFile{}}
+	var pages []string
+	hashed := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if path.Ext(name) == ".html" {
+			pages = append(pages, name)
+			site.add(name, content, false)
+			return nil
+		}
+		hash := contentHash(content)
+		ext := path.Ext(name)
+		hashed[name] = strings.TrimSuffix(name, ext) + "." + hash[:staticHashLength] + ext
+		site.add(name, content, false)
+		site.add(hashed[name], content, true)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Pages refer to the assets beside them by name, as href="admin.css"
+	for _, name := range pages {
+		content := site.files[name].content
+		for asset, hashedName := range hashed {
+			if path.Dir(asset) == path.Dir(name) {
+				content = bytes.ReplaceAll(content, []byte(`="`+path.Base(asset)+`"`), []byte(`="`+path.Base(hashedName)+`"`))
+			}
+		}
+		site.add(name, content, false)
+	}
+	return site, nil
+}
+
+func (s *staticSite) add(name string, content []byte, immutable bool) {
+	file := &staticFile{content: content, immutable: immutable, etag: `"` + contentHash(content)[:32] + `"`}
+	file.contentType = mime.TypeByExtension(path.Ext(name))
+	if file.contentType == "" {
+		file.contentType = http.DetectContentType(content)
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(content)
+	zw.Close()
+	if buf.Len()+staticGzipMinimumSaving < len(content) {
+		file.gzipped = buf.Bytes()
+	}
+	s.files[name] = file
+}
+
+// ServeHTTP serves the file named by the path, which the caller has stripped of its prefix. A
+// directory path serves its index.html.
+func (s *staticSite) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if name == "" || strings.HasSuffix(name, "/") {
+		name += "index.html"
+	}
+	file, ok := s.files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", file.contentType)
+	header.Set("Vary", "Accept-Encoding")
+	if file.immutable {
+		header.Set("Cache-Control", immutableCacheControl)
+	} else {
+		header.Set("Cache-Control", revalidateCacheControl)
+	}
+	content, etag := file.content, file.etag
+	if file.gzipped != nil && acceptsGzip(r) {
+		// The encoded body is a different representation, so it needs its own validator
+		content, etag = file.gzipped, strings.TrimSuffix(etag, `"`)+`-gzip"`
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("ETag", etag)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
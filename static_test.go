@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticSite(t *testing.T) {
+	script := strings.Repeat("console.log('hello');\n", 20)
+	site, err := newStaticSite(fstest.MapFS{
+		"index.html": {Data: []byte(`<link rel="stylesheet" href="app.css"><script src="app.js"></script>`)},
+		"app.js":     {Data: []byte(script)},
+		"app.css":    {Data: []byte("body {}")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		site.ServeHTTP(rec, req)
+		return rec
+	}
+
+	page := get("/")
+	if page.Code != http.StatusOK || page.Header().Get("Cache-Control") != "no-cache" || page.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("page = %d %v", page.Code, page.Header())
+	}
+	hashedScript := regexp.MustCompile(`src="(app\.[0-9a-f]{10}\.js)"`).FindStringSubmatch(page.Body.String())
+	if hashedScript == nil || !regexp.MustCompile(`href="app\.[0-9a-f]{10}\.css"`).MatchString(page.Body.String()) {
+		t.Fatalf("page doesn't refer to hashed assets: %s", page.Body)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		header       []string
+		wantStatus   int
+		wantCache    string
+		wantEncoding string
+	}{
+		{"Hashed", "/" + hashedScript[1], nil, http.StatusOK, immutableCacheControl, ""},
+		{"Hashed Gzipped", "/" + hashedScript[1], []string{"Accept-Encoding", "br, gzip"}, http.StatusOK, immutableCacheControl, "gzip"},
+		{"Gzip Refused", "/" + hashedScript[1], []string{"Accept-Encoding", "gzip;q=0"}, http.StatusOK, immutableCacheControl, ""},
+		{"Unhashed", "/app.js", nil, http.StatusOK, "no-cache", ""},
+		{"Too Small To Compress", "/app.css", []string{"Accept-Encoding", "gzip"}, http.StatusOK, "no-cache", ""},
+		{"Stale Hash", "/app.0123456789.js", nil, http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(tt.path, tt.header...)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.wantCache)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			body := rec.Body.Bytes()
+			if tt.wantEncoding == "gzip" {
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, _ = io.ReadAll(zr)
+			}
+			if strings.HasSuffix(tt.path, ".js") && string(body) != script {
+				t.Errorf("body = %q", body)
+			}
+
+			// The ETag revalidates the same representation
+			etag := rec.Header().Get("ETag")
+			if again := get(tt.path, append([]string{"If-None-Match", etag}, tt.header...)...); again.Code != http.StatusNotModified {
+				t.Errorf("revalidation with %s = %d, want 304", etag, again.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// storageBuckets are the upper bounds, in seconds, of the storage duration histogram
+var storageBuckets = []float64{0.0001, 0.001, 0.01, 0.1, 1, 10}
+
+// storageOpStats accumulates the timings of one kind of storage operation
+type storageOpStats struct {
+	count   int
+	slow    int
+	seconds float64
+	// buckets[i] counts operations no slower than storageBuckets[i]; slower ones only count towards count
+	buckets []int
+}
+
+// storageTimings times storage operations by name, logging those slower than slowThreshold
+type storageTimings struct {
+	mu  sync.Mutex
+	ops map[string]*storageOpStats
+	// slowThreshold is the duration past which an operation is logged; 0 disables the log
+	slowThreshold time.Duration
+}
+
+// storageMetrics times the task store, its backend, the journal, and counter persistence; set from STORAGE_SLOW_THRESHOLD
+var storageMetrics = &storageTimings{ops: map[string]*storageOpStats{}, slowThreshold: 100 * time.Millisecond}
+
+// observeStorage records one operation on key, started when elapsed was, for use with defer:
+//
+//	defer observeStorage("get", strconv.Itoa(id), clock.Stopwatch())
+func observeStorage(operation, key string, elapsed func() time.Duration) {
+	storageMetrics.observe(operation, key, elapsed())
+}
+
+// timeStorage runs fn as one timed storage operation on key
+func timeStorage(operation, key string, fn func() error) error {
+	defer observeStorage(operation, key, clock.Stopwatch())
+	return fn()
+}
+
+func (m *storageTimings) observe(operation, key string, duration time.Duration) {
+	m.mu.Lock()
+	op := m.ops[operation]
+	if op == nil {
+		op = &storageOpStats{buckets: make([]int, len(storageBuckets))}
+		m.ops[operation] = op
+	}
+	op.count++
+	op.seconds += duration.Seconds()
+	for i, bound := range storageBuckets {
+		if duration.Seconds() <= bound {
+			op.buckets[i]++
+		}
+	}
+	slow := m.slowThreshold > 0 && duration > m.slowThreshold
+	if slow {
+		op.slow++
+	}
+	threshold := m.slowThreshold
+	m.mu.Unlock()
+
+	if slow {
+		if key == "" {
+			key = "-"
+		}
+		logError("Slow storage operation: %s on %s took %v (threshold %v)", operation, key, duration, threshold)
+	}
+}
+
+// writeMetrics writes the timings as a Prometheus histogram and a slow-operation counter, labelled by operation
+func (m *storageTimings) writeMetrics(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	operations := make([]string, 0, len(m.ops))
+	for operation := range m.ops {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	fmt.Fprintf(w, "# HELP task_tracker_storage_operation_seconds Time spent in storage operations, including waiting for the store's lock\n")
+	fmt.Fprintf(w, "# TYPE task_tracker_storage_operation_seconds histogram\n")
+	for _, operation := range operations {
+		op := m.ops[operation]
+		for i, bound := range storageBuckets {
+			fmt.Fprintf(w, "task_tracker_storage_operation_seconds_bucket{operation=%q,le=%q} %d\n", operation, strconv.FormatFloat(bound, 'g', -1, 64), op.buckets[i])
+		}
+		fmt.Fprintf(w, "task_tracker_storage_operation_seconds_bucket{operation=%q,le=\"+Inf\"} %d\n", operation, op.count)
+		fmt.Fprintf(w, "task_tracker_storage_operation_seconds_sum{operation=%q} %g\n", operation, op.seconds)
+		fmt.Fprintf(w, "task_tracker_storage_operation_seconds_count{operation=%q} %d\n", operation, op.count)
+	}
+	fmt.Fprintf(w, "# HELP task_tracker_storage_slow_operations_total Storage operations slower than STORAGE_SLOW_THRESHOLD\n")
+	fmt.Fprintf(w, "# TYPE task_tracker_storage_slow_operations_total counter\n")
+	for _, operation := range operations {
+		fmt.Fprintf(w, "task_tracker_storage_slow_operations_total{operation=%q} %d\n", operation, m.ops[operation].slow)
+	}
+}
+
+// StorageOperationSummary is one operation's timings in GET /admin/summary
+type StorageOperationSummary struct {
+	Count       int     `json:"count"`
+	MeanSeconds float64 `json:"mean_seconds"`
+	Slow        int     `json:"slow"`
+}
+
+func (m *storageTimings) summary() map[string]StorageOperationSummary {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	summary := make(map[string]StorageOperationSummary, len(m.ops))
+	for operation, op := range m.ops {
+		summary[operation] = StorageOperationSummary{Count: op.count, MeanSeconds: op.seconds / float64(op.count), Slow: op.slow}
+	}
+	return summary
+}
+
+// backendKey names where a backend keeps its tasks, for slow-operation logs
+func backendKey(backend taskBackend) string {
+	switch b := backend.(type) {
+	case fileTaskBackend:
+		return b.file()
+	case *sqliteBackend:
+		return b.path
+	}
+	return fmt.Sprintf("%T", backend)
+}
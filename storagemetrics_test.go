@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useStorageMetrics gives a test fresh storage timings with the given slow threshold
+func useStorageMetrics(t *testing.T, threshold time.Duration) *storageTimings {
+	t.Helper()
+	fresh := &storageTimings{ops: map[string]*storageOpStats{}, slowThreshold: threshold}
+	original := storageMetrics
+	storageMetrics = fresh
+	t.Cleanup(func() { storageMetrics = original })
+	return fresh
+}
+
+// slowBackend takes delay of fake-clock time to save
+type slowBackend struct {
+	clock *fakeClock
+	delay time.Duration
+}
+
+func (b slowBackend) Load() ([]Task, error) { return nil, nil }
+func (b slowBackend) Save([]Task) error     { b.clock.Advance(b.delay); return nil }
+func (b slowBackend) Close() error          { return nil }
+
+func TestSlowStorageOperationsAreLogged(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		delay     time.Duration
+		wantLog   string
+	}{
+		{name: "slow save", threshold: 100 * time.Millisecond, delay: 250 * time.Millisecond,
+			wantLog: "Slow storage operation: save on main.slowBackend took 250ms (threshold 100ms)"},
+		{name: "fast save", threshold: 100 * time.Millisecond, delay: 50 * time.Millisecond},
+		{name: "log disabled", threshold: 0, delay: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFakeClock(t, testNow)
+			metrics := useStorageMetrics(t, tt.threshold)
+			logs := captureLog(t)
+			store := newMemoryStore(nil)
+			store.backend = slowBackend{clock: fake, delay: tt.delay}
+
+			if err := store.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if tt.wantLog == "" {
+				if strings.Contains(logs.String(), "Slow storage") {
+					t.Errorf("unexpected slow-operation log:\n%s", logs)
+				}
+			} else if !strings.Contains(logs.String(), tt.wantLog) {
+				t.Errorf("log missing %q:\n%s", tt.wantLog, logs)
+			}
+			if op := metrics.ops["save"]; op == nil || op.count != 1 || op.seconds != tt.delay.Seconds() {
+				t.Errorf("save stats = %+v, want one save of %v", op, tt.delay)
+			}
+		})
+	}
+}
+
+func TestStorageMetricsExposition(t *testing.T) {
+	useFakeClock(t, testNow)
+	useStorageMetrics(t, 0)
+	useTasks(t, []Task{{ID: 1, Title: "One"}})
+	taskStore.Get(1)
+	taskStore.Get(2)
+	taskStore.Update(1, func(t *Task) error { return nil })
+	storageMetrics.observe("save", "tasks.json", 5*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	Metrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE task_tracker_storage_operation_seconds histogram\n",
+		`task_tracker_storage_operation_seconds_count{operation="get"} 2` + "\n",
+		`task_tracker_storage_operation_seconds_bucket{operation="get",le="0.0001"} 2` + "\n",
+		`task_tracker_storage_operation_seconds_count{operation="update"} 1` + "\n",
+		`task_tracker_storage_operation_seconds_bucket{operation="save",le="0.001"} 0` + "\n",
+		`task_tracker_storage_operation_seconds_bucket{operation="save",le="0.01"} 1` + "\n",
+		`task_tracker_storage_operation_seconds_bucket{operation="save",le="+Inf"} 1` + "\n",
+		`task_tracker_storage_operation_seconds_sum{operation="save"} 0.005` + "\n",
+		`task_tracker_storage_slow_operations_total{operation="save"} 0` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q", want)
+		}
+	}
+}
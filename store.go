@@ -0,0 +1,617 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrTaskNotFound is returned by TaskStore methods when no task has the requested ID
+var ErrTaskNotFound = errors.New("task not found")
+
+// ErrSnapshotChanged is returned by DeleteWhere when the list changed after the caller's snapshot
+var ErrSnapshotChanged = errors.New("tasks changed since the snapshot")
+
+// ErrTaskIDExhausted is returned by Create once maxTaskID has been issued
+var ErrTaskIDExhausted = errors.New("task ID space exhausted")
+
+// ErrUnsavedChanges is returned by Reload when it would discard changes not yet saved
+var ErrUnsavedChanges = errors.New("there are unsaved changes")
+
+// ErrTaskIDTaken is returned by Insert when a task already has one of the IDs
+var ErrTaskIDTaken = errors.New("task ID already in use")
+
+// ErrParentNotFound is returned by Insert when a task's parent is neither inserted with it nor a
+// stored task with the same owner
+var ErrParentNotFound = errors.New("parent task does not exist")
+
+// TaskStore is how handlers read and change tasks. Every method is safe for concurrent use,
+// and returned tasks are copies the caller may keep.
+type TaskStore interface {
+	// List returns every task in display order
+	List() []Task
+	// Get returns the task with the given ID
+	Get(id int) (Task, error)
+	// Create assigns the next ID and version 1 to task and appends it to the list
+	Create(task Task) (Task, error)
+	// Update applies fn to the task atomically and bumps its version; if fn returns an error the
+	// task is left unchanged
+	Update(id int, fn func(*Task) error) (Task, error)
+	// Delete removes the task with the given ID
+	Delete(id int) error
+	// Reorder rearranges the list to match ids, which must name every task exactly once
+	Reorder(ids []int) ([]Task, error)
+	// Modified reports when the list last changed, the zero time if never
+	Modified() time.Time
+	// Generation counts changes to the list, identifying the snapshot a client last read
+	Generation() uint64
+	// DeleteWhere removes the tasks match accepts, provided the list is still at generation;
+	// otherwise it deletes nothing and returns ErrSnapshotChanged
+	DeleteWhere(generation uint64, match func(Task) bool) ([]Task, error)
+	// Insert appends tasks keeping their IDs, as when restoring an export. onConflict says what
+	// happens to a task whose ID is taken: conflictFail inserts nothing and returns ErrTaskIDTaken,
+	// conflictSkip leaves the task out, and conflictRemap gives it a new ID.
+	Insert(tasks []Task, onConflict string) (InsertResult, error)
+	// WithTx runs fn against tx, a view of the store whose changes all take effect when fn returns nil
+	// and none do when it returns an error. The store is locked until fn returns, so fn must use tx,
+	// never the store itself.
+	WithTx(fn func(tx TaskStore) error) error
+}
+
+// What Insert does with a task whose ID is already in use
+const (
+	conflictFail  = "fail"
+	conflictSkip  = "skip"
+	conflictRemap = "remap"
+)
+
+// InsertResult is what Insert did
+type InsertResult struct {
+	Inserted []Task
+	// Skipped are the taken IDs of the tasks conflictSkip left out
+	Skipped []int
+	// IDMap maps the old ID of each task conflictRemap renumbered to its new one
+	IDMap map[int]int
+}
+
+// taskStore is the store the HTTP handlers use
+var taskStore TaskStore = newMemoryStore(nil)
+
+// memoryStore keeps tasks in memory, optionally persisting them to a backend
+type memoryStore struct {
+	mu       sync.Mutex
+	tasks    []Task
+	lastID   int
+	modified time.Time
+	// generation is bumped by every change
+	generation uint64
+	// listJSON caches the marshaled task list for GET /tasks; nil means it must be regenerated, as it
+	// must once listJSONUntil passes and a lock it shows has expired
+	listJSON      []byte
+	listJSONUntil time.Time
+
+	backend      taskBackend
+	writeThrough bool
+	// unsaved counts changes since the last save. Once it reaches autosaveAfter, saveDue is signalled.
+	unsaved       int
+	autosaveAfter int
+	saveDue       chan struct{}
+	// journal, when attached, records each change before it is applied
+	journal *taskJournal
+	// lastSaved and saveLatency describe the last successful save, and dirtySince is when the oldest
+	// unsaved change was made, for health checks
+	lastSaved        time.Time
+	saveLatency      time.Duration
+	dirtySince       time.Time
+	autosaveInterval time.Duration
+
+	// inTx marks the private copy WithTx hands to its function: instead of journalling changes and
+	// counting events, it holds them in txEntries and txEvents until the transaction commits
+	inTx      bool
+	txEntries []journalEntry
+	txEvents  []func()
+}
+
+// newMemoryStore returns a store holding list, with IDs continuing after its highest ID
+func newMemoryStore(list []Task) *memoryStore {
+	s := &memoryStore{tasks: []Task{}}
+	for _, t := range list {
+		s.tasks = append(s.tasks, t.clone())
+		if t.ID > s.lastID {
+			s.lastID = t.ID
+		}
+	}
+	s.modified = clock.Now()
+	return s
+}
+
+// openTaskStore loads a memory store from backend, recovering as loadTasks does. With writeThrough every
+// change is saved before the handler responds; otherwise the caller saves with Flush.
+func openTaskStore(backend taskBackend, writeThrough bool) (*memoryStore, error) {
+	loaded, err := loadTasks(backend)
+	if err != nil {
+		return nil, err
+	}
+	s := newMemoryStore(loaded)
+	s.backend, s.writeThrough = backend, writeThrough
+	return s, nil
+}
+
+func (s *memoryStore) List() []Task {
+	defer observeStorage("list", "", clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list, now := s.snapshot(), clock.Now()
+	for i := range list {
+		list[i] = withoutExpiredLock(list[i], now)
+	}
+	return list
+}
+
+func (s *memoryStore) Get(id int) (Task, error) {
+	defer observeStorage("get", strconv.Itoa(id), clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.indexOf(id)
+	if index == -1 {
+		return Task{}, ErrTaskNotFound
+	}
+	return withoutExpiredLock(s.tasks[index].clone(), clock.Now()), nil
+}
+
+func (s *memoryStore) Create(task Task) (Task, error) {
+	defer observeStorage("create", "", clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastID >= maxTaskID {
+		return Task{}, ErrTaskIDExhausted
+	}
+	task.ID = s.lastID + 1
+	task.Version = 1
+	if err := s.record(putEntry(task)); err != nil {
+		return Task{}, err
+	}
+	s.lastID++
+	s.tasks = append(s.tasks, task.clone())
+	s.changed()
+	s.afterCommit(taskEvents.recordCreated)
+	s.publishChange(changeCreated, task)
+	return task, nil
+}
+
+func (s *memoryStore) Update(id int, fn func(*Task) error) (Task, error) {
+	defer observeStorage("update", strconv.Itoa(id), clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.indexOf(id)
+	if index == -1 {
+		return Task{}, ErrTaskNotFound
+	}
+	// fn works on a copy so a failed update can't leave a half-applied change behind
+	updated := withoutExpiredLock(s.tasks[index].clone(), clock.Now())
+	if err := fn(&updated); err != nil {
+		return Task{}, err
+	}
+	updated.ID = id
+	updated.Version = s.tasks[index].Version
+	if contentChanged(s.tasks[index], updated) {
+		updated.Version++
+	}
+	if err := s.record(putEntry(updated)); err != nil {
+		return Task{}, err
+	}
+	wasCompleted := s.tasks[index].Completed
+	s.tasks[index] = updated
+	s.changed()
+	if updated.Completed && !wasCompleted {
+		completed, at := updated.clone(), clock.Now()
+		s.afterCommit(func() { taskEvents.recordCompleted(completed, at) })
+	}
+	s.publishChange(changeUpdated, updated)
+	return updated.clone(), nil
+}
+
+func (s *memoryStore) Delete(id int) error {
+	defer observeStorage("delete", strconv.Itoa(id), clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.indexOf(id)
+	if index == -1 {
+		return ErrTaskNotFound
+	}
+	if err := s.record(journalEntry{Op: "delete", IDs: []int{id}}); err != nil {
+		return err
+	}
+	s.publishChange(changeDeleted, s.tasks[index])
+	s.tasks = append(s.tasks[:index], s.tasks[index+1:]...)
+	s.changed()
+	return nil
+}
+
+func (s *memoryStore) Reorder(ids []int) ([]Task, error) {
+	defer observeStorage("reorder", "", clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reordered, err := orderTasks(s.tasks, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.record(journalEntry{Op: "order", IDs: ids}); err != nil {
+		return nil, err
+	}
+	s.tasks = reordered
+	s.changed()
+	return s.snapshot(), nil
+}
+
+func (s *memoryStore) Modified() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modified
+}
+
+func (s *memoryStore) Generation() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation
+}
+
+func (s *memoryStore) DeleteWhere(generation uint64, match func(Task) bool) ([]Task, error) {
+	defer observeStorage("delete_where", "", clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if generation != s.generation {
+		return nil, ErrSnapshotChanged
+	}
+	deleted := []Task{}
+	var ids []int
+	for _, t := range s.tasks {
+		if match(t) {
+			deleted = append(deleted, t.clone())
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(deleted) == 0 {
+		return deleted, nil
+	}
+	if err := s.record(journalEntry{Op: "delete", IDs: ids}); err != nil {
+		return nil, err
+	}
+	s.tasks, _ = applyJournalEntry(s.tasks, journalEntry{Op: "delete", IDs: ids})
+	s.changed()
+	for _, t := range deleted {
+		s.publishChange(changeDeleted, t)
+	}
+	return deleted, nil
+}
+
+func (s *memoryStore) Insert(tasks []Task, onConflict string) (InsertResult, error) {
+	defer observeStorage("insert", "", clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var taken []int
+	for _, t := range tasks {
+		if s.indexOf(t.ID) != -1 {
+			taken = append(taken, t.ID)
+		}
+	}
+	result := InsertResult{Inserted: []Task{}}
+	switch onConflict {
+	case conflictSkip:
+		result.Skipped = []int{}
+	case conflictRemap:
+		result.IDMap = map[int]int{}
+	}
+	if len(taken) > 0 {
+		var err error
+		if tasks, err = s.resolveConflicts(tasks, taken, onConflict, &result); err != nil {
+			return InsertResult{}, err
+		}
+	}
+	if len(tasks) == 0 {
+		return result, nil
+	}
+	if err := s.checkInsertedParents(tasks); err != nil {
+		return InsertResult{}, err
+	}
+	entries := make([]journalEntry, len(tasks))
+	for i, t := range tasks {
+		entries[i] = putEntry(t)
+	}
+	if err := s.record(entries...); err != nil {
+		return InsertResult{}, err
+	}
+	for _, t := range tasks {
+		s.tasks = append(s.tasks, t.clone())
+		if t.ID > s.lastID {
+			s.lastID = t.ID
+		}
+		result.Inserted = append(result.Inserted, t.clone())
+		s.afterCommit(taskEvents.recordCreated)
+		s.publishChange(changeCreated, t)
+	}
+	s.changed()
+	return result, nil
+}
+
+// checkInsertedParents makes sure the parent of each of tasks is another of them or a stored task with
+// the same owner, so an import can't hang a task under one its owner can't see. Callers must hold s.mu.
+func (s *memoryStore) checkInsertedParents(tasks []Task) error {
+	inserted := make(map[int]bool, len(tasks))
+	for _, t := range tasks {
+		inserted[t.ID] = true
+	}
+	for _, t := range tasks {
+		if t.ParentID == 0 || inserted[t.ParentID] {
+			continue
+		}
+		if i := s.indexOf(t.ParentID); i == -1 || s.tasks[i].Owner != t.Owner {
+			return fmt.Errorf("%w: task %d has parent_id %d", ErrParentNotFound, t.ID, t.ParentID)
+		}
+	}
+	return nil
+}
+
+// resolveConflicts applies the onConflict policy to tasks, some of whose IDs are taken. Remapped
+// tasks are numbered after both the stored and the imported IDs, and parent_id references within the
+// import follow them; references to a skipped task are left to the stored task with its ID, which
+// checkInsertedParents then requires to have the same owner.
+func (s *memoryStore) resolveConflicts(tasks []Task, taken []int, onConflict string, result *InsertResult) ([]Task, error) {
+	isTaken := make(map[int]bool, len(taken))
+	for _, id := range taken {
+		isTaken[id] = true
+	}
+	switch onConflict {
+	case conflictSkip:
+		kept := make([]Task, 0, len(tasks)-len(taken))
+		for _, t := range tasks {
+			if isTaken[t.ID] {
+				result.Skipped = append(result.Skipped, t.ID)
+			} else {
+				kept = append(kept, t)
+			}
+		}
+		return kept, nil
+	case conflictRemap:
+		next := s.lastID
+		for _, t := range tasks {
+			if t.ID > next {
+				next = t.ID
+			}
+		}
+		if next > maxTaskID-len(taken) {
+			return nil, ErrTaskIDExhausted
+		}
+		remapped := make([]Task, len(tasks))
+		for i, t := range tasks {
+			if isTaken[t.ID] {
+				next++
+				result.IDMap[t.ID] = next
+				t.ID = next
+			}
+			remapped[i] = t
+		}
+		for i := range remapped {
+			if id, ok := result.IDMap[remapped[i].ParentID]; ok {
+				remapped[i].ParentID = id
+			}
+		}
+		return remapped, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrTaskIDTaken, formatIDs(taken))
+}
+
+// ListJSON returns the marshaled task list, re-marshalling only after a change.
+// The returned slice is shared and must not be modified.
+func (s *memoryStore) ListJSON() ([]byte, error) {
+	defer observeStorage("list", "", clock.Stopwatch())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clock.Now()
+	if s.listJSON == nil || (!s.listJSONUntil.IsZero() && !now.Before(s.listJSONUntil)) {
+		list, until := make([]Task, len(s.tasks)), time.Time{}
+		for i, t := range s.tasks {
+			list[i] = withoutExpiredLock(t, now)
+			if lock := list[i].Lock; lock != nil && (until.IsZero() || lock.ExpiresAt.Before(until)) {
+				until = lock.ExpiresAt
+			}
+		}
+		data, err := json.Marshal(list)
+		if err != nil {
+			return nil, err
+		}
+		logDebug("Re-encoded task list cache (%d tasks, %d bytes)", len(s.tasks), len(data))
+		s.listJSON, s.listJSONUntil = data, until
+	}
+	return s.listJSON, nil
+}
+
+// Flush saves the current list to the backend, if there is one, then empties the journal it supersedes
+func (s *memoryStore) Flush() error {
+	return s.flush(storageRetry{release: &s.mu})
+}
+
+// FlushFinal is Flush for shutdown: it tries to save even while the storage breaker is open
+func (s *memoryStore) FlushFinal() error {
+	return s.flush(storageRetry{final: true, release: &s.mu})
+}
+
+func (s *memoryStore) flush(retry storageRetry) error {
+	if s.backend == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.save(retry); err != nil {
+		return err
+	}
+	s.unsaved = 0
+	if s.journal != nil {
+		// Replay is idempotent, so a journal left behind by a failed truncate is harmless
+		if err := s.journal.truncate(); err != nil {
+			logError("Failed to compact journal %s: %v", s.journal.filename, err)
+		}
+	}
+	return nil
+}
+
+// Reload replaces the list with what the backend holds, as after the tasks file was edited by hand, and
+// returns the number of tasks loaded. Unless discard is set it fails with ErrUnsavedChanges while any
+// change is unsaved. IDs issued before the reload are never reissued.
+func (s *memoryStore) Reload(discard bool) (int, error) {
+	if s.backend == nil {
+		return 0, errors.New("the store has no backend to reload from")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unsaved > 0 && !discard {
+		return 0, fmt.Errorf("%w (%d)", ErrUnsavedChanges, s.unsaved)
+	}
+	var loaded []Task
+	err := storageRetry{release: &s.mu}.run("reload tasks", func() error {
+		return timeStorage("load", backendKey(s.backend), func() (err error) {
+			loaded, err = s.backend.Load()
+			return err
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	// s.mu was released between attempts, so a change may have been made meanwhile
+	if s.unsaved > 0 && !discard {
+		return 0, fmt.Errorf("%w (%d)", ErrUnsavedChanges, s.unsaved)
+	}
+	s.tasks = make([]Task, len(loaded))
+	for i, t := range loaded {
+		s.tasks[i] = t.clone()
+		if t.ID > s.lastID {
+			s.lastID = t.ID
+		}
+	}
+	// The list now matches the backend, so nothing is unsaved and the journal's changes are superseded
+	s.modified = clock.Now()
+	s.generation++
+	s.listJSON = nil
+	s.unsaved = 0
+	if s.journal != nil {
+		if err := s.journal.truncate(); err != nil {
+			logError("Failed to compact journal %s: %v", s.journal.filename, err)
+		}
+	}
+	return len(s.tasks), nil
+}
+
+// Close releases the backend and journal. It does not save; call Flush first.
+func (s *memoryStore) Close() error {
+	if s.journal != nil {
+		if err := s.journal.Close(); err != nil {
+			logError("Failed to close journal %s: %v", s.journal.filename, err)
+		}
+	}
+	if s.backend == nil {
+		return nil
+	}
+	return s.backend.Close()
+}
+
+// changed records a mutation: it drops the cached encoding and writes through when configured.
+// A change that fails to write through is counted as unsaved like any other, so autosave retries it
+// and health checks report it. Every write to s.tasks must call it. Callers must hold s.mu.
+func (s *memoryStore) changed() {
+	s.modified = clock.Now()
+	s.generation++
+	s.listJSON = nil
+	if s.backend != nil && s.writeThrough {
+		// The caller's change must stay atomic, so there's no giving up s.mu to back off and retry
+		err := s.save(storageRetry{once: true})
+		if err == nil {
+			// The whole list was saved, including any change that failed to write through before
+			s.unsaved = 0
+			return
+		}
+		logError("Failed to write tasks through to storage: %v", err)
+	}
+	if s.unsaved == 0 {
+		s.dirtySince = s.modified
+	}
+	s.unsaved++
+	if s.autosaveAfter > 0 && s.unsaved >= s.autosaveAfter {
+		select {
+		case s.saveDue <- struct{}{}:
+		default: // a save is already due
+		}
+	}
+}
+
+// save writes the list to the backend, recording when and how quickly it succeeded. Callers must hold
+// s.mu; if retry releases it between attempts, each attempt saves the list as it is by then.
+func (s *memoryStore) save(retry storageRetry) error {
+	elapsed := clock.Stopwatch()
+	err := retry.run("save tasks", func() error {
+		return timeStorage("save", backendKey(s.backend), func() error { return s.backend.Save(s.tasks) })
+	})
+	if err == nil {
+		s.lastSaved, s.saveLatency = clock.Now(), elapsed()
+	}
+	return err
+}
+
+// indexOf returns the index of the task with the given ID, or -1 if absent. Callers must hold s.mu.
+func (s *memoryStore) indexOf(id int) int {
+	for i, t := range s.tasks {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// snapshot copies the task list. Callers must hold s.mu.
+func (s *memoryStore) snapshot() []Task {
+	list := make([]Task, len(s.tasks))
+	for i, t := range s.tasks {
+		list[i] = t.clone()
+	}
+	return list
+}
+
+// clone returns a copy of t that shares no slices or pointers with it
+func (t Task) clone() Task {
+	if t.Checklist != nil {
+		t.Checklist = append([]ChecklistItem(nil), t.Checklist...)
+	}
+	if t.ChecklistCompletion != nil {
+		completion := *t.ChecklistCompletion
+		t.ChecklistCompletion = &completion
+	}
+	if t.Tags != nil {
+		t.Tags = append([]string(nil), t.Tags...)
+	}
+	if t.Links != nil {
+		t.Links = append([]TaskLink(nil), t.Links...)
+	}
+	if t.Lock != nil {
+		lock := *t.Lock
+		t.Lock = &lock
+	}
+	if t.CreatedAt != nil {
+		created := *t.CreatedAt
+		t.CreatedAt = &created
+	}
+	if t.UpdatedAt != nil {
+		updated := *t.UpdatedAt
+		t.UpdatedAt = &updated
+	}
+	return t
+}
+
+// encodeTaskList marshals the full list, using the store's cached encoding when it keeps one
+func encodeTaskList(store TaskStore) ([]byte, error) {
+	if cached, ok := store.(interface{ ListJSON() ([]byte, error) }); ok {
+		return cached.ListJSON()
+	}
+	return json.Marshal(store.List())
+}
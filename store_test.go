@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// useTasks installs a fresh memory store holding list for the rest of the test
+func useTasks(t testing.TB, list []Task) *memoryStore {
+	t.Helper()
+	store := newMemoryStore(list)
+	original := taskStore
+	taskStore = store
+	t.Cleanup(func() { taskStore = original })
+	return store
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := newMemoryStore([]Task{{ID: 4, Title: "Existing"}})
+
+	created, err := store.Create(Task{ID: 99, Title: "New"})
+	if err != nil || created.ID != 5 {
+		t.Fatalf("Create = %+v, %v; want ID 5 continuing after the highest loaded ID", created, err)
+	}
+
+	// A failed update must leave the task exactly as it was, even when fn touched slices first
+	_, err = store.Update(5, func(task *Task) error {
+		task.Title = "Half-applied"
+		task.Checklist = append(task.Checklist, ChecklistItem{ID: 1, Text: "Leaked"})
+		return errors.New("rejected")
+	})
+	if err == nil || err.Error() != "rejected" {
+		t.Errorf("Update error = %v, want the error from fn", err)
+	}
+	if got, _ := store.Get(5); got.Title != "New" || got.Checklist != nil {
+		t.Errorf("task after failed update = %+v, want it unchanged", got)
+	}
+
+	// Returned tasks are copies
+	listed := store.List()
+	listed[0].Title = "Mutated by caller"
+	if got, _ := store.Get(4); got.Title != "Existing" {
+		t.Errorf("List handed out shared state: stored title is %q", got.Title)
+	}
+
+	if err := store.Delete(4); err != nil {
+		t.Errorf("Delete = %v", err)
+	}
+	if _, err := store.Get(4); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Get after delete = %v, want ErrTaskNotFound", err)
+	}
+	if _, err := store.Update(4, func(*Task) error { return nil }); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Update of missing task = %v, want ErrTaskNotFound", err)
+	}
+	if err := store.Delete(4); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Delete of missing task = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestMemoryStoreIDExhaustion(t *testing.T) {
+	store := newMemoryStore([]Task{{ID: maxTaskID, Title: "Last"}})
+	if _, err := store.Create(Task{Title: "One too many"}); !errors.Is(err, ErrTaskIDExhausted) {
+		t.Errorf("Create = %v, want ErrTaskIDExhausted", err)
+	}
+}
+
+// unavailableStore is a fake TaskStore whose writes always fail, as a broken backend would
+type unavailableStore struct {
+	TaskStore
+}
+
+var errUnavailable = errors.New("backend unavailable")
+
+func (unavailableStore) Create(Task) (Task, error) { return Task{}, errUnavailable }
+func (unavailableStore) Update(int, func(*Task) error) (Task, error) {
+	return Task{}, errUnavailable
+}
+func (unavailableStore) Delete(int) error { return errUnavailable }
+func (s unavailableStore) WithTx(fn func(TaskStore) error) error {
+	return s.TaskStore.WithTx(func(tx TaskStore) error { return fn(unavailableStore{tx}) })
+}
+
+func TestHandlersSurfaceStoreFailures(t *testing.T) {
+	original := taskStore
+	taskStore = unavailableStore{TaskStore: newMemoryStore(nil)}
+	t.Cleanup(func() { taskStore = original })
+
+	tests := []struct {
+		method   string
+		url      string
+		payload  string
+		wantBody string
+	}{
+		{http.MethodPost, "/tasks", `{"title": "New"}`, `{"error":"Failed to create task","code":"internal_error"}`},
+		{http.MethodPut, "/tasks/1", `{"title": "Renamed"}`, `{"error":"Failed to update task","code":"internal_error"}`},
+		{http.MethodDelete, "/tasks/1", "", `{"error":"Failed to delete task","code":"internal_error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Tasks(rec, httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload)))
+			if rec.Code != http.StatusInternalServerError {
+				t.Errorf("got status %d, want %d", rec.Code, http.StatusInternalServerError)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Subtasks serves GET /tasks/{id}/subtasks, the task's direct children in stored order
+func Subtasks(w http.ResponseWriter, r *http.Request) {
+	ID, err := ParseTaskID(r)
+	if err != nil {
+		logErrorContext(r.Context(), err.Error())
+		writeJsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	list := storeFor(r).List()
+	if indexOfTask(list, ID) == -1 {
+		logErrorContext(r.Context(), "Task not found with ID %d in subtasks", ID)
+		writeJsonErrorCode(w, http.StatusNotFound, codeTaskNotFound, fmt.Sprintf("No task found with ID %d", ID))
+		return
+	}
+	children := []Task{}
+	for _, t := range list {
+		if t.ParentID == ID {
+			children = append(children, t)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(children)
+}
+
+// validateParent checks that parentID names an existing task and, for an existing task id,
+// that making it the parent wouldn't create a cycle. A zero parentID means no parent.
+func validateParent(list []Task, id, parentID int) error {
+	if parentID == 0 {
+		return nil
+	}
+	if parentID == id {
+		return errors.New("A task cannot be its own parent")
+	}
+	// Walk up from the new parent; reaching id means id would become its own ancestor.
+	// The step bound stops the walk on cycles already in the data.
+	for ancestor, steps := parentID, 0; ancestor != 0 && steps <= len(list); steps++ {
+		index := indexOfTask(list, ancestor)
+		if index == -1 {
+			if ancestor == parentID {
+				return fmt.Errorf("parent_id %d does not exist", parentID)
+			}
+			break
+		}
+		if id != 0 && ancestor == id {
+			return fmt.Errorf("parent_id %d would make task %d its own ancestor", parentID, id)
+		}
+		ancestor = list[index].ParentID
+	}
+	return nil
+}
+
+// incompleteDescendants returns the IDs of every open task below id, depth first
+func incompleteDescendants(list []Task, id int) []int {
+	var open []int
+	visited := map[int]bool{id: true}
+	var walk func(parent int)
+	walk = func(parent int) {
+		for _, t := range list {
+			if t.ParentID != parent || visited[t.ID] {
+				continue
+			}
+			visited[t.ID] = true
+			if !t.Completed {
+				open = append(open, t.ID)
+			}
+			walk(t.ID)
+		}
+	}
+	walk(id)
+	return open
+}
+
+// formatIDs joins task IDs for error messages
+func formatIDs(ids []int) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// indexOfTask returns the index of the task with the given ID in list, or -1
+func indexOfTask(list []Task, id int) int {
+	for i, t := range list {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// detachSubtasks makes the subtasks of the deleted tasks top-level tasks. Callers run it in the
+// transaction that deleted them, so no subtask is left pointing at a missing parent.
+func detachSubtasks(tx TaskStore, deleted map[int]bool) error {
+	for _, t := range tx.List() {
+		if !deleted[t.ParentID] {
+			continue
+		}
+		_, err := tx.Update(t.ID, func(child *Task) error {
+			child.ParentID = 0
+			child.touch(clock.Now())
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("detaching subtask %d of deleted task %d: %w", t.ID, t.ParentID, err)
+		}
+	}
+	return nil
+}
+
+// completeSubtasks completes every open task below id
+func completeSubtasks(tx TaskStore, id int) error {
+	for _, childID := range incompleteDescendants(tx.List(), id) {
+		_, err := tx.Update(childID, func(t *Task) error {
+			t.Completed = true
+			t.touch(clock.Now())
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("completing subtask %d of task %d: %w", childID, id, err)
+		}
+	}
+	return nil
+}
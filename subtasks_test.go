@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSubtasks(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Move house"},
+		{ID: 2, Title: "Pack", ParentID: 1},
+		{ID: 3, Title: "Pack books", ParentID: 2},
+		{ID: 4, Title: "Book van", ParentID: 1, Completed: true},
+	})
+
+	tests := []struct {
+		name       string // Test case name
+		method     string // HTTP method
+		url        string // Request URL
+		payload    string // The JSON payload sent in the request
+		wantStatus int    // Expected HTTP status code
+		wantBody   string // Expected response body
+	}{
+		{"List Subtasks", http.MethodGet, "/tasks/1/subtasks", "", http.StatusOK,
+			`[{"id":2,"title":"Pack","completed":false,"parent_id":1},{"id":4,"title":"Book van","completed":true,"parent_id":1}]`},
+		{"Subtasks Of Missing Task", http.MethodGet, "/tasks/99/subtasks", "", http.StatusNotFound, `{"error":"No task found with ID 99","code":"task_not_found"}`},
+		{"Create With Missing Parent", http.MethodPost, "/tasks", `{"title": "Stray", "parent_id": 99}`, http.StatusBadRequest, `{"error":"parent_id 99 does not exist","code":"validation_failed"}`},
+		{"Own Parent", http.MethodPut, "/tasks/2", `{"title": "Pack", "parent_id": 2}`, http.StatusBadRequest, `{"error":"A task cannot be its own parent","code":"validation_failed"}`},
+		{"Cycle", http.MethodPut, "/tasks/1", `{"title": "Move house", "parent_id": 3}`, http.StatusBadRequest, `{"error":"parent_id 3 would make task 1 its own ancestor","code":"validation_failed"}`},
+		{"Blocked By Open Subtasks", http.MethodPut, "/tasks/1?subtasks=block", `{"title": "Move house", "completed": true}`, http.StatusConflict,
+			`{"error":"Task 1 has incomplete subtasks: 2, 3","code":"conflict"}`},
+		{"Unknown Mode", http.MethodPut, "/tasks/1?subtasks=skip", `{"title": "Move house", "completed": true}`, http.StatusBadRequest,
+			`{"error":"Unknown subtasks \"skip\", must be cascade or block","code":"invalid_request"}`},
+		{"Cascade", http.MethodPut, "/tasks/1?subtasks=cascade", `{"title": "Move house", "completed": true}`, http.StatusOK,
+			`{"id":1,"title":"Move house","completed":true,"updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Cascade Reached Grandchildren", http.MethodGet, "/tasks?completed=false", "", http.StatusOK, `[]`},
+		{"Delete Detaches Subtasks", http.MethodDelete, "/tasks/2", "", http.StatusOK, `{"message":"Task deleted","status":"success"}`},
+		{"Grandchild Is Top Level", http.MethodGet, "/tasks?fields=id,parent_id", "", http.StatusOK, `[{"id":1},{"id":3},{"id":4,"parent_id":1}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxTagLength bounds a single tag, in bytes after normalization
+const maxTagLength = 64
+
+// normalizeTags lowercases and trims tags and drops duplicates, keeping first-seen order
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(normalizeText(tag)))
+		if tag == "" {
+			return nil, errors.New("tags cannot be empty")
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("tags must be at most %d bytes", maxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// hasTag reports whether the task carries tag
+func (t Task) hasTag(tag string) bool {
+	for _, have := range t.Tags {
+		if have == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllTags reports whether the task carries every tag in tags
+func hasAllTags(t Task, tags []string) bool {
+	for _, tag := range tags {
+		if !t.hasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// TagCount is one entry of GET /tags
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// countTags returns every distinct tag in list with the number of tasks carrying it, sorted by tag
+func countTags(list []Task) []TagCount {
+	counts := map[string]int{}
+	for _, t := range list {
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags
+}
+
+// Tags serves GET /tags, the distinct tags in use with task counts
+func Tags(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method: %s", r.Method)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(countTags(storeFor(r).List()))
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{
+		{ID: 1, Title: "Report", Tags: []string{"work", "writing"}},
+		{ID: 2, Title: "Groceries", Tags: []string{"home"}},
+		{ID: 3, Title: "Standup", Tags: []string{"work"}},
+	})
+
+	tests := []struct {
+		name       string // Test case name
+		method     string // HTTP method
+		url        string // Request URL
+		payload    string // The JSON payload sent in the request
+		wantStatus int    // Expected HTTP status code
+		wantBody   string // Expected response body
+	}{
+		{"Create Normalizes Tags", http.MethodPost, "/tasks", `{"title": "Invoice", "tags": [" Work ", "work", "Money"]}`, http.StatusCreated,
+			`{"id":4,"title":"Invoice","completed":false,"tags":["work","money"],"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Empty Tag Rejected", http.MethodPost, "/tasks", `{"title": "Bad", "tags": [" "]}`, http.StatusBadRequest, `{"error":"tags cannot be empty","code":"validation_failed"}`},
+		{"Filter By Tag", http.MethodGet, "/tasks?tag=WORK&fields=id", "", http.StatusOK, `[{"id":1},{"id":3},{"id":4}]`},
+		{"Filter By Several Tags", http.MethodGet, "/tasks?tag=work&tag=writing&fields=id", "", http.StatusOK, `[{"id":1}]`},
+		{"PUT Without Tags Keeps Them", http.MethodPut, "/tasks/2", `{"title": "Groceries", "completed": true}`, http.StatusOK,
+			`{"id":2,"title":"Groceries","completed":true,"tags":["home"],"updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"PUT Replaces Tags", http.MethodPut, "/tasks/2", `{"title": "Groceries", "completed": true, "tags": ["errands"]}`, http.StatusOK,
+			`{"id":2,"title":"Groceries","completed":true,"tags":["errands"],"updated_at":"2024-05-01T12:00:00Z","version":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantBody {
+				t.Errorf("got body %s, want %s", got, tt.wantBody)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	Tags(rec, httptest.NewRequest(http.MethodGet, "/tags", nil))
+	want := `[{"tag":"errands","count":1},{"tag":"money","count":1},{"tag":"work","count":3},{"tag":"writing","count":1}]`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("GET /tags got %s, want %s", got, want)
+	}
+}
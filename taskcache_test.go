@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type taskCacheTestCase struct {
+	name     string // Test case name
+	method   string // HTTP method of the mutation
+	url      string // Endpoint of the mutation
+	payload  string // The JSON payload sent in the request
+	wantList string // Expected GET /tasks body after the mutation
+}
+
+// taskCacheTests run in order; each mutation must be visible in the next list response
+var taskCacheTests = []taskCacheTestCase{
+	{
+		name:     "After POST",
+		method:   http.MethodPost,
+		url:      "/tasks",
+		payload:  `{"title": "Second"}`,
+		wantList: `[{"id":1,"title":"First","completed":false},{"id":2,"title":"Second","completed":false,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}]`,
+	},
+	{
+		name:     "After PUT",
+		method:   http.MethodPut,
+		url:      "/tasks/1",
+		payload:  `{"title": "First (edited)", "completed": true}`,
+		wantList: `[{"id":1,"title":"First (edited)","completed":true,"updated_at":"2024-05-01T12:00:00Z","version":1},{"id":2,"title":"Second","completed":false,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}]`,
+	},
+	{
+		name:     "After Checklist Change",
+		method:   http.MethodPost,
+		url:      "/tasks/2/checklist",
+		payload:  `{"text": "Step"}`,
+		wantList: `[{"id":1,"title":"First (edited)","completed":true,"updated_at":"2024-05-01T12:00:00Z","version":1},{"id":2,"title":"Second","completed":false,"checklist":[{"id":1,"text":"Step","done":false}],"checklist_completion":0,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":2}]`,
+	},
+	{
+		name:     "After DELETE",
+		method:   http.MethodDelete,
+		url:      "/tasks/1",
+		wantList: `[{"id":2,"title":"Second","completed":false,"checklist":[{"id":1,"text":"Step","done":false}],"checklist_completion":0,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":2}]`,
+	},
+	{
+		name:     "After Failed Mutation",
+		method:   http.MethodDelete,
+		url:      "/tasks/999",
+		wantList: `[{"id":2,"title":"Second","completed":false,"checklist":[{"id":1,"text":"Step","done":false}],"checklist_completion":0,"created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":2}]`,
+	},
+}
+
+func TestTaskListCacheInvalidation(t *testing.T) {
+	useFakeClock(t, testNow)
+	useTasks(t, []Task{{ID: 1, Title: "First"}})
+
+	// Prime the cache
+	rec := httptest.NewRecorder()
+	Tasks(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+	if got := strings.TrimSpace(rec.Body.String()); got != `[{"id":1,"title":"First","completed":false}]` {
+		t.Fatalf("got initial list %s", got)
+	}
+
+	for _, tt := range taskCacheTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.payload))
+			Tasks(httptest.NewRecorder(), req)
+
+			rec := httptest.NewRecorder()
+			Tasks(rec, httptest.NewRequest(http.MethodGet, "/tasks", nil))
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.wantList {
+				t.Errorf("Test %s: got list %s, want %s", tt.name, got, tt.wantList)
+			}
+		})
+	}
+}
+
+func TestTaskListCacheReused(t *testing.T) {
+	store := useTasks(t, []Task{{ID: 1, Title: "First"}})
+
+	first, err := store.ListJSON()
+	if err != nil {
+		t.Fatalf("Failed to marshal task list: %v", err)
+	}
+	second, _ := store.ListJSON()
+	if &first[0] != &second[0] {
+		t.Errorf("Expected repeated reads to share the cached encoding")
+	}
+
+	store.Update(1, func(task *Task) error { task.Completed = true; return nil })
+	if store.listJSON != nil {
+		t.Errorf("Expected a change to drop the cached encoding")
+	}
+}
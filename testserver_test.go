@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Client sends requests to a test server and fails the test on transport errors
+type Client struct {
+	t       testing.TB
+	baseURL string
+	http    *http.Client
+	// Key, when set, is sent as a bearer API key
+	Key string
+}
+
+// StartTestServer serves the full public router and middleware from an httptest.Server backed by its own
+// write-through store in a temp directory. Servers don't share tasks, so parallel tests can each start one.
+// Counters are still package-wide.
+func StartTestServer(t testing.TB) (*httptest.Server, *Client) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "tasks.json")
+	if err := os.WriteFile(filename, []byte("[]"), 0644); err != nil {
+		t.Fatalf("failed to create test store: %v", err)
+	}
+	store, err := openTaskStore(fileBackend{filename: filename}, true)
+	if err != nil {
+		t.Fatalf("failed to open test store: %v", err)
+	}
+	headers, _ := parseSecurityHeaders("")
+	server := httptest.NewServer(RequestID(SecurityHeaders(WithTaskStore(Authenticate(newPublicMux(2*time.Second)), store), headers)))
+	t.Cleanup(server.Close)
+	return server, &Client{t: t, baseURL: server.URL, http: server.Client()}
+}
+
+// Do sends a request with a JSON body, if any, and returns the status code and trimmed body
+func (c *Client) Do(method, path, body string) (int, string) {
+	c.t.Helper()
+	req, err := http.NewRequest(method, c.baseURL+path, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Key != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Key)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("%s %s: reading body: %v", method, path, err)
+	}
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+// Get sends a GET request
+func (c *Client) Get(path string) (int, string) {
+	c.t.Helper()
+	return c.Do(http.MethodGet, path, "")
+}
+
+// Post sends a POST request with a JSON body
+func (c *Client) Post(path, body string) (int, string) {
+	c.t.Helper()
+	return c.Do(http.MethodPost, path, body)
+}
+
+// Put sends a PUT request with a JSON body
+func (c *Client) Put(path, body string) (int, string) {
+	c.t.Helper()
+	return c.Do(http.MethodPut, path, body)
+}
+
+// Delete sends a DELETE request
+func (c *Client) Delete(path string) (int, string) {
+	c.t.Helper()
+	return c.Do(http.MethodDelete, path, "")
+}
+
+func TestTestServersAreIsolated(t *testing.T) {
+	t.Parallel()
+	_, first := StartTestServer(t)
+	_, second := StartTestServer(t)
+
+	if status, _ := first.Post("/tasks", `{"title": "Only in the first"}`); status != http.StatusCreated {
+		t.Fatalf("POST got status %d, want %d", status, http.StatusCreated)
+	}
+	if _, body := second.Get("/tasks"); body != "[]" {
+		t.Errorf("second server sees %s, want []", body)
+	}
+	// Requests go through the middleware, not just the handler
+	if status, _ := second.Do(http.MethodPost, "/tasks", ""); status != http.StatusUnsupportedMediaType {
+		t.Errorf("POST without Content-Type got status %d, want %d", status, http.StatusUnsupportedMediaType)
+	}
+}
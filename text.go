@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"golang.org/x/text/search"
+	"golang.org/x/text/unicode/norm"
+)
+
+// textLocale selects the collation rules for sorting and matching titles; set from TASKS_LOCALE
+var textLocale = language.English
+
+// normalizeText converts user-supplied text to Unicode NFC so visually identical strings compare equal
+func normalizeText(s string) string {
+	return norm.NFC.String(s)
+}
+
+// maxNotesLength bounds Task.Notes, in bytes after normalization
+const maxNotesLength = 64 * 1024
+
+// normalizeNotes normalizes task notes, rejecting notes longer than maxNotesLength
+func normalizeNotes(notes string) (string, error) {
+	notes = normalizeText(notes)
+	if len(notes) > maxNotesLength {
+		return "", fmt.Errorf("notes must be at most %d bytes", maxNotesLength)
+	}
+	return notes, nil
+}
+
+// sortTasksByTitle orders tasks by title using locale-aware, case-insensitive collation,
+// falling back to ID for titles that collate equal
+func sortTasksByTitle(list []Task) {
+	// Collators keep internal buffers, so each sort gets its own
+	collator := collate.New(textLocale, collate.IgnoreCase)
+	sort.SliceStable(list, func(i, j int) bool {
+		if c := collator.CompareString(list[i].Title, list[j].Title); c != 0 {
+			return c < 0
+		}
+		return list[i].ID < list[j].ID
+	})
+}
+
+// titleMatcher returns a predicate matching titles that contain query, ignoring case, diacritics, and width
+func titleMatcher(query string) func(string) bool {
+	matcher := search.New(textLocale, search.Loose)
+	pattern := matcher.CompileString(normalizeText(query))
+	return func(title string) bool {
+		start, _ := pattern.IndexString(title)
+		return start != -1
+	}
+}
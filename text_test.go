@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTitlesNormalizedToNFC(t *testing.T) {
+	useTasks(t, []Task{})
+
+	// "e" followed by a combining acute accent
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{"title": "Cafe\u0301 order"}`))
+	rec := httptest.NewRecorder()
+	Tasks(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if got := taskStore.List()[0].Title; got != "Caf\u00e9 order" {
+		t.Errorf("got title %q, want precomposed %q", got, "Caf\u00e9 order")
+	}
+}
+
+type listQueryTestCase struct {
+	name       string // Test case name
+	query      string // Raw query string for GET /tasks
+	wantStatus int    // Expected HTTP status code
+	wantIDs    string // Expected task IDs in order, or the error body
+}
+
+var listQueryTests = []listQueryTestCase{
+	{
+		name:       "Collated Sort",
+		query:      "sort=title",
+		wantStatus: http.StatusOK,
+		wantIDs:    "2,4,1,3",
+	},
+	{
+		name:       "Search Ignores Case And Accents",
+		query:      "q=" + url.QueryEscape("CAFE"),
+		wantStatus: http.StatusOK,
+		wantIDs:    "1,4",
+	},
+	{
+		name:       "Search And Sort",
+		query:      "q=cafe&sort=title",
+		wantStatus: http.StatusOK,
+		wantIDs:    "4,1",
+	},
+	{
+		name:       "Unknown Sort",
+		query:      "sort=color",
+		wantStatus: http.StatusBadRequest,
+		wantIDs:    `{"error":"Unknown sort \"color\", must be title or priority","code":"invalid_request"}`,
+	},
+}
+
+func TestListSearchAndSort(t *testing.T) {
+	useTasks(t, []Task{
+		{ID: 1, Title: "Café visit"},
+		{ID: 2, Title: "apples"},
+		{ID: 3, Title: "Zebra crossing"},
+		{ID: 4, Title: "cafe budget"},
+	})
+
+	for _, tt := range listQueryTests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks?fields=id&"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Test %s: got status %d, want %d", tt.name, rec.Code, tt.wantStatus)
+			}
+			gotBody := strings.TrimSpace(rec.Body.String())
+			if tt.wantStatus != http.StatusOK {
+				if gotBody != tt.wantIDs {
+					t.Errorf("Test %s: got body %s, want %s", tt.name, gotBody, tt.wantIDs)
+				}
+				return
+			}
+			// Reduce [{"id":2},{"id":4}] to "2,4"
+			gotIDs := strings.NewReplacer(`[`, ``, `]`, ``, `{"id":`, ``, `}`, ``).Replace(gotBody)
+			if gotIDs != tt.wantIDs {
+				t.Errorf("Test %s: got IDs %s, want %s", tt.name, gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIToken is a scoped credential a user issues for a script or integration. Its scope is one of the
+// roles, and it never grants more than its owner's role. Its secret is shown once, when the token is
+// created or rotated; only a hash is kept.
+type APIToken struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Owner     string     `json:"owner"`
+	Scope     string     `json:"scope"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// storedToken is an APIToken as persisted, with the hash of its secret
+type storedToken struct {
+	APIToken
+	Hash string `json:"token_sha256"`
+}
+
+// usable reports whether the token can still authenticate at now
+func (t APIToken) usable(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+var (
+	apiTokens  []storedToken
+	tokenMutex sync.Mutex
+	// tokensFile is where tokens are saved after every change; empty keeps them in memory only
+	tokensFile string
+)
+
+// LoadTokensFromFile reads saved tokens; a missing file means none have been issued
+func LoadTokensFromFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+	if err := json.Unmarshal(data, &apiTokens); err != nil {
+		return err
+	}
+	logInfo("API tokens loaded successfully from %s", filename)
+	return nil
+}
+
+// saveTokensLocked writes every token to tokensFile. Revocations must survive a restart, so it runs
+// on each change rather than at shutdown. The caller holds tokenMutex.
+func saveTokensLocked() error {
+	if tokensFile == "" {
+		return nil
+	}
+	data, err := marshalCanonical(apiTokens)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(tokensFile, 0600, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// findToken returns the usable token whose secret is key, comparing hashes in constant time
+func findToken(key string) (APIToken, bool) {
+	hash := []byte(hashAPIKey(key))
+	now := clock.Now()
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+	for _, t := range apiTokens {
+		if subtle.ConstantTimeCompare(hash, []byte(t.Hash)) == 1 && t.usable(now) {
+			return t.APIToken, true
+		}
+	}
+	return APIToken{}, false
+}
+
+// newTokenSecret returns a random secret and its hash
+func newTokenSecret() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	secret := "tt_" + base64.RawURLEncoding.EncodeToString(raw)
+	return secret, hashAPIKey(secret), nil
+}
+
+// tokenRequest is the body of POST /me/tokens
+type tokenRequest struct {
+	Name      string     `json:"name"`
+	Scope     string     `json:"scope"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// issuedToken is a token together with its secret, returned only when the secret is created
+type issuedToken struct {
+	APIToken
+	Token string `json:"token"`
+}
+
+// Tokens manages the authenticated user's API tokens. It must be reached with the user's own key: a
+// token can't issue, rotate, or revoke tokens, so a leaked one can't outlive its revocation.
+//
+//	GET    /me/tokens              list tokens, including revoked and expired ones
+//	POST   /me/tokens              issue a token ({"name", "scope", "expires_at"}); the response holds its secret
+//	DELETE /me/tokens/{id}         revoke a token
+//	POST   /me/tokens/{id}/rotate  replace a token's secret, invalidating the old one
+func Tokens(w http.ResponseWriter, r *http.Request) {
+	logInfoContext(r.Context(), "Received %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+	user := userFor(r)
+	if user == "" {
+		writeJsonError(w, http.StatusNotFound, "API tokens require USERS_FILE to be configured")
+		return
+	}
+	if viaToken(r) {
+		logErrorContext(r.Context(), "User %s tried to manage tokens with a token", user)
+		writeJsonError(w, http.StatusForbidden, "API tokens can only be managed with your own key")
+		return
+	}
+	parts := strings.Split(strings.Trim(path.Clean(r.URL.Path), "/"), "/")
+	switch {
+	case len(parts) == 2 && r.Method == "GET":
+		listTokens(w, user)
+	case len(parts) == 2 && r.Method == "POST":
+		createToken(w, r, user)
+	case len(parts) == 3 && r.Method == "DELETE":
+		revokeToken(w, user, parts[2])
+	case len(parts) == 4 && parts[3] == "rotate" && r.Method == "POST":
+		rotateToken(w, user, parts[2])
+	case len(parts) > 4 || (len(parts) == 4 && parts[3] != "rotate"):
+		writeJsonError(w, http.StatusNotFound, "Not Found")
+	default:
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+	}
+}
+
+func listTokens(w http.ResponseWriter, user string) {
+	tokenMutex.Lock()
+	mine := []APIToken{}
+	for _, t := range apiTokens {
+		if t.Owner == user {
+			mine = append(mine, t.APIToken)
+		}
+	}
+	tokenMutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mine)
+}
+
+func createToken(w http.ResponseWriter, r *http.Request, user string) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJsonError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	var req tokenRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJsonError(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+	req.Name = strings.TrimSpace(normalizeText(req.Name))
+	now := clock.Now()
+	switch {
+	case req.Name == "":
+		writeJsonError(w, http.StatusBadRequest, "Token name cannot be empty")
+		return
+	case roleRank(req.Scope) == 0:
+		writeJsonError(w, http.StatusBadRequest, "scope must be read-only, read-write, or admin")
+		return
+	case req.ExpiresAt != nil && !req.ExpiresAt.After(now):
+		writeJsonError(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	secret, hash, err := newTokenSecret()
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to generate token: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	// A token never grants more than its owner has; Authenticate also caps it in case the role is lowered later
+	token := APIToken{ID: hex.EncodeToString(id), Name: req.Name, Owner: user, Scope: lesserRole(req.Scope, roleFor(r)), CreatedAt: now}
+	if req.ExpiresAt != nil {
+		expires := req.ExpiresAt.UTC()
+		token.ExpiresAt = &expires
+	}
+
+	tokenMutex.Lock()
+	apiTokens = append(apiTokens, storedToken{APIToken: token, Hash: hash})
+	err = saveTokensLocked()
+	if err != nil {
+		apiTokens = apiTokens[:len(apiTokens)-1]
+	}
+	tokenMutex.Unlock()
+	if err != nil {
+		logErrorContext(r.Context(), "Failed to save tokens: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to save token")
+		return
+	}
+	logInfoContext(r.Context(), "User %s issued %s token %s", user, token.Scope, token.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issuedToken{APIToken: token, Token: secret})
+}
+
+// changeToken applies fn to the user's token with the given ID and saves, restoring the token if the save fails
+func changeToken(w http.ResponseWriter, user, id string, fn func(*storedToken) error) (APIToken, bool) {
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+	for i := range apiTokens {
+		if apiTokens[i].ID != id || apiTokens[i].Owner != user {
+			continue
+		}
+		original := apiTokens[i]
+		if err := fn(&apiTokens[i]); err != nil {
+			writeJsonError(w, http.StatusConflict, err.Error())
+			return APIToken{}, false
+		}
+		if err := saveTokensLocked(); err != nil {
+			apiTokens[i] = original
+			logError("Failed to save tokens: %v", err)
+			writeJsonError(w, http.StatusInternalServerError, "Failed to save token")
+			return APIToken{}, false
+		}
+		return apiTokens[i].APIToken, true
+	}
+	writeJsonError(w, http.StatusNotFound, fmt.Sprintf("No token found with ID %s", id))
+	return APIToken{}, false
+}
+
+func revokeToken(w http.ResponseWriter, user, id string) {
+	token, ok := changeToken(w, user, id, func(t *storedToken) error {
+		if t.RevokedAt == nil {
+			now := clock.Now()
+			t.RevokedAt = &now
+		}
+		return nil
+	})
+	if !ok {
+		return
+	}
+	logInfo("User %s revoked token %s", user, id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+func rotateToken(w http.ResponseWriter, user, id string) {
+	secret, hash, err := newTokenSecret()
+	if err != nil {
+		logError("Failed to generate token: %v", err)
+		writeJsonError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	token, ok := changeToken(w, user, id, func(t *storedToken) error {
+		now := clock.Now()
+		if !t.usable(now) {
+			return fmt.Errorf("Token %s is revoked or expired", id)
+		}
+		t.Hash, t.RotatedAt = hash, &now
+		return nil
+	})
+	if !ok {
+		return
+	}
+	logInfo("User %s rotated token %s", user, id)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(issuedToken{APIToken: token, Token: secret})
+}
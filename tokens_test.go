@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useTokens starts the test with no API tokens, saved to a temp file
+func useTokens(t *testing.T) string {
+	t.Helper()
+	originalTokens, originalFile := apiTokens, tokensFile
+	apiTokens, tokensFile = nil, filepath.Join(t.TempDir(), "tokens.json")
+	t.Cleanup(func() { apiTokens, tokensFile = originalTokens, originalFile })
+	return tokensFile
+}
+
+// issueToken creates a token as c and returns its ID and secret
+func issueToken(t *testing.T, c *Client, body string) (string, string) {
+	t.Helper()
+	status, resp := c.Post("/me/tokens", body)
+	if status != http.StatusCreated {
+		t.Fatalf("issue token: got %d %s", status, resp)
+	}
+	var issued issuedToken
+	if err := json.Unmarshal([]byte(resp), &issued); err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return issued.ID, issued.Token
+}
+
+func TestAPITokens(t *testing.T) {
+	clock := useFakeClock(t, testNow)
+	useUsers(t, "alice")
+	filename := useTokens(t)
+	server, alice := StartTestServer(t)
+	alice.Key = "alice-key"
+	as := func(key string) *Client { return &Client{t: t, baseURL: server.URL, http: server.Client(), Key: key} }
+
+	_, readKey := issueToken(t, alice, `{"name": "dashboard", "scope": "read-only"}`)
+	writeID, writeKey := issueToken(t, alice, `{"name": "ci", "scope": "read-write", "expires_at": "2024-05-02T12:00:00Z"}`)
+
+	steps := []struct {
+		name       string
+		client     *Client
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"Read-Only Reads", as(readKey), "GET", "/tasks", "", http.StatusOK},
+		{"Read-Only Can't Write", as(readKey), "POST", "/tasks", `{"title": "No"}`, http.StatusForbidden},
+		{"Read-Write Writes", as(writeKey), "POST", "/tasks", `{"title": "Yes"}`, http.StatusCreated},
+		{"Token Can't List Tokens", as(writeKey), "GET", "/me/tokens", "", http.StatusForbidden},
+		{"Token Can't Issue Tokens", as(writeKey), "POST", "/me/tokens", `{"name": "x", "scope": "read-only"}`, http.StatusForbidden},
+		{"Bad Scope", alice, "POST", "/me/tokens", `{"name": "x", "scope": "root"}`, http.StatusBadRequest},
+		{"Past Expiry", alice, "POST", "/me/tokens", `{"name": "x", "scope": "admin", "expires_at": "2024-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"Revoke Unknown", alice, "DELETE", "/me/tokens/nope", "", http.StatusNotFound},
+	}
+	for _, tt := range steps {
+		if status, body := tt.client.Do(tt.method, tt.path, tt.body); status != tt.wantStatus {
+			t.Errorf("%s: got %d %s, want %d", tt.name, status, body, tt.wantStatus)
+		}
+	}
+
+	// Rotation invalidates the old secret at once
+	status, body := alice.Post("/me/tokens/"+writeID+"/rotate", "")
+	var rotated issuedToken
+	if err := json.Unmarshal([]byte(body), &rotated); status != http.StatusOK || err != nil {
+		t.Fatalf("rotate: got %d %s", status, body)
+	}
+	if status, _ := as(writeKey).Get("/tasks"); status != http.StatusUnauthorized {
+		t.Errorf("old secret after rotation: got %d, want 401", status)
+	}
+	if status, _ := as(rotated.Token).Get("/tasks"); status != http.StatusOK {
+		t.Errorf("new secret after rotation: got %d, want 200", status)
+	}
+
+	// Tokens stop working once they expire
+	clock.Advance(25 * time.Hour)
+	if status, _ := as(rotated.Token).Get("/tasks"); status != http.StatusUnauthorized {
+		t.Errorf("expired token: got %d, want 401", status)
+	}
+
+	// A revocation is saved before it is acknowledged, and only hashes are saved
+	if status, body := alice.Delete("/me/tokens/" + writeID); status != http.StatusOK {
+		t.Fatalf("revoke: got %d %s", status, body)
+	}
+	apiTokens = nil
+	if err := LoadTokensFromFile(filename); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(apiTokens) != 2 || apiTokens[1].RevokedAt == nil || apiTokens[1].Hash != hashAPIKey(rotated.Token) {
+		t.Errorf("reloaded tokens %+v, want the revoked, rotated token", apiTokens)
+	}
+}
+
+func TestTokensRequireUsers(t *testing.T) {
+	t.Parallel()
+	_, client := StartTestServer(t)
+	status, body := client.Get("/me/tokens")
+	if want := `{"error":"API tokens require USERS_FILE to be configured","code":"not_found"}`; status != http.StatusNotFound || body != want {
+		t.Errorf("got %d %s, want 404 %s", status, body, want)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+// WithTx runs fn against a private copy of the list. If fn succeeds, the copy's changes are journalled
+// together and replace the list in one step, counting as a single change for saving and snapshot
+// tokens; if it fails or panics, the copy is dropped. Other requests wait until fn returns.
+func (s *memoryStore) WithTx(fn func(tx TaskStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := &memoryStore{
+		tasks:      s.snapshot(),
+		lastID:     s.lastID,
+		modified:   s.modified,
+		generation: s.generation,
+		inTx:       true,
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if len(tx.txEntries) == 0 {
+		return nil
+	}
+	if err := s.record(tx.txEntries...); err != nil {
+		return err
+	}
+	s.tasks, s.lastID = tx.tasks, tx.lastID
+	s.changed()
+	for _, event := range tx.txEvents {
+		s.afterCommit(event)
+	}
+	return nil
+}
+
+// afterCommit runs event, a side effect of a change such as counting a completion, once the change
+// is certain to stand: now, or when the transaction making it commits. Callers must hold s.mu.
+func (s *memoryStore) afterCommit(event func()) {
+	if s.inTx {
+		s.txEvents = append(s.txEvents, event)
+		return
+	}
+	event()
+}
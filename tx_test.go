@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithTx(t *testing.T) {
+	tests := []struct {
+		name       string
+		fail       error
+		wantTitles []string
+	}{
+		{"Commit", nil, []string{"Renamed", "Three"}},
+		{"Rollback", errors.New("rejected"), []string{"One", "Two"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := openJournaledStore(t, dir)
+			store.Create(Task{Title: "One"})
+			store.Create(Task{Title: "Two"})
+			generation, created := store.Generation(), taskEvents.stats().Created
+
+			err := store.WithTx(func(tx TaskStore) error {
+				tx.Update(1, func(task *Task) error { task.Title = "Renamed"; return nil })
+				tx.Delete(2)
+				tx.Create(Task{Title: "Three"})
+				if got := len(tx.List()); got != 2 {
+					t.Errorf("tx sees %d tasks, want its own changes", got)
+				}
+				return tt.fail
+			})
+			if err != tt.fail {
+				t.Fatalf("WithTx = %v, want %v", err, tt.fail)
+			}
+
+			wantGeneration, wantCreated := generation, created
+			if tt.fail == nil {
+				wantGeneration, wantCreated = generation+1, created+1
+			}
+			if got := store.Generation(); got != wantGeneration {
+				t.Errorf("generation = %d, want %d", got, wantGeneration)
+			}
+			if got := taskEvents.stats().Created; got != wantCreated {
+				t.Errorf("created events = %d, want %d", got, wantCreated)
+			}
+			if got := taskTitles(store.List()); !reflect.DeepEqual(got, tt.wantTitles) {
+				t.Errorf("titles = %v, want %v", got, tt.wantTitles)
+			}
+
+			// The journal holds exactly the committed changes
+			store.Close()
+			reopened := openJournaledStore(t, dir)
+			defer reopened.Close()
+			if got := taskTitles(reopened.List()); !reflect.DeepEqual(got, tt.wantTitles) {
+				t.Errorf("titles after replay = %v, want %v", got, tt.wantTitles)
+			}
+		})
+	}
+}
+
+func TestOwnedStoreWithTx(t *testing.T) {
+	store := newMemoryStore([]Task{{ID: 1, Title: "Mine", Owner: "alice"}, {ID: 2, Title: "Theirs", Owner: "bob"}})
+	err := ownedStore{TaskStore: store, owner: "alice"}.WithTx(func(tx TaskStore) error {
+		if got := taskTitles(tx.List()); !reflect.DeepEqual(got, []string{"Mine"}) {
+			t.Errorf("tx lists %v, want only the owner's tasks", got)
+		}
+		if err := tx.Delete(2); !errors.Is(err, ErrTaskNotFound) {
+			t.Errorf("Delete of another user's task = %v, want ErrTaskNotFound", err)
+		}
+		_, err := tx.Create(Task{Title: "New"})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.Get(3); got.Owner != "alice" {
+		t.Errorf("created task owner = %q, want alice", got.Owner)
+	}
+}
+
+// failingSubtaskStore fails updates to one task, as a broken backend might partway through
+type failingSubtaskStore struct {
+	TaskStore
+	failID int
+}
+
+func (s failingSubtaskStore) Update(id int, fn func(*Task) error) (Task, error) {
+	if id == s.failID {
+		return Task{}, errUnavailable
+	}
+	return s.TaskStore.Update(id, fn)
+}
+
+func (s failingSubtaskStore) WithTx(fn func(TaskStore) error) error {
+	return s.TaskStore.WithTx(func(tx TaskStore) error { return fn(failingSubtaskStore{tx, s.failID}) })
+}
+
+func TestCascadeRollsBack(t *testing.T) {
+	store := newMemoryStore([]Task{{ID: 1, Title: "Parent"}, {ID: 2, Title: "Child", ParentID: 1}, {ID: 3, Title: "Grandchild", ParentID: 2}})
+	original := taskStore
+	taskStore = failingSubtaskStore{store, 3}
+	t.Cleanup(func() { taskStore = original })
+
+	rec := httptest.NewRecorder()
+	Tasks(rec, httptest.NewRequest("PUT", "/tasks/1?subtasks=cascade", strings.NewReader(`{"title": "Parent", "completed": true}`)))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d %s, want 500", rec.Code, rec.Body)
+	}
+	for _, task := range store.List() {
+		if task.Completed {
+			t.Errorf("task %d completed by a failed cascade", task.ID)
+		}
+	}
+}
+
+func taskTitles(tasks []Task) []string {
+	titles := make([]string, len(tasks))
+	for i, task := range tasks {
+		titles[i] = task.Title
+	}
+	return titles
+}
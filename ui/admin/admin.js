@@ -0,0 +1,138 @@
+"use strict";
+
+// The key lives for the browser tab only, and is sent to the admin endpoints that need it
+const keyStorage = "task-tracker-admin-key";
+
+function authHeaders() {
+  const key = sessionStorage.getItem(keyStorage);
+  return key ? { Authorization: "Bearer " + key } : {};
+}
+
+async function call(method, path) {
+  const response = await fetch(path, { method, headers: authHeaders() });
+  const body = await response.json().catch(() => ({}));
+  if (!response.ok && response.status !== 503) {
+    throw new Error(body.error || response.status + " " + response.statusText);
+  }
+  return body;
+}
+
+function cell(row, text, className) {
+  const td = row.insertCell();
+  td.textContent = text;
+  if (className) {
+    td.className = "badge " + className;
+  }
+}
+
+function fillTable(id, rows) {
+  const body = document.querySelector("#" + id + " tbody");
+  body.replaceChildren();
+  for (const values of rows) {
+    const row = body.insertRow();
+    values.forEach((value) => cell(row, value));
+  }
+}
+
+function showError(message) {
+  const result = document.getElementById("result");
+  result.textContent = message;
+  result.className = "error";
+}
+
+async function loadHealth() {
+  const report = await call("GET", "/healthz?verbose=true");
+  const status = document.getElementById("health-status");
+  status.textContent = report.status;
+  status.className = "badge " + report.status;
+  const body = document.querySelector("#health tbody");
+  body.replaceChildren();
+  for (const [name, component] of Object.entries(report.components || {})) {
+    const row = body.insertRow();
+    cell(row, name);
+    cell(row, component.status, component.status);
+    const { status: _, ...details } = component;
+    cell(row, Object.entries(details).map(([k, v]) => k + ": " + v).join(", "));
+  }
+}
+
+async function loadSummary() {
+  const summary = await call("GET", "/admin/summary");
+  const list = document.getElementById("summary");
+  list.replaceChildren();
+  const figures = {
+    "Tasks": summary.tasks.total,
+    "Completed": summary.tasks.completed,
+    "Overdue": summary.tasks.overdue,
+    "Created since start": summary.created_total,
+    "Completed since start": summary.completed_total,
+    "Storage degraded": summary.storage.degraded ? "yes" : "no",
+    "Storage retries": summary.storage.retries,
+    "Requests in flight": summary.requests.in_flight,
+    "Requests shed": summary.requests.shed,
+  };
+  for (const [label, value] of Object.entries(figures)) {
+    const dt = document.createElement("dt");
+    dt.textContent = label;
+    const dd = document.createElement("dd");
+    dd.textContent = value;
+    list.append(dt, dd);
+  }
+  const operations = Object.entries(summary.storage_operations).sort(([a], [b]) => a.localeCompare(b));
+  fillTable("operations", operations.map(([name, op]) => [name, op.count, (op.mean_seconds * 1000).toFixed(2) + " ms", op.slow]));
+}
+
+async function loadBackups() {
+  const backups = await call("GET", "/admin/backups");
+  fillTable("backups", backups.map((b) => [b.name, b.size + " bytes", new Date(b.modified).toLocaleString()]));
+}
+
+async function refresh() {
+  const results = await Promise.allSettled([loadHealth(), loadSummary(), loadBackups()]);
+  const failed = results.find((r) => r.status === "rejected");
+  if (failed) {
+    showError(failed.reason.message);
+  }
+}
+
+const actions = {
+  save: () => call("POST", "/admin/save"),
+  reload: () => {
+    if (!confirm("Replace the tasks in memory with the saved ones?")) {
+      return null;
+    }
+    return call("POST", "/admin/reload");
+  },
+  verify: () => call("GET", "/admin/backup/verify"),
+  check: () => call("GET", "/admin/check"),
+  repair: () => call("POST", "/admin/check"),
+};
+
+document.addEventListener("click", async (event) => {
+  const action = actions[event.target.dataset.action];
+  if (!action) {
+    return;
+  }
+  try {
+    const response = await action();
+    if (response) {
+      const result = document.getElementById("result");
+      result.textContent = JSON.stringify(response, null, 2);
+      result.className = "";
+    }
+    refresh();
+  } catch (err) {
+    showError(err.message);
+  }
+});
+
+document.getElementById("key-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const input = document.getElementById("api-key");
+  sessionStorage.setItem(keyStorage, input.value);
+  input.value = "";
+  refresh();
+});
+
+refresh();
+setInterval(refresh, 15000);
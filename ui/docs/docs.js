@@ -0,0 +1,187 @@
+"use strict";
+
+const methods = ["get", "post", "put", "delete"];
+
+function element(tag, text, className) {
+  const el = document.createElement(tag);
+  if (text !== undefined) {
+    el.textContent = text;
+  }
+  if (className) {
+    el.className = className;
+  }
+  return el;
+}
+
+// resolve follows a local "#/components/..." reference
+function resolve(spec, value) {
+  if (!value || !value.$ref) {
+    return value;
+  }
+  return value.$ref.slice(2).split("/").reduce((node, key) => node[key], spec);
+}
+
+function schemaName(ref) {
+  return ref.split("/").pop();
+}
+
+// describe renders a schema as a short type expression, linking named schemas to their tables
+function describe(schema) {
+  const span = element("span");
+  if (!schema) {
+    return span;
+  }
+  if (schema.$ref) {
+    const link = element("a", schemaName(schema.$ref));
+    link.href = "#schema-" + schemaName(schema.$ref);
+    span.append(link);
+    return span;
+  }
+  const alternatives = schema.oneOf || schema.allOf;
+  if (alternatives) {
+    alternatives.forEach((alternative, i) => {
+      if (i > 0) {
+        span.append(schema.oneOf ? " or " : " and ");
+      }
+      span.append(describe(alternative));
+    });
+    return span;
+  }
+  if (schema.type === "array") {
+    span.append("array of ", describe(schema.items));
+    return span;
+  }
+  if (schema.type === "object" && schema.properties) {
+    span.append("{ ");
+    Object.entries(schema.properties).forEach(([name, property], i) => {
+      span.append(i > 0 ? ", " : "", element("code", name), ": ", describe(property));
+    });
+    span.append(" }");
+    return span;
+  }
+  let text = schema.type || "any";
+  if (schema.format) {
+    text += " (" + schema.format + ")";
+  }
+  if (schema.enum) {
+    text += ": " + schema.enum.join(" | ");
+  }
+  span.append(text);
+  return span;
+}
+
+function table(headings, rows) {
+  const result = element("table");
+  const head = result.createTHead().insertRow();
+  headings.forEach((heading) => head.append(element("th", heading)));
+  const body = result.createTBody();
+  for (const cells of rows) {
+    const row = body.insertRow();
+    cells.forEach((value) => row.insertCell().append(value));
+  }
+  return result;
+}
+
+function renderOperation(spec, path, pathItem, method) {
+  const op = pathItem[method];
+  const details = element("details");
+  const summary = element("summary");
+  summary.append(element("span", method, "method " + method), " ", element("code", path), " ", op.summary || "");
+  const body = element("div");
+  if (op.description) {
+    body.append(element("p", op.description));
+  }
+  if (op.security && op.security.length === 0) {
+    body.append(element("p", "No API key needed."));
+  }
+  const parameters = (pathItem.parameters || []).concat(op.parameters || []).map((p) => resolve(spec, p));
+  if (parameters.length > 0) {
+    body.append(element("h4", "Parameters"));
+    body.append(table(["Name", "In", "Type", "Description"], parameters.map((p) => [
+      element("code", p.name + (p.required ? "" : "?")), p.in, describe(p.schema), p.description || "",
+    ])));
+  }
+  if (op.requestBody) {
+    body.append(element("h4", "Request body"));
+    body.append(table(["Content type", "Schema"], Object.entries(op.requestBody.content).map(([type, media]) => [
+      type, describe(media.schema),
+    ])));
+  }
+  body.append(element("h4", "Responses"));
+  body.append(table(["Status", "Description", "Schema"], Object.entries(op.responses).map(([status, response]) => {
+    response = resolve(spec, response);
+    const schemas = element("span");
+    Object.entries(response.content || {}).forEach(([type, media], i) => {
+      schemas.append(i > 0 ? "; " : "", type + ": ", describe(media.schema));
+    });
+    return [status, response.description, schemas];
+  })));
+  details.append(summary, body);
+  return details;
+}
+
+function renderSchema(name, schema) {
+  const section = element("details");
+  section.id = "schema-" + name;
+  section.append(element("summary", name));
+  const body = element("div");
+  if (schema.properties) {
+    const required = new Set(schema.required || []);
+    body.append(table(["Property", "Type", "Description"], Object.entries(schema.properties).map(([property, value]) => [
+      element("code", property + (required.has(property) ? "" : "?")),
+      describe(value),
+      [value.description, value.readOnly ? "Set by the server." : "", value.nullable ? "null clears it." : ""].filter(Boolean).join(" "),
+    ])));
+  } else {
+    body.append(describe(schema));
+  }
+  section.append(body);
+  return section;
+}
+
+function render(spec) {
+  document.getElementById("title").textContent = spec.info.title + " v" + spec.info.version;
+  document.getElementById("description").textContent = spec.info.description || "";
+  if (spec.servers && spec.servers.length > 0) {
+    document.getElementById("server").textContent = "Server: " + spec.servers[0].url;
+  }
+  const byTag = new Map((spec.tags || []).map((tag) => [tag.name, []]));
+  for (const [path, pathItem] of Object.entries(spec.paths)) {
+    for (const method of methods.filter((m) => pathItem[m])) {
+      const tag = (pathItem[method].tags || ["other"])[0];
+      if (!byTag.has(tag)) {
+        byTag.set(tag, []);
+      }
+      byTag.get(tag).push(renderOperation(spec, path, pathItem, method));
+    }
+  }
+  const toc = document.getElementById("toc");
+  const operations = document.getElementById("operations");
+  for (const [tag, rendered] of byTag) {
+    if (rendered.length === 0) {
+      continue;
+    }
+    const heading = element("h2", tag);
+    heading.id = "tag-" + tag;
+    const link = element("a", tag);
+    link.href = "#" + heading.id;
+    toc.append(link);
+    operations.append(heading, ...rendered);
+  }
+  const schemas = document.getElementById("schemas");
+  for (const [name, schema] of Object.entries(spec.components.schemas)) {
+    schemas.append(renderSchema(name, schema));
+  }
+}
+
+fetch("/openapi.json")
+  .then((response) => {
+    if (!response.ok) {
+      throw new Error(response.status + " " + response.statusText);
+    }
+    return response.json();
+  })
+  .then(render)
+  .catch((err) => {
+    document.getElementById("operations").append(element("p", "Failed to load the API description: " + err.message, "error"));
+  });
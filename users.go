@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// User is an account allowed to call the API. Each user sees and changes only the tasks they own.
+type User struct {
+	Name string `json:"name"`
+	// KeySHA256 is the hex SHA-256 of the user's API key; the key itself is never stored
+	KeySHA256 string `json:"key_sha256"`
+	// Role limits what the user can do: read-only, read-write, or admin (the default)
+	Role string `json:"role,omitempty"`
+	// Identities are other names an authenticating proxy may send for the user, such as an email address
+	Identities []string `json:"identities,omitempty"`
+}
+
+// users is loaded from USERS_FILE; while it is empty the API is open and tasks have no owner
+var users []User
+
+// userNamePattern keeps names safe to use in URLs, logs, and @mentions
+var userNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// loadUsers reads a JSON array of users, rejecting bad names, duplicate names, and malformed key hashes
+func loadUsers(filename string) ([]User, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var loaded []User
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	seen := map[string]bool{}
+	for i, u := range loaded {
+		if !userNamePattern.MatchString(u.Name) {
+			return nil, fmt.Errorf("user %d: name %q must be lowercase letters, digits, '.', '_', or '-'", i, u.Name)
+		}
+		if seen[u.Name] {
+			return nil, fmt.Errorf("user %d: duplicate name %q", i, u.Name)
+		}
+		seen[u.Name] = true
+		// A user without a key can only sign in through an authenticating proxy
+		if key, err := hex.DecodeString(u.KeySHA256); u.KeySHA256 != "" && (err != nil || len(key) != sha256.Size) {
+			return nil, fmt.Errorf("user %q: key_sha256 must be 64 hex digits", u.Name)
+		}
+		loaded[i].KeySHA256 = strings.ToLower(u.KeySHA256)
+		if u.Role == "" {
+			loaded[i].Role = roleAdmin
+		} else if roleRank(u.Role) == 0 {
+			return nil, fmt.Errorf("user %q: role must be read-only, read-write, or admin", u.Name)
+		}
+	}
+	return loaded, nil
+}
+
+// hashAPIKey returns the form of key stored in the users file
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// findUserByKey returns the user whose key hashes to key's hash, comparing in constant time. Users
+// without a key never match, since no hash is empty.
+func findUserByKey(key string) (User, bool) {
+	hash := []byte(hashAPIKey(key))
+	for _, u := range users {
+		if subtle.ConstantTimeCompare(hash, []byte(u.KeySHA256)) == 1 {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// userRole returns the named user's role, or "" if there is no such user
+func userRole(name string) string {
+	for _, u := range users {
+		if u.Name == name {
+			return u.Role
+		}
+	}
+	return ""
+}
+
+// userKey is the request context key for the authenticated user's name
+type userKey struct{}
+
+// userFor returns the name of the user r was authenticated as, or "" when authentication is off
+func userFor(r *http.Request) string {
+	name, _ := r.Context().Value(userKey{}).(string)
+	return name
+}
+
+// withUser returns r acting as the named user, with its task store narrowed to that user's tasks
+func withUser(r *http.Request, name string) *http.Request {
+	ctx := context.WithValue(r.Context(), userKey{}, name)
+	ctx = context.WithValue(ctx, taskStoreKey{}, ownedStore{TaskStore: storeFor(r), owner: name})
+	return r.WithContext(ctx)
+}
+
+// isPublicPath reports whether path is served without an API key
+func isPublicPath(path string) bool {
+	return path == "/tasks/health" || path == "/openapi.json" || path == "/docs" ||
+		strings.HasPrefix(path, "/.well-known/") || strings.HasPrefix(path, "/docs/")
+}
+
+// Authenticate requires an "Authorization: Bearer <key>" header holding a configured user's key or one
+// of their API tokens, scopes the request to that user's tasks, and enforces its route's access policy. It does nothing while no users are configured. Health checks,
+// the discovery document, and the API description stay public so load balancers and clients can reach them before signing in.
+// Requests from an authenticating proxy are identified by its identity header instead of a key.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(users) == 0 || isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if fromAuthProxy(r) {
+			var ok bool
+			if r, ok = authenticateProxied(w, r); ok && authorize(w, r) {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || key == "" {
+			logErrorContext(r.Context(), "Unauthenticated %s request for %s from %s", r.Method, r.URL.Path, clientIP(r))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="task-tracker"`)
+			writeJsonError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		var name, role string
+		var viaToken bool
+		if user, ok := findUserByKey(key); ok {
+			name, role = user.Name, user.Role
+		} else if token, ok := findToken(key); ok {
+			// A token never outranks its owner, even if the owner's role was lowered after it was issued
+			name, role, viaToken = token.Owner, lesserRole(token.Scope, userRole(token.Owner)), true
+		} else {
+			logErrorContext(r.Context(), "Invalid API key for %s %s from %s", r.Method, r.URL.Path, clientIP(r))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="task-tracker", error="invalid_token"`)
+			writeJsonError(w, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+		r = withRole(withUser(r, name), role)
+		if viaToken {
+			r = r.WithContext(context.WithValue(r.Context(), viaTokenKey{}, true))
+		}
+		if !authorize(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ownedStore is the view of a TaskStore one user gets: other users' tasks don't exist in it
+type ownedStore struct {
+	TaskStore
+	owner string
+}
+
+func (s ownedStore) List() []Task {
+	var mine []Task
+	for _, t := range s.TaskStore.List() {
+		if t.Owner == s.owner {
+			mine = append(mine, t)
+		}
+	}
+	if mine == nil {
+		mine = []Task{}
+	}
+	return mine
+}
+
+func (s ownedStore) Get(id int) (Task, error) {
+	task, err := s.TaskStore.Get(id)
+	if err == nil && task.Owner != s.owner {
+		return Task{}, ErrTaskNotFound
+	}
+	return task, err
+}
+
+func (s ownedStore) Create(task Task) (Task, error) {
+	task.Owner = s.owner
+	return s.TaskStore.Create(task)
+}
+
+func (s ownedStore) Update(id int, fn func(*Task) error) (Task, error) {
+	return s.TaskStore.Update(id, func(t *Task) error {
+		if t.Owner != s.owner {
+			return ErrTaskNotFound
+		}
+		return fn(t)
+	})
+}
+
+func (s ownedStore) DeleteWhere(generation uint64, match func(Task) bool) ([]Task, error) {
+	return s.TaskStore.DeleteWhere(generation, func(t Task) bool { return t.Owner == s.owner && match(t) })
+}
+
+func (s ownedStore) Insert(tasks []Task, onConflict string) (InsertResult, error) {
+	owned := make([]Task, len(tasks))
+	for i, t := range tasks {
+		t.Owner = s.owner
+		owned[i] = t
+	}
+	return s.TaskStore.Insert(owned, onConflict)
+}
+
+func (s ownedStore) WithTx(fn func(tx TaskStore) error) error {
+	return s.TaskStore.WithTx(func(tx TaskStore) error {
+		return fn(ownedStore{TaskStore: tx, owner: s.owner})
+	})
+}
+
+func (s ownedStore) Delete(id int) error {
+	// Owners never change, so a task seen as ours here is still ours when it is deleted
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return s.TaskStore.Delete(id)
+}
+
+// Reorder rearranges the user's tasks among the list positions they already hold; ids must
+// name every task the user owns. Other users' tasks keep their places.
+func (s ownedStore) Reorder(ids []int) ([]Task, error) {
+	all := s.TaskStore.List()
+	reordered, err := orderTasks(s.List(), ids)
+	if err != nil {
+		return nil, err
+	}
+	fullOrder := make([]int, len(all))
+	next := 0
+	for i, t := range all {
+		if t.Owner == s.owner {
+			t = reordered[next]
+			next++
+		}
+		fullOrder[i] = t.ID
+	}
+	if _, err := s.TaskStore.Reorder(fullOrder); err != nil {
+		return nil, errors.New("Tasks changed while reordering, please retry")
+	}
+	return s.List(), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useUsers configures admin users whose API keys are their names followed by "-key"
+func useUsers(t *testing.T, names ...string) {
+	t.Helper()
+	original := users
+	users = nil
+	for _, name := range names {
+		users = append(users, User{Name: name, KeySHA256: hashAPIKey(name + "-key"), Role: roleAdmin})
+	}
+	t.Cleanup(func() { users = original })
+}
+
+func TestUserScoping(t *testing.T) {
+	useFakeClock(t, testNow)
+	useUsers(t, "alice", "bob")
+	server, alice := StartTestServer(t)
+	alice.Key = "alice-key"
+	bob := &Client{t: t, baseURL: server.URL, http: server.Client(), Key: "bob-key"}
+	anonymous := &Client{t: t, baseURL: server.URL, http: server.Client()}
+
+	steps := []struct {
+		name       string
+		client     *Client
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"No Key", anonymous, "GET", "/tasks", "", http.StatusUnauthorized, `{"error":"Authentication required","code":"unauthorized"}`},
+		{"Wrong Key", &Client{t: t, baseURL: server.URL, http: server.Client(), Key: "guess"}, "GET", "/tasks", "", http.StatusUnauthorized, `{"error":"Invalid API key","code":"unauthorized"}`},
+		{"Health Is Public", anonymous, "GET", "/tasks/health", "", http.StatusOK, `OK`},
+		{"Alice Creates", alice, "POST", "/tasks", `{"title": "Alice's task", "owner": "bob"}`, http.StatusCreated,
+			`{"id":1,"title":"Alice's task","completed":false,"owner":"alice","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Bob Creates", bob, "POST", "/tasks", `{"title": "Bob's task"}`, http.StatusCreated,
+			`{"id":2,"title":"Bob's task","completed":false,"owner":"bob","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}`},
+		{"Alice Lists Her Tasks", alice, "GET", "/tasks", "", http.StatusOK,
+			`[{"id":1,"title":"Alice's task","completed":false,"owner":"alice","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}]`},
+		{"Bob Lists His Tasks", bob, "GET", "/tasks", "", http.StatusOK,
+			`[{"id":2,"title":"Bob's task","completed":false,"owner":"bob","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}]`},
+		{"Bob Can't Update Alice's Task", bob, "PUT", "/tasks/1", `{"title": "Mine now"}`, http.StatusNotFound, `{"error":"No task found with ID 1","code":"task_not_found"}`},
+		{"Bob Can't Delete Alice's Task", bob, "DELETE", "/tasks/1", "", http.StatusNotFound, `{"error":"No task found with ID 1","code":"task_not_found"}`},
+		{"Bob Can't Parent To Alice's Task", bob, "PUT", "/tasks/2", `{"title": "Bob's task", "parent_id": 1}`, http.StatusBadRequest, `{"error":"parent_id 1 does not exist","code":"validation_failed"}`},
+		{"Bob Reorders Only His Tasks", bob, "PUT", "/tasks/order", `{"ids": [2]}`, http.StatusOK,
+			`[{"id":2,"title":"Bob's task","completed":false,"owner":"bob","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}]`},
+		{"Alice Still Has Her Task", alice, "GET", "/tasks", "", http.StatusOK,
+			`[{"id":1,"title":"Alice's task","completed":false,"owner":"alice","created_at":"2024-05-01T12:00:00Z","updated_at":"2024-05-01T12:00:00Z","version":1}]`},
+	}
+	for _, tt := range steps {
+		status, body := tt.client.Do(tt.method, tt.path, tt.body)
+		if status != tt.wantStatus || body != tt.wantBody {
+			t.Errorf("%s: got %d %s, want %d %s", tt.name, status, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestLoadUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"Valid", `[{"name": "alice", "key_sha256": "` + hashAPIKey("k") + `"}]`, ""},
+		{"Bad Name", `[{"name": "Alice Smith", "key_sha256": "` + hashAPIKey("k") + `"}]`, `user 0: name "Alice Smith" must be`},
+		{"Duplicate", `[{"name": "a", "key_sha256": "` + hashAPIKey("k") + `"}, {"name": "a", "key_sha256": "` + hashAPIKey("j") + `"}]`, `user 1: duplicate name "a"`},
+		{"Bad Role", `[{"name": "alice", "key_sha256": "` + hashAPIKey("k") + `", "role": "root"}]`, `user "alice": role must be read-only, read-write, or admin`},
+		{"Plain Key", `[{"name": "alice", "key_sha256": "secret"}]`, `user "alice": key_sha256 must be 64 hex digits`},
+		{"Proxy Only", `[{"name": "alice", "identities": ["alice@example.com"]}]`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "users.json")
+			os.WriteFile(filename, []byte(tt.content), 0644)
+			_, err := loadUsers(filename)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
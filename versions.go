@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// requireTaskVersion makes PUT /tasks/{id} refuse updates that don't say which version they replace
+var requireTaskVersion bool
+
+// versionConflict rejects an update based on a version of the task that is no longer current
+type versionConflict struct {
+	id, current, expected int
+}
+
+func (e *versionConflict) Error() string {
+	return fmt.Sprintf("Task %d is at version %d, not %d; reload it and retry", e.id, e.current, e.expected)
+}
+
+// contentChanged reports whether an update changed a task beyond its edit lock. Locks are advisory and
+// come and go while someone edits, so taking, releasing, or losing one leaves the version alone and
+// doesn't invalidate the If-Match of the client doing the editing.
+func contentChanged(before, after Task) bool {
+	before.Lock, after.Lock = nil, nil
+	before.Version, after.Version = 0, 0
+	return !reflect.DeepEqual(before, after)
+}
+
+// versionETag is the ETag naming a task version, as PUT and POST /tasks send it
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// expectedVersion reads the version an update replaces from If-Match or the body's version field,
+// which must agree when both are sent. stated reports whether the request gave either; If-Match: *
+// states that any version will do, leaving expected nil.
+func expectedVersion(r *http.Request, body []byte) (expected *int, stated bool, err error) {
+	if raw := strings.TrimSpace(r.Header.Get("If-Match")); raw != "" {
+		stated = true
+		if raw != "*" {
+			version, err := strconv.Atoi(strings.Trim(raw, `"`))
+			if err != nil || version < 0 {
+				return nil, false, errors.New(`If-Match must be a single task version, such as "3", or *`)
+			}
+			expected = &version
+		}
+	}
+	if hasJSONField(body, "version") {
+		var fields struct {
+			Version *int `json:"version"`
+		}
+		if err := json.Unmarshal(body, &fields); err != nil || fields.Version == nil {
+			return nil, false, errors.New("version must be the task version the update replaces")
+		}
+		if expected != nil && *expected != *fields.Version {
+			return nil, false, fmt.Errorf("If-Match names version %d but the body has version %d", *expected, *fields.Version)
+		}
+		if !stated {
+			expected, stated = fields.Version, true
+		}
+	}
+	return expected, stated, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUpdateTaskVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifMatch     string
+		payload     string
+		require     bool
+		wantStatus  int
+		wantCode    string
+		wantETag    string
+		wantVersion int // of the stored task afterwards
+	}{
+		{"No Version", "", `{"title": "Edited"}`, false, http.StatusOK, "", `"4"`, 4},
+		{"If-Match Current", `"3"`, `{"title": "Edited"}`, false, http.StatusOK, "", `"4"`, 4},
+		{"If-Match Unquoted", "3", `{"title": "Edited"}`, false, http.StatusOK, "", `"4"`, 4},
+		{"If-Match Stale", `"2"`, `{"title": "Edited"}`, false, http.StatusConflict, codeVersionConflict, `"3"`, 3},
+		{"If-Match Any", "*", `{"title": "Edited"}`, true, http.StatusOK, "", `"4"`, 4},
+		{"Body Current", "", `{"title": "Edited", "version": 3}`, false, http.StatusOK, "", `"4"`, 4},
+		{"Body Stale", "", `{"title": "Edited", "version": 1}`, false, http.StatusConflict, codeVersionConflict, `"3"`, 3},
+		{"Disagreeing", `"3"`, `{"title": "Edited", "version": 2}`, false, http.StatusBadRequest, codeInvalidRequest, "", 3},
+		{"Invalid If-Match", `W/"3"`, `{"title": "Edited"}`, false, http.StatusBadRequest, codeInvalidRequest, "", 3},
+		{"Required", "", `{"title": "Edited"}`, true, http.StatusPreconditionRequired, codePreconditionRequired, "", 3},
+		{"Required And Sent", `"3"`, `{"title": "Edited"}`, true, http.StatusOK, "", `"4"`, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := useTasks(t, []Task{{ID: 1, Title: "Draft", Version: 3}})
+			original := requireTaskVersion
+			requireTaskVersion = tt.require
+			defer func() { requireTaskVersion = original }()
+
+			req := httptest.NewRequest("PUT", "/tasks/1", strings.NewReader(tt.payload))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			Tasks(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if tt.wantCode != "" {
+				var body errorResponse
+				json.Unmarshal(rec.Body.Bytes(), &body)
+				if body.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+				}
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if got, _ := store.Get(1); got.Version != tt.wantVersion {
+				t.Errorf("stored version = %d, want %d", got.Version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestStoreVersions(t *testing.T) {
+	store := newMemoryStore(nil)
+	created, _ := store.Create(Task{Title: "New", Version: 7})
+	if created.Version != 1 {
+		t.Errorf("created version = %d, want 1", created.Version)
+	}
+	// The store owns the version, whatever the update function sets
+	updated, _ := store.Update(created.ID, func(task *Task) error { task.Title, task.Version = "Renamed", 42; return nil })
+	if updated.Version != 2 {
+		t.Errorf("updated version = %d, want 2", updated.Version)
+	}
+	// Taking and releasing an edit lock doesn't change the task's content, so it keeps its version
+	for _, lock := range []*TaskLock{{Owner: "Alice", ExpiresAt: testNow.Add(time.Minute)}, nil} {
+		locked, _ := store.Update(created.ID, func(task *Task) error { task.Lock = lock; return nil })
+		if locked.Version != 2 {
+			t.Errorf("version after lock change = %d, want 2", locked.Version)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// apiVersion is bumped on incompatible API changes
+const apiVersion = "1"
+
+// DiscoveryDocument is served at /.well-known/tasktracker so clients can configure themselves from a base URL
+type DiscoveryDocument struct {
+	Service      string            `json:"service"`
+	APIVersion   string            `json:"api_version"`
+	AuthModes    []string          `json:"auth_modes"`
+	Capabilities []string          `json:"capabilities"`
+	Endpoints    map[string]string `json:"endpoints"`
+}
+
+// capabilities lists the optional features this server supports, for clients to feature-detect
+var capabilities = []string{
+	"boards",
+	"checklists",
+	"counters",
+	"due_dates",
+	"export",
+	"feeds",
+	"field_projection",
+	"imports",
+	"links",
+	"openapi",
+	"locks",
+	"pagination",
+	"preferences",
+	"priority",
+	"start_dates",
+	"subtasks",
+	"tags",
+}
+
+// WellKnown serves the discovery document with absolute endpoint URLs for the host the client used
+func WellKnown(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		logErrorContext(r.Context(), "Unsupported method %s for %s", r.Method, r.URL.Path)
+		writeJsonError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+	base := requestBaseURL(r)
+	features := capabilities
+	if len(validationRules) > 0 {
+		features = append(append([]string(nil), capabilities...), "validation_rules")
+	}
+	doc := DiscoveryDocument{
+		Service:      "task-tracker",
+		APIVersion:   apiVersion,
+		AuthModes:    authModes(),
+		Capabilities: features,
+		Endpoints: map[string]string{
+			"tasks":       base + "/tasks",
+			"task":        base + "/tasks/{id}",
+			"health":      base + "/tasks/health",
+			"tags":        base + "/tags",
+			"board":       base + "/boards/{project}",
+			"counters":    base + "/counters",
+			"imports":     base + "/imports",
+			"jobs":        base + "/jobs/{id}",
+			"export":      base + "/export",
+			"import":      base + "/import",
+			"preferences": base + "/me/preferences",
+			"feed":        base + "/feed.json",
+			"atom":        base + "/feed.atom",
+			"openapi":     base + "/openapi.json",
+			"docs":        base + "/docs/",
+		},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	json.NewEncoder(w).Encode(doc)
+}
+
+// authModes names how clients authenticate: "bearer" API keys once users are configured
+func authModes() []string {
+	if len(users) > 0 {
+		return []string{"bearer"}
+	}
+	return []string{"none"}
+}
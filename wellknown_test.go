@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWellKnown(t *testing.T) {
+	t.Parallel()
+	server, client := StartTestServer(t)
+
+	status, body := client.Get("/.well-known/tasktracker")
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want %d", status, http.StatusOK)
+	}
+	var doc DiscoveryDocument
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("invalid discovery document: %v", err)
+	}
+	if doc.APIVersion != apiVersion || doc.Service != "task-tracker" {
+		t.Errorf("got service %q version %q", doc.Service, doc.APIVersion)
+	}
+	// Endpoints are absolute, so a client needs nothing but the base URL
+	if got, want := doc.Endpoints["tasks"], server.URL+"/tasks"; got != want {
+		t.Errorf("tasks endpoint = %q, want %q", got, want)
+	}
+	// Every advertised endpoint without a placeholder is actually routed
+	for name, url := range doc.Endpoints {
+		if strings.Contains(url, "{") {
+			continue
+		}
+		if status, _ := client.Get(url[len(server.URL):]); status == http.StatusNotFound {
+			t.Errorf("endpoint %s (%s) is not routed", name, url)
+		}
+	}
+}